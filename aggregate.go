@@ -0,0 +1,149 @@
+package trace
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+)
+
+// aggregateBounds are the upper bounds of the latency buckets of
+// WithAggregate, the last bucket holding the durations above them.
+var aggregateBounds = []time.Duration{
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// funcAggregate accumulates the spans of one function.
+type funcAggregate struct {
+	count   uint64
+	errors  uint64
+	total   time.Duration
+	max     time.Duration
+	buckets []uint64 // len(aggregateBounds)+1
+}
+
+// aggregate holds the functions of the traces logged with WithAggregate.
+var aggregate = struct {
+	mux   sync.Mutex
+	funcs map[string]*funcAggregate
+}{funcs: map[string]*funcAggregate{}}
+
+// Bucket is a bucket of a latency histogram: the number of spans that took
+// at most Le, and more than the bound of the previous bucket. Le is 0 for
+// the last bucket, which has no bound.
+type Bucket struct {
+	Le    time.Duration `json:"le"`
+	Count uint64        `json:"count"`
+}
+
+// FuncReport sums up the spans of a function across the traces logged with
+// WithAggregate. The percentiles are estimated from the histogram: they are
+// the bound of the bucket they fall in, at most Max.
+type FuncReport struct {
+	Func      string        `json:"func"`
+	Count     uint64        `json:"count"`
+	Errors    uint64        `json:"errors"`
+	ErrorRate float64       `json:"error_rate"`
+	Mean      time.Duration `json:"mean"`
+	Max       time.Duration `json:"max"`
+	P50       time.Duration `json:"p50"`
+	P90       time.Duration `json:"p90"`
+	P99       time.Duration `json:"p99"`
+	Buckets   []Bucket      `json:"buckets"`
+}
+
+// aggregateSpans adds the finished spans of the subtree to the aggregate.
+func aggregateSpans(s *spanSnapshot) {
+	aggregate.mux.Lock()
+	defer aggregate.mux.Unlock()
+	s.aggregate()
+}
+
+func (s *spanSnapshot) aggregate() {
+	if !s.running && !s.start.IsZero() {
+		f := aggregate.funcs[s.funcName]
+		if f == nil {
+			f = &funcAggregate{buckets: make([]uint64, len(aggregateBounds)+1)}
+			aggregate.funcs[s.funcName] = f
+		}
+		d := s.duration()
+		f.count++
+		if s.status == StatusError {
+			f.errors++
+		}
+		f.total += d
+		if d > f.max {
+			f.max = d
+		}
+		f.buckets[sort.Search(len(aggregateBounds), func(i int) bool { return d <= aggregateBounds[i] })]++
+	}
+	for _, v := range s.children {
+		v.aggregate()
+	}
+}
+
+// AggregateReport returns the latency and errors of every function across
+// the traces logged with WithAggregate so far, sorted by name.
+func AggregateReport() []FuncReport {
+	aggregate.mux.Lock()
+	defer aggregate.mux.Unlock()
+	res := make([]FuncReport, 0, len(aggregate.funcs))
+	for name, f := range aggregate.funcs {
+		r := FuncReport{
+			Func:      name,
+			Count:     f.count,
+			Errors:    f.errors,
+			ErrorRate: float64(f.errors) / float64(f.count),
+			Mean:      f.total / time.Duration(f.count),
+			Max:       f.max,
+			Buckets:   make([]Bucket, len(f.buckets)),
+		}
+		for i, n := range f.buckets {
+			r.Buckets[i].Count = n
+			if i < len(aggregateBounds) {
+				r.Buckets[i].Le = aggregateBounds[i]
+			}
+		}
+		r.P50, r.P90, r.P99 = f.percentile(0.5), f.percentile(0.9), f.percentile(0.99)
+		res = append(res, r)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Func < res[j].Func })
+	return res
+}
+
+// percentile estimates the q-th quantile of the durations.
+func (f *funcAggregate) percentile(q float64) time.Duration {
+	rank := uint64(q*float64(f.count) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var n uint64
+	for i, v := range f.buckets {
+		if n += v; n < rank {
+			continue
+		}
+		if i < len(aggregateBounds) && aggregateBounds[i] < f.max {
+			return aggregateBounds[i]
+		}
+		break
+	}
+	return f.max
+}
+
+// ResetAggregate forgets the functions aggregated so far.
+func ResetAggregate() {
+	aggregate.mux.Lock()
+	defer aggregate.mux.Unlock()
+	aggregate.funcs = map[string]*funcAggregate{}
+}
+
+// PublishAggregate exports AggregateReport as the expvar name, to be served
+// at /debug/vars. Like expvar.Publish, it panics if name is already taken.
+func PublishAggregate(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return AggregateReport()
+	}))
+}
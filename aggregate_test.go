@@ -0,0 +1,62 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+)
+
+func fetch(tc *TraceContext, clock *stepClock, d time.Duration, fail bool) {
+	tc = tc.Trace()
+	defer tc.End()
+	clock.now = clock.now.Add(d)
+	if fail {
+		_ = tc.Error("timeout")
+	}
+}
+
+func TestWithAggregate(t *testing.T) {
+	ResetAggregate()
+	defer ResetAggregate()
+	clock := &stepClock{now: time.Unix(1700000000, 0)}
+	for i := 0; i < 10; i++ {
+		tc := NewTraceContext(context.Background(), nil, WithClock(clock), WithAggregate())
+		fetch(tc, clock, time.Duration(i+1)*time.Millisecond, i == 9)
+		tc.End()
+		tc.Log()
+	}
+	done := make(chan struct{})
+	defer close(done)
+	running := NewTraceContext(context.Background(), nil, WithAggregate())
+	running.Go(func(child *TraceContext) { <-done })
+	running.Log()
+
+	report := AggregateReport()
+	if len(report) != 2 {
+		t.Fatalf("want the test and fetch, got %+v", report)
+	}
+	r := report[1]
+	if r.Func != "github.com/mucolud/trace.fetch" || r.Count != 10 || r.Errors != 1 || r.ErrorRate != 0.1 {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if r.Mean != 5500*time.Microsecond || r.Max != 10*time.Millisecond {
+		t.Fatalf("unexpected mean %v or max %v", r.Mean, r.Max)
+	}
+	if r.P50 != 5*time.Millisecond || r.P90 != 10*time.Millisecond || r.P99 != 10*time.Millisecond {
+		t.Fatalf("unexpected percentiles %v %v %v", r.P50, r.P90, r.P99)
+	}
+	if r.Buckets[0] != (Bucket{Le: time.Millisecond, Count: 1}) || r.Buckets[3].Count != 5 {
+		t.Fatalf("unexpected buckets %+v", r.Buckets)
+	}
+	if report[0].Count != 11 {
+		t.Fatalf("running spans should be left out: %+v", report[0])
+	}
+
+	PublishAggregate("trace_aggregate_test")
+	var published []FuncReport
+	if err := json.Unmarshal([]byte(expvar.Get("trace_aggregate_test").String()), &published); err != nil || len(published) != 2 {
+		t.Fatalf("the report should be published: %v %+v", err, published)
+	}
+}
@@ -0,0 +1,69 @@
+package trace
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Arg is a named argument of the function a span traces.
+type Arg struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// Args records the arguments the span's function was called with, as
+// name/value pairs in the order of the signature. They are attributes of
+// the span rather than info nodes, rendered next to its name:
+//
+//	func A(tc *trace.TraceContext, name string, age int) {
+//		tc = tc.Trace().Args("name", name, "age", age)
+//
+// prints "pkg.A(name="bob", age=42)". Values are rendered like params.
+func (tc *TraceContext) Args(kv ...interface{}) *TraceContext {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.args = append(tc.args, kv...)
+	return tc
+}
+
+// renderArgs turns recorded name/value pairs into Args ready to be
+// serialized. Only the values count against limits.
+func (tc *TraceContext) renderArgs(kv []interface{}, limits *sizeLimits) []Arg {
+	if len(kv) == 0 {
+		return nil
+	}
+	names, values := tc.renderPairs(kv)
+	limits.apply(values, 0, 1)
+	res := make([]Arg, 0, len(names))
+	for i, name := range names {
+		res = append(res, Arg{Name: name, Value: values[i]})
+	}
+	return res
+}
+
+// flattenArgs turns args back into the name/value list Args records.
+func flattenArgs(args []Arg) []interface{} {
+	res := make([]interface{}, 0, 2*len(args))
+	for _, v := range args {
+		res = append(res, v.Name, v.Value)
+	}
+	return res
+}
+
+// formatArgs renders the arguments after the span name.
+func (s *spanSnapshot) formatArgs() string {
+	if len(s.args) == 0 {
+		return ""
+	}
+	var str = &strings.Builder{}
+	str.WriteString("(")
+	for i, v := range s.args {
+		if i > 0 {
+			str.WriteString(", ")
+		}
+		res, _ := json.Marshal(v.Value)
+		str.WriteString(v.Name + "=" + string(res))
+	}
+	str.WriteString(")")
+	return str.String()
+}
@@ -0,0 +1,60 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func greet(tc *TraceContext, name string, age int) {
+	tc = tc.Trace().Args("name", name, "age", age)
+	tc.Info("hello")
+}
+
+func TestTraceContext_Args(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	greet(tc, "张三", 18)
+	tc.Trace().Args("empty")
+	tc.Log()
+
+	if !strings.Contains(buf.String(), `trace.greet(name="张三", age=18)`+"\n") {
+		t.Fatalf("args should be rendered next to the span name: %s", buf.String())
+	}
+	if strings.Count(buf.String(), `"hello"`) != 1 || strings.Contains(buf.String(), `["name"`) {
+		t.Fatalf("args should not be info nodes: %s", buf.String())
+	}
+
+	data := tc.Snapshot()
+	want := []Arg{{"name", "张三"}, {"age", 18}}
+	if !reflect.DeepEqual(data.Children[0].Args, want) {
+		t.Fatalf("unexpected args %+v", data.Children[0].Args)
+	}
+	if got := data.Children[1].Args; len(got) != 1 || got[0] != (Arg{Name: "empty"}) {
+		t.Fatalf("a missing value should be nil: %+v", got)
+	}
+	b, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back TraceData
+	if err := back.Unmarshal(b); err != nil || !reflect.DeepEqual(back.Children[0].Args, want) {
+		t.Fatalf("args should round trip: %v %+v", err, back.Children[0].Args)
+	}
+}
+
+func TestTraceContext_ArgsRedacted(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithRedactor(DefaultRedactor))
+	tc.Args("user", "ann", "token", "xyz")
+	tc.Log()
+
+	if got := tc.Snapshot().Args; !reflect.DeepEqual(got, []Arg{{"user", "ann"}, {"token", "***"}}) {
+		t.Fatalf("the value of a secret arg should be masked: %v", got)
+	}
+	if strings.Contains(buf.String(), "xyz") {
+		t.Fatalf("secret arg printed:\n%s", buf.String())
+	}
+}
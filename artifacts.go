@@ -0,0 +1,92 @@
+package trace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// artifactDir is the scratch directory shared by all spans of a trace.
+type artifactDir struct {
+	mux  sync.Mutex
+	dir  string
+	keep bool
+}
+
+// Artifacts manages files a trace produces, such as intermediate exports of
+// a job. Files live in a scratch directory owned by the trace, are recorded
+// as "artifact" events on the span they were created from, and are removed
+// once the trace is logged unless Keep was called.
+type Artifacts struct {
+	tc  *TraceContext
+	dir *artifactDir
+}
+
+// Artifacts returns the artifact manager of the trace, recording on tc.
+func (tc *TraceContext) Artifacts() *Artifacts {
+	tc.state.mux.Lock()
+	defer tc.state.mux.Unlock()
+	if tc.state.artifacts == nil {
+		tc.state.artifacts = &artifactDir{}
+	}
+	return &Artifacts{tc: tc, dir: tc.state.artifacts}
+}
+
+// Dir returns the scratch directory, creating it on first use.
+func (a *Artifacts) Dir() (string, error) {
+	a.dir.mux.Lock()
+	defer a.dir.mux.Unlock()
+	if a.dir.dir == "" {
+		dir, err := ioutil.TempDir("", "trace-"+a.tc.TraceID()+"-")
+		if err != nil {
+			return "", err
+		}
+		a.dir.dir = dir
+	}
+	return a.dir.dir, nil
+}
+
+// Create creates the file name in the scratch directory and records it.
+func (a *Artifacts) Create(name string) (*os.File, error) {
+	dir, err := a.Dir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, filepath.Base(name))
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	a.tc.Event("artifact", "name", filepath.Base(name), "path", path)
+	return f, nil
+}
+
+// Add records a file created elsewhere as an artifact of the span. It is
+// not removed on cleanup.
+func (a *Artifacts) Add(path string) {
+	a.tc.Event("artifact", "name", filepath.Base(path), "path", path)
+}
+
+// Keep leaves the scratch directory in place after the trace is logged,
+// e.g. to investigate a failed run.
+func (a *Artifacts) Keep() {
+	a.dir.mux.Lock()
+	defer a.dir.mux.Unlock()
+	a.dir.keep = true
+}
+
+// cleanup removes the scratch directory unless it is kept.
+func (d *artifactDir) cleanup() error {
+	if d == nil {
+		return nil
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if d.keep || d.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(d.dir)
+	d.dir = ""
+	return err
+}
@@ -0,0 +1,41 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_Artifacts(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	child := tc.Trace()
+	f, err := child.Artifacts().Create("report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	dir, _ := tc.Artifacts().Dir()
+	if !strings.HasPrefix(f.Name(), dir) {
+		t.Fatalf("spans of one trace must share the scratch directory")
+	}
+
+	tc.Log()
+	if !strings.Contains(buf.String(), `artifact {"name":"report.csv","path":"`+f.Name()+`"}`) {
+		t.Fatalf("artifact not recorded on the span: %s", buf.String())
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("scratch directory should be removed after Log")
+	}
+
+	tc = NewTraceContext(context.Background(), nil)
+	tc.Artifacts().Keep()
+	dir, _ = tc.Artifacts().Dir()
+	tc.Log()
+	defer os.RemoveAll(dir)
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("kept directory should survive Log: %v", err)
+	}
+}
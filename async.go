@@ -0,0 +1,125 @@
+package trace
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrDropped is returned by the writes of an AsyncWriter whose queue is
+	// full. Log counts them as trace_dropped_total instead of failures.
+	ErrDropped = errors.New("trace: writer queue full, trace dropped")
+	// ErrWriteTimeout is returned by Log when a sink did not take the trace
+	// within WithWriteTimeout.
+	ErrWriteTimeout = errors.New("trace: write timed out")
+)
+
+// AsyncWriter hands writes to a background goroutine through a bounded
+// queue, so that Log never blocks on a slow sink such as a network writer.
+// Writes arriving while the queue is full are dropped.
+type AsyncWriter struct {
+	w       io.Writer
+	queue   chan []byte
+	done    chan struct{}
+	dropped uint64
+	failed  uint64
+	once    sync.Once
+}
+
+// NewAsyncWriter returns an AsyncWriter writing to w with room for size
+// pending writes. It can be wrapped by Formatted or MultiLogger like any
+// sink.
+func NewAsyncWriter(w io.Writer, size int) *AsyncWriter {
+	a := &AsyncWriter{w: w, queue: make(chan []byte, size), done: make(chan struct{})}
+	go a.run()
+	return a
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+	for p := range a.queue {
+		if _, err := a.w.Write(p); err != nil {
+			atomic.AddUint64(&a.failed, 1)
+		}
+	}
+}
+
+// Write queues a copy of p, or returns ErrDropped if the queue is full.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	b := append([]byte(nil), p...)
+	select {
+	case a.queue <- b:
+		return len(p), nil
+	default:
+		atomic.AddUint64(&a.dropped, 1)
+		return 0, ErrDropped
+	}
+}
+
+// Dropped returns the number of writes dropped because the queue was full.
+func (a *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Failed returns the number of queued writes the underlying writer failed.
+func (a *AsyncWriter) Failed() uint64 {
+	return atomic.LoadUint64(&a.failed)
+}
+
+// Close writes the queued writes and stops the background goroutine. No
+// write may follow.
+func (a *AsyncWriter) Close() error {
+	a.once.Do(func() {
+		close(a.queue)
+	})
+	<-a.done
+	return nil
+}
+
+// timeoutWriter gives up on writes taking longer than timeout. The write
+// goes on in the background, p must not be reused.
+type timeoutWriter struct {
+	w       io.Writer
+	timeout time.Duration
+}
+
+func (t timeoutWriter) Write(p []byte) (int, error) {
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := t.w.Write(p)
+		done <- result{n, err}
+	}()
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-timer.C:
+		return 0, ErrWriteTimeout
+	}
+}
+
+// sinkWriter returns w guarded by WithWriteTimeout, if set.
+func (tc *TraceContext) sinkWriter(w io.Writer) io.Writer {
+	if d := tc.opts.writeTimeout; d > 0 {
+		if _, ok := w.(*AsyncWriter); !ok {
+			return timeoutWriter{w: w, timeout: d}
+		}
+	}
+	return w
+}
+
+// countDropped reports a trace a sink dropped.
+func (tc *TraceContext) countDropped() {
+	atomic.AddUint64(&stats.Dropped, 1)
+	if m := tc.opts.metrics; m != nil {
+		m.Count("trace_dropped_total", 1)
+	}
+}
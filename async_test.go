@@ -0,0 +1,73 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockWriter blocks every write until release is closed.
+type blockWriter struct {
+	release chan struct{}
+}
+
+func (b blockWriter) Write(p []byte) (int, error) {
+	<-b.release
+	return len(p), nil
+}
+
+func TestAsyncWriter(t *testing.T) {
+	block := blockWriter{release: make(chan struct{})}
+	buf := &bytes.Buffer{}
+	async := NewAsyncWriter(MultiLogger(block, buf), 1)
+	var dropped int64
+	metrics := WithMetrics(MetricsFunc(func(name string, delta int64, labels ...string) {
+		if name == "trace_dropped_total" {
+			dropped += delta
+		}
+	}))
+	before := ReadStats().Dropped
+	for i := 0; i < 4; i++ {
+		tc := NewTraceContext(context.Background(), Formatted(async, FormatJSON), metrics)
+		tc.Info("n", i)
+		if err := tc.LogE(); err != nil {
+			t.Fatalf("drops should not fail Log: %v", err)
+		}
+		for i == 0 && len(async.queue) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	// the first write is blocked in the writer, the second is queued
+	if async.Dropped() != 2 || dropped != 2 || ReadStats().Dropped-before != 2 {
+		t.Fatalf("full queue should drop: %d %d", async.Dropped(), dropped)
+	}
+	close(block.release)
+	if err := async.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 2 || !strings.Contains(buf.String(), `["n",0]`) {
+		t.Fatalf("queued traces should be written: %s", buf.String())
+	}
+}
+
+func TestWithWriteTimeout(t *testing.T) {
+	block := blockWriter{release: make(chan struct{})}
+	defer close(block.release)
+	fallback := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), block, WithWriteTimeout(10*time.Millisecond), WithFallbackLogger(fallback))
+	tc.Info("stuck")
+	if err := tc.LogE(); err != ErrWriteTimeout {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if !strings.Contains(fallback.String(), `["stuck"]`) {
+		t.Fatalf("timed out trace should go to the fallback logger: %s", fallback.String())
+	}
+
+	buf := &bytes.Buffer{}
+	tc = NewTraceContext(context.Background(), buf, WithWriteTimeout(time.Second))
+	if err := tc.LogE(); err != nil || buf.Len() == 0 {
+		t.Fatalf("fast writes should go through: %v", err)
+	}
+}
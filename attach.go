@@ -0,0 +1,75 @@
+package trace
+
+import (
+	"encoding/base64"
+	"strconv"
+	"time"
+)
+
+// defaultAttachmentLimit is the number of bytes kept of an attachment
+// without WithAttachmentLimit.
+const defaultAttachmentLimit = 64 << 10
+
+// Attachment is a payload attached to a span with Attach, such as a request
+// body or a generated SQL query. Data is encoded as base64 in JSON.
+type Attachment struct {
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type,omitempty"`
+	Time        time.Time `json:"time"`
+	Size        int       `json:"size"` // of the payload, Data may hold less
+	Data        []byte    `json:"data,omitempty"`
+}
+
+// Truncated reports whether Data was cut to the limit of WithAttachmentLimit.
+func (a Attachment) Truncated() bool {
+	return len(a.Data) < a.Size
+}
+
+// Attach attaches a copy of data to the span under name, cut to the limit
+// of WithAttachmentLimit. The tree only refers to it by name, type and
+// size; the payload is part of the structured formats, such as TraceData,
+// FormatProtobuf and FormatOTLP.
+func (tc *TraceContext) Attach(name string, data []byte, contentType string) {
+	a := Attachment{Name: name, ContentType: contentType, Time: tc.now(), Size: len(data)}
+	if limit := tc.attachmentLimit(); len(data) > limit {
+		data = data[:limit]
+	}
+	a.Data = append([]byte(nil), data...)
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.attachments = append(tc.attachments, a)
+}
+
+func (tc *TraceContext) attachmentLimit() int {
+	if n := tc.opts.attachmentLimit; n > 0 {
+		return n
+	}
+	return defaultAttachmentLimit
+}
+
+// formatAttachment renders the reference to a in the tree.
+func formatAttachment(a Attachment) string {
+	res := a.Name
+	if a.ContentType != "" {
+		res += " " + a.ContentType
+	}
+	res += " " + strconv.Itoa(a.Size) + " bytes"
+	if a.Truncated() {
+		res += " (" + strconv.Itoa(len(a.Data)) + " kept)"
+	}
+	return res
+}
+
+// otlpEvent converts a to an "attachment" event carrying the payload.
+func (a Attachment) otlpEvent() otlpEvent {
+	return otlpEvent{
+		TimeUnixNano: otlpTime(a.Time),
+		Name:         "attachment",
+		Attributes: []otlpKeyValue{
+			otlpAttr("attachment.name", a.Name),
+			otlpAttr("attachment.content_type", a.ContentType),
+			otlpAttr("attachment.size", a.Size),
+			otlpAttr("attachment.data", base64.StdEncoding.EncodeToString(a.Data)),
+		},
+	}
+}
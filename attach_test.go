@@ -0,0 +1,47 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_Attach(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithAttachmentLimit(8))
+	body := []byte(`{"user":42}`)
+	tc.Trace().Attach("request", body, "application/json")
+	body[0] = 'x'
+	tc.Log()
+
+	if !strings.Contains(buf.String(), "├# attachment request application/json 11 bytes (8 kept)\n") {
+		t.Fatalf("the tree should refer to the attachment: %s", buf.String())
+	}
+	if strings.Contains(buf.String(), `"user"`) || strings.Contains(buf.String(), "eyJ1") {
+		t.Fatalf("the payload should stay out of the tree: %s", buf.String())
+	}
+
+	data := tc.Snapshot()
+	a := data.Children[0].Attachments
+	if len(a) != 1 || string(a[0].Data) != `{"user":` || a[0].Size != 11 || !a[0].Truncated() {
+		t.Fatalf("unexpected attachments %+v", a)
+	}
+	b, _ := json.Marshal(a[0])
+	if !strings.Contains(string(b), `"data":"eyJ1c2VyIjo="`) {
+		t.Fatalf("the payload should be base64 in JSON: %s", b)
+	}
+	wire, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back TraceData
+	if err := back.Unmarshal(wire); err != nil {
+		t.Fatal(err)
+	}
+	if got := back.Children[0].Attachments; len(got) != 1 || got[0].Name != "request" || string(got[0].Data) != `{"user":` ||
+		got[0].Size != 11 || !got[0].Time.Equal(a[0].Time) {
+		t.Fatalf("attachments should round trip: %+v", got)
+	}
+}
@@ -0,0 +1,77 @@
+package trace
+
+import "time"
+
+// Tags set on the spans created by Attempt.
+const (
+	AttemptTag = "retry.attempt"
+	BackoffTag = "retry.backoff"
+	OutcomeTag = "retry.outcome"
+)
+
+// Attempt creates a child span for the n-th attempt, counting from 1, of a
+// retried call, so that every attempt shows as a sibling named after the
+// caller. The span is tagged with n and, once a previous attempt ended,
+// with the backoff waited since. End it with EndAttempt.
+//
+//	for n := 1; ; n++ {
+//		attempt := tc.Attempt(n)
+//		err := call(attempt)
+//		if attempt.EndAttempt(err) == nil || n == 3 {
+//			return err
+//		}
+//		time.Sleep(backoff)
+//	}
+func (tc *TraceContext) Attempt(n int, opts ...SpanOption) *TraceContext {
+	return tc.attempt(2, n, opts)
+}
+
+// attempt creates an attempt span named after the caller skip frames up.
+func (tc *TraceContext) attempt(skip int, n int, opts []SpanOption) *TraceContext {
+	prev := tc.lastAttempt()
+	span := tc.trace(skip+1, opts)
+	span.SetTag(AttemptTag, n)
+	if prev != nil {
+		prev.mux.Lock()
+		end := prev.end
+		prev.mux.Unlock()
+		if !end.IsZero() {
+			span.SetTag(BackoffTag, span.start.Sub(end).Round(time.Microsecond))
+		}
+	}
+	return span
+}
+
+// lastAttempt returns the latest child created by Attempt, nil if none.
+func (tc *TraceContext) lastAttempt() *TraceContext {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	for i := len(tc.children) - 1; i >= 0; i-- {
+		child := tc.children[i]
+		child.mux.Lock()
+		_, ok := child.tags[AttemptTag]
+		child.mux.Unlock()
+		if ok {
+			return child
+		}
+	}
+	return nil
+}
+
+// EndAttempt records the outcome of an attempt created by Attempt and ends
+// it: err, if not nil, is recorded as an error of the span. It returns err.
+func (tc *TraceContext) EndAttempt(err error) error {
+	return tc.endAttempt(2, err)
+}
+
+// endAttempt ends the attempt, attributing err to the caller skip frames up.
+func (tc *TraceContext) endAttempt(skip int, err error) error {
+	if err != nil {
+		_ = tc.error(skip+1, 0, []interface{}{err})
+		tc.SetTag(OutcomeTag, "error")
+	} else {
+		tc.SetTag(OutcomeTag, "ok")
+	}
+	tc.End()
+	return err
+}
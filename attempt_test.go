@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTraceContext_Attempt(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithClock(clock))
+	for n := 1; n <= 3; n++ {
+		attempt := tc.Attempt(n)
+		var err error
+		if n < 3 {
+			err = errors.New("unavailable")
+		}
+		if attempt.EndAttempt(err) == nil {
+			break
+		}
+		clock.now = clock.now.Add(100 * time.Millisecond)
+	}
+	tc.Log()
+
+	data := tc.Snapshot()
+	if len(data.Children) != 3 {
+		t.Fatalf("every attempt should be a sibling span: %+v", data.Children)
+	}
+	for i, child := range data.Children {
+		if child.Tags[AttemptTag] != i+1 {
+			t.Fatalf("attempt %d tagged %v", i+1, child.Tags[AttemptTag])
+		}
+	}
+	if _, ok := data.Children[0].Tags[BackoffTag]; ok {
+		t.Fatalf("the first attempt has no backoff")
+	}
+	if data.Children[1].Tags[BackoffTag] != 100*time.Millisecond {
+		t.Fatalf("backoff should be timed: %v", data.Children[1].Tags[BackoffTag])
+	}
+	if data.Children[1].Tags[OutcomeTag] != "error" || data.Children[2].Tags[OutcomeTag] != "ok" {
+		t.Fatalf("outcomes should be recorded: %+v", data.Children)
+	}
+	if !strings.Contains(buf.String(), "retry.backoff=100ms") || strings.Count(buf.String(), `["unavailable"]`) != 2 {
+		t.Fatalf("attempts should be in the tree: %s", buf.String())
+	}
+}
@@ -0,0 +1,148 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+// The benchmarks are laid out for benchstat:
+//
+//	go test -run '^$' -bench . -benchmem -count 10 > new.txt
+//	benchstat old.txt new.txt
+//
+// See BENCHMARKS.md for the baseline numbers.
+
+func record(tc *TraceContext) {
+	tc.Info("user", 42)
+	child := tc.Trace()
+	child.Info("query", "select 1")
+	_ = child.Error(errors.New("timeout"))
+	tc.Trace().Trace().Info("nested")
+}
+
+func BenchmarkRecord(b *testing.B) {
+	err := errors.New("timeout")
+	b.Run("info", func(b *testing.B) {
+		tc := NewTraceContext(context.Background(), ioutil.Discard)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tc.Info("user", 42)
+			if len(tc.infos) == 1024 {
+				tc.infos = tc.infos[:0]
+			}
+		}
+	})
+	b.Run("error", func(b *testing.B) {
+		tc := NewTraceContext(context.Background(), ioutil.Discard)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = tc.Error("query failed", err)
+			if len(tc.errors) == 1024 {
+				tc.errors = tc.errors[:0]
+			}
+		}
+	})
+	b.Run("event", func(b *testing.B) {
+		tc := NewTraceContext(context.Background(), ioutil.Discard)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tc.Event("cache miss", "key", "user:1")
+			if len(tc.events) == 1024 {
+				tc.events = tc.events[:0]
+			}
+		}
+	})
+}
+
+func BenchmarkConvert(b *testing.B) {
+	tc := NewTraceContext(context.Background(), ioutil.Discard)
+	cases := []struct {
+		name   string
+		params []interface{}
+	}{
+		{"string", []interface{}{"query failed"}},
+		{"int", []interface{}{42}},
+		{"error", []interface{}{errors.New("timeout")}},
+		{"struct", []interface{}{struct{ ID int }{42}}},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = tc.convertToError(c.params)
+			}
+		})
+	}
+}
+
+func BenchmarkChild(b *testing.B) {
+	b.Run("trace", func(b *testing.B) {
+		tc := NewTraceContext(context.Background(), ioutil.Discard)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tc.Trace()
+			if len(tc.children) == 1024 {
+				tc.children = tc.children[:0]
+			}
+		}
+	})
+}
+
+func BenchmarkLog(b *testing.B) {
+	tc := NewTraceContext(context.Background(), ioutil.Discard)
+	for i := 0; i < 10; i++ {
+		record(tc.Trace())
+	}
+	b.Run("tree", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tc.Log()
+		}
+	})
+	b.Run("writeto", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = tc.WriteTo(ioutil.Discard)
+		}
+	})
+	b.Run("tree+flat", func(b *testing.B) {
+		tc.opts.flatLogger = ioutil.Discard
+		defer func() { tc.opts.flatLogger = nil }()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tc.Log()
+		}
+	})
+}
+
+func BenchmarkLifecycle(b *testing.B) {
+	b.Run("gc", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tc := NewTraceContext(context.Background(), ioutil.Discard)
+			record(tc)
+		}
+	})
+	b.Run("release", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tc := NewTraceContext(context.Background(), ioutil.Discard)
+			record(tc)
+			tc.Release()
+		}
+	})
+}
+
+func BenchmarkConcurrentRoots(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			tc := NewTraceContext(context.Background(), ioutil.Discard)
+			record(tc)
+			tc.Log()
+			tc.Release()
+		}
+	})
+}
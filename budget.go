@@ -0,0 +1,38 @@
+package trace
+
+import "time"
+
+// Remaining returns the time left before the deadline of the span's
+// context, negative once it passed, and false if the context has no
+// deadline.
+func (tc *TraceContext) Remaining() (time.Duration, bool) {
+	deadline, ok := tc.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// remaining is Remaining as recorded on spans, 0 without a deadline.
+func remaining(ctx interface{ Deadline() (time.Time, bool) }) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	if d := time.Until(deadline); d != 0 {
+		return d
+	}
+	return -1 // exactly exhausted, still not "no deadline"
+}
+
+// formatBudget renders the deadline budget the span started with.
+func (s *spanSnapshot) formatBudget() string {
+	if s.budget == 0 {
+		return ""
+	}
+	d := s.budget
+	if d >= time.Millisecond || d <= -time.Millisecond {
+		d = d.Round(time.Millisecond)
+	}
+	return " (budget " + d.String() + ")"
+}
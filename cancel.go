@@ -0,0 +1,81 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errSpanDone is the cause of the contexts released by the CancelFunc of
+// WithCancel and WithTimeout: the work finished, nothing was cancelled.
+var errSpanDone = errors.New("trace: span done")
+
+// WithCancel returns a child span whose context is cancelled when cancel is
+// called or the context of tc is done, like context.WithCancel. Calling
+// cancel also ends the span. Releasing the context this way once the work
+// is over is not reported as a cancellation.
+func (tc *TraceContext) WithCancel(opts ...SpanOption) (*TraceContext, context.CancelFunc) {
+	span, cancel := withCancel(tc.trace(2, opts), 0)
+	return span, func() { cancel(nil) }
+}
+
+// WithTimeout returns a child span whose context is done after d, like
+// context.WithTimeout. See WithCancel for cancel.
+func (tc *TraceContext) WithTimeout(d time.Duration, opts ...SpanOption) (*TraceContext, context.CancelFunc) {
+	span, cancel := withCancel(tc.trace(2, opts), d)
+	return span, func() { cancel(nil) }
+}
+
+// WithCancelCause is WithCancel with a cancel taking the cause, like
+// context.WithCancelCause: cancel(err) cancels the context of the span with
+// err, rendered as the cause, and leaves the span running; cancel(nil)
+// releases the context and ends the span as the cancel of WithCancel does.
+func (tc *TraceContext) WithCancelCause(opts ...SpanOption) (*TraceContext, context.CancelCauseFunc) {
+	return withCancel(tc.trace(2, opts), 0)
+}
+
+func withCancel(span *TraceContext, timeout time.Duration) (*TraceContext, context.CancelCauseFunc) {
+	ctx, cancelCause := context.WithCancelCause(span.context())
+	cancel := func() {}
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	span.mux.Lock()
+	span.Context = ctx
+	span.budget = remaining(ctx)
+	span.mux.Unlock()
+	return span, func(cause error) {
+		if cause != nil {
+			cancelCause(cause)
+			return
+		}
+		span.End()
+		cancelCause(errSpanDone)
+		cancel()
+	}
+}
+
+// spanCause returns why the context of span was done when it ended, or is
+// done now if it did not end: a span ending before the cancellation was
+// not cut short by it.
+func spanCause(span *TraceContext) error {
+	span.mux.Lock()
+	ended, cause := !span.end.IsZero(), span.endCause
+	span.mux.Unlock()
+	if ended {
+		return cause
+	}
+	return cancelCause(span)
+}
+
+// cancelCause returns why the context of span is done, or nil if it is not
+// or was released by the CancelFunc of WithCancel or WithTimeout.
+func cancelCause(span *TraceContext) error {
+	if span.Err() == nil {
+		return nil
+	}
+	if cause := context.Cause(span); cause != errSpanDone {
+		return cause
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCancelCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(ctx, buf)
+	tc.Trace().Info("work")
+	cancel(errors.New("client went away"))
+	tc.Log()
+
+	out := buf.String()
+	if !strings.Contains(out, "TestCancelCause (cancelled: client went away)\n") {
+		t.Fatalf("cause not rendered on the root: %s", out)
+	}
+	if strings.Count(out, "cancelled:") != 1 {
+		t.Fatalf("cause must only be rendered where it shows up first: %s", out)
+	}
+
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancelTimeout()
+	<-ctx.Done()
+	buf.Reset()
+	NewTraceContext(ctx, buf).Log()
+	if !strings.Contains(buf.String(), "(cancelled: context deadline exceeded)") {
+		t.Fatalf("deadline not rendered: %s", buf.String())
+	}
+}
+
+func TestCancelCause_endedSpans(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	tc := NewTraceContext(ctx, nil)
+	done := tc.Trace(WithSpanName("done"))
+	done.End()
+	cut, cutCancel := tc.WithCancelCause(WithSpanName("cut"))
+	cutCancel(errors.New("shard lost"))
+	cut.End()
+	cancel(errors.New("client went away"))
+
+	if done.Status() != StatusOK || cut.Status() != StatusCancelled || tc.Status() != StatusCancelled {
+		t.Fatalf("unexpected statuses %s %s %s", done.Status(), cut.Status(), tc.Status())
+	}
+	data := tc.Snapshot()
+	if data.Cause != "client went away" || data.Children[0].Cause != "" || data.Children[1].Cause != "shard lost" {
+		t.Fatalf("a span should keep the cause it ended with: %+v", data)
+	}
+}
+
+func TestTraceContext_WithTimeout(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	func() {
+		child, cancel := tc.WithTimeout(time.Nanosecond)
+		defer cancel()
+		<-child.Done()
+		child.Info("gave up")
+	}()
+	func() {
+		child, cancel := tc.WithCancel()
+		defer cancel()
+		child.Trace().Info("done in time")
+	}()
+	tc.Log()
+
+	out := buf.String()
+	if tc.Err() != nil || strings.Count(out, "cancelled") != 1 ||
+		!regexp.MustCompile(`func1 \(budget [^)]+\) \(cancelled: context deadline exceeded\)`).MatchString(out) {
+		t.Fatalf("only the span timing out should be cancelled: %s", out)
+	}
+	if strings.Contains(out, "running") {
+		t.Fatalf("cancel should end the spans: %s", out)
+	}
+}
+
+func TestTraceContext_WithCancelCause(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	child, cancel := tc.WithCancelCause(WithSpanName("fanout"))
+	cancel(errors.New("shard failed"))
+	if cause := context.Cause(child); cause == nil || cause.Error() != "shard failed" {
+		t.Fatalf("cancel with a cause should cancel the span: %v", cause)
+	}
+	cancel(nil)
+	tc.Log()
+	if !strings.Contains(buf.String(), "├fanout (cancelled: shard failed)") {
+		t.Fatalf("the cause should be rendered: %s", buf.String())
+	}
+}
+
+func TestTraceContext_Remaining(t *testing.T) {
+	if _, ok := NewTraceContext(context.Background(), nil).Remaining(); ok {
+		t.Fatalf("no deadline, no budget")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(ctx, buf)
+	if d, ok := tc.Remaining(); !ok || d <= 59*time.Minute || d > time.Hour {
+		t.Fatalf("unexpected remaining %v %v", d, ok)
+	}
+	child, cancelChild := tc.WithTimeout(time.Minute)
+	defer cancelChild()
+	child.Info("call")
+	tc.Log()
+
+	out := buf.String()
+	if !strings.Contains(out, "TestTraceContext_Remaining (budget 1h0m0s)\n") ||
+		!strings.Contains(out, "TestTraceContext_Remaining (budget 1m0s)\n") {
+		t.Fatalf("spans should show the budget they started with: %s", out)
+	}
+	if data := tc.Snapshot(); data.Budget <= 59*time.Minute || data.Children[0].Budget > time.Minute {
+		t.Fatalf("unexpected budgets %v %v", data.Budget, data.Children[0].Budget)
+	}
+}
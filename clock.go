@@ -0,0 +1,19 @@
+package trace
+
+import "time"
+
+// Clock is the source of time of a trace. See tracetest.Clock for a manual
+// clock to use in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (tc *TraceContext) now() time.Time {
+	return tc.opts.clock.Now()
+}
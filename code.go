@@ -0,0 +1,55 @@
+package trace
+
+import "strconv"
+
+// Category classifies failures by their error code.
+type Category string
+
+const (
+	CategoryUnknown    Category = "unknown"
+	CategoryValidation Category = "validation"
+	CategoryAuth       Category = "auth"
+	CategoryDependency Category = "dependency"
+	CategoryInternal   Category = "internal"
+)
+
+// DefaultCategory classifies HTTP status codes: 401 and 403 are auth
+// failures, other 4xx validation failures, 502 to 504 dependency failures
+// and everything else from 500 on internal.
+func DefaultCategory(code int) Category {
+	switch {
+	case code == 401 || code == 403:
+		return CategoryAuth
+	case code >= 400 && code < 500:
+		return CategoryValidation
+	case code >= 502 && code <= 504:
+		return CategoryDependency
+	case code >= 500:
+		return CategoryInternal
+	}
+	return CategoryUnknown
+}
+
+// ErrorCode records an error like Error, tagged with code and the category
+// the code belongs to (see WithCategories).
+func (tc *TraceContext) ErrorCode(code int, params ...interface{}) error {
+	return tc.error(2, code, params)
+}
+
+func (tc *TraceContext) category(code int) Category {
+	if code == 0 {
+		return ""
+	}
+	if fn := tc.opts.categories; fn != nil {
+		return fn(code)
+	}
+	return DefaultCategory(code)
+}
+
+// formatCode renders the code of an error node in the tree.
+func (n *node) formatCode() string {
+	if n.Code == 0 {
+		return ""
+	}
+	return "(" + strconv.Itoa(n.Code) + " " + string(n.Category) + ") "
+}
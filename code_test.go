@@ -0,0 +1,72 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type countMetrics struct {
+	mux    sync.Mutex
+	counts map[string]int64
+}
+
+func (m *countMetrics) Count(name string, delta int64, labels ...string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.counts[name+strings.Join(labels, ",")] += delta
+}
+
+func TestErrorCode(t *testing.T) {
+	buf := &bytes.Buffer{}
+	metrics := &countMetrics{counts: map[string]int64{}}
+	tc := NewTraceContext(context.Background(), buf, WithMetrics(metrics))
+	_ = tc.ErrorCode(422, "invalid email")
+	_ = tc.ErrorCode(503, "billing unavailable")
+	_ = tc.Error("plain")
+	tc.Go(func(*TraceContext) { panic("boom") })
+	tc.state.wait(0)
+	tc.Log()
+
+	if !strings.Contains(buf.String(), "├E (422 validation) github.com/mucolud/trace.TestErrorCode:") {
+		t.Fatalf("code not shown in the tree: %s", buf.String())
+	}
+	entries := tc.Entries()
+	if entries[0].Code != 422 || entries[0].Category != CategoryValidation ||
+		entries[1].Category != CategoryDependency || entries[2].Category != "" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	want := map[string]int64{
+		"trace_errors_totalcategory,validation,code,422": 1,
+		"trace_errors_totalcategory,dependency,code,503": 1,
+		"trace_errors_totalcategory,unknown,code,0":      1,
+		"trace_errors_totalcategory,internal,code,0":     1,
+	}
+	for k, v := range want {
+		if metrics.counts[k] != v {
+			t.Fatalf("counter %s = %d, all: %v", k, metrics.counts[k], metrics.counts)
+		}
+	}
+
+	tc = NewTraceContext(context.Background(), nil, WithCategories(func(code int) Category {
+		return CategoryAuth
+	}))
+	_ = tc.ErrorCode(7, "expired token")
+	if e := tc.Entries()[0]; e.Category != CategoryAuth {
+		t.Fatalf("custom categories not applied: %+v", e)
+	}
+}
+
+func TestDefaultCategory(t *testing.T) {
+	cases := map[int]Category{
+		0: CategoryUnknown, 400: CategoryValidation, 401: CategoryAuth, 403: CategoryAuth,
+		404: CategoryValidation, 500: CategoryInternal, 503: CategoryDependency, 42: CategoryUnknown,
+	}
+	for code, want := range cases {
+		if got := DefaultCategory(code); got != want {
+			t.Errorf("DefaultCategory(%d) = %s, want %s", code, got, want)
+		}
+	}
+}
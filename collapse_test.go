@@ -0,0 +1,46 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stepClock struct {
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	return c.now
+}
+
+func loadProfile(tc *TraceContext, clock *stepClock) {
+	tc.Info("profile")
+	tc.Trace().Info("avatar")
+	clock.now = clock.now.Add(12 * time.Millisecond)
+	tc.End()
+}
+
+func saveProfile(tc *TraceContext, clock *stepClock) {
+	clock.now = clock.now.Add(time.Millisecond)
+	_ = tc.Error("conflict")
+}
+
+func TestWithCollapse(t *testing.T) {
+	clock := &stepClock{now: time.Unix(0, 0)}
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithClock(clock), WithCollapse(50*time.Millisecond))
+	loadProfile(tc.Trace(), clock)
+	saveProfile(tc.Trace(), clock)
+	tc.Log()
+
+	out := buf.String()
+	if !strings.Contains(out, "├✓ github.com/mucolud/trace.TestWithCollapse 2 spans 12ms\n") {
+		t.Fatalf("healthy subtree should be collapsed: %s", out)
+	}
+	if strings.Contains(out, "avatar") || !strings.Contains(out, `"conflict"`) {
+		t.Fatalf("failing subtree should be rendered in full: %s", out)
+	}
+}
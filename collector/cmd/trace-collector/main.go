@@ -0,0 +1,48 @@
+// Command trace-collector receives the traces of many services, keeps the
+// most recent ones in memory to browse them in traceui and writes all of
+// them to stdout.
+//
+//	trace-collector -addr :4319 -size 10000
+//
+// Services post their traces to /v1/traces, see collector.NewClient. The UI
+// is served at /.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/collector"
+	"github.com/mucolud/trace/tracestore"
+	"github.com/mucolud/trace/traceui"
+)
+
+func main() {
+	addr := flag.String("addr", ":4319", "HTTP listen address")
+	size := flag.Int("size", 10000, "number of traces kept in memory")
+	format := flag.String("format", "tree", "format of the traces on stdout: tree, json, logfmt or none")
+	flag.Parse()
+
+	store := tracestore.New(tracestore.NewMemory(*size))
+	cfg := collector.Config{Store: store}
+	switch *format {
+	case "tree":
+		cfg.Sink = os.Stdout
+	case "json":
+		cfg.Sink = trace.Formatted(os.Stdout, trace.FormatJSON)
+	case "logfmt":
+		cfg.Sink = trace.Formatted(os.Stdout, trace.FormatLogfmt)
+	case "none":
+	default:
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/traces", collector.New(cfg))
+	mux.Handle("/", traceui.Handler(store))
+	log.Printf("collecting traces on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
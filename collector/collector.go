@@ -0,0 +1,158 @@
+// Package collector gathers the traces of many services in one place: they
+// post their finished traces to it, the collector stores them and writes
+// them again to a shared sink, such as a central log.
+//
+// In the services:
+//
+//	tc := trace.NewTraceContext(ctx, trace.MultiLogger(os.Stderr,
+//		collector.NewClient("http://collector:4319/v1/traces")))
+//
+// In the collector, see cmd/trace-collector for a complete server:
+//
+//	c := collector.New(collector.Config{Store: store, Sink: os.Stdout})
+//	http.Handle("/v1/traces", c)
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/tracestore"
+)
+
+// ContentType is the media type of traces encoded by trace.TraceData.Marshal.
+const ContentType = "application/x-protobuf"
+
+// maxBody bounds the size of a posted trace.
+const maxBody = 16 << 20
+
+// Config configures a Collector. Zero fields are disabled.
+type Config struct {
+	// Store keeps every received trace.
+	Store *tracestore.Store
+	// Sink receives every trace again, in the format of its sinks like for
+	// trace.NewTraceContext.
+	Sink io.Writer
+	// Options configure how traces are written to Sink.
+	Options []trace.Option
+}
+
+// Collector receives traces and hands them to its store and sink.
+type Collector struct {
+	cfg Config
+	mux sync.Mutex // serializes writes to the sink
+}
+
+// New returns a collector for cfg.
+func New(cfg Config) *Collector {
+	return &Collector{cfg: cfg}
+}
+
+// Receive stores t and writes it to the sink. Both are attempted, the first
+// error is returned.
+func (c *Collector) Receive(t trace.TraceData) error {
+	var firstErr error
+	if c.cfg.Store != nil {
+		firstErr = c.cfg.Store.Put(t)
+	}
+	if c.cfg.Sink != nil {
+		c.mux.Lock()
+		err := trace.WriteTrace(c.cfg.Sink, &t, c.cfg.Options...)
+		c.mux.Unlock()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ServeHTTP accepts a trace posted as protobuf, with ContentType, or as JSON
+// encoded trace.TraceData, possibly gzipped with "Content-Encoding: gzip".
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxBody)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = io.LimitReader(zr, maxBody)
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		code := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	var t trace.TraceData
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case ContentType:
+		err = t.Unmarshal(b)
+	case "application/json", "":
+		err = json.Unmarshal(b, &t)
+	default:
+		http.Error(w, "unsupported content type "+mediaType, http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := c.Receive(t); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// client posts every write to a collector.
+type client struct {
+	url    string
+	client *http.Client
+}
+
+// NewClient returns a sink posting every trace to the collector listening
+// at url. Wrap it with trace.Compressed to post large traces gzipped.
+func NewClient(url string) io.Writer {
+	return trace.Formatted(&client{url: url, client: &http.Client{Timeout: 10 * time.Second}}, trace.FormatProtobuf)
+}
+
+func (c *client) Write(p []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(p))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if trace.Gzipped(p) {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return 0, fmt.Errorf("collector: post to %s: %s", c.url, resp.Status)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/tracestore"
+)
+
+func TestCollector(t *testing.T) {
+	store := tracestore.New(tracestore.NewMemory(10))
+	sink := &bytes.Buffer{}
+	srv := httptest.NewServer(New(Config{Store: store, Sink: sink}))
+	defer srv.Close()
+
+	local := &bytes.Buffer{}
+	tc := trace.NewTraceContext(context.Background(), trace.MultiLogger(local, NewClient(srv.URL)))
+	child := tc.Trace()
+	child.Info("user", 42)
+	_ = child.Error(errors.New("timeout"))
+	tc.Log()
+
+	got, err := store.Get(tc.TraceID())
+	if err != nil || !got.HasError() || len(got.Children) != 1 {
+		t.Fatalf("trace should be stored: %+v, %v", got, err)
+	}
+	if sink.String() != local.String() {
+		t.Fatalf("sink should receive the trace as logged locally:\n%s\nwant:\n%s", sink, local)
+	}
+}
+
+func TestCollector_gzip(t *testing.T) {
+	store := tracestore.New(tracestore.NewMemory(10))
+	srv := httptest.NewServer(New(Config{Store: store}))
+	defer srv.Close()
+
+	tc := trace.NewTraceContext(context.Background(), trace.Compressed(NewClient(srv.URL), 0))
+	tc.Info("user", 42)
+	tc.Log()
+
+	if got, err := store.Get(tc.TraceID()); err != nil || len(got.Infos) != 1 {
+		t.Fatalf("compressed trace should be stored: %+v, %v", got, err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader("{}"))
+	req.Header.Set("Content-Encoding", "gzip")
+	New(Config{}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("a body that is not gzip should be rejected, got %d", rec.Code)
+	}
+}
+
+func TestCollectorRejects(t *testing.T) {
+	c := New(Config{})
+	for _, v := range []struct {
+		method, contentType, body string
+		code                      int
+	}{
+		{http.MethodGet, "", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "text/plain", "{}", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "application/json", "{", http.StatusBadRequest},
+		{http.MethodPost, ContentType, "\x0a\xff", http.StatusBadRequest},
+		{http.MethodPost, "application/json; charset=utf-8", `{"traceId":"1"}`, http.StatusNoContent},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(v.method, "/v1/traces", strings.NewReader(v.body))
+		req.Header.Set("Content-Type", v.contentType)
+		c.ServeHTTP(rec, req)
+		if rec.Code != v.code {
+			t.Errorf("%s %s %q: got %d, want %d", v.method, v.contentType, v.body, rec.Code, v.code)
+		}
+	}
+}
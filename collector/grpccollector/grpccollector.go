@@ -0,0 +1,100 @@
+// Package grpccollector serves a collector.Collector over gRPC, as the
+// mucolud.trace.Collector service of trace.proto, and provides the client
+// sink for it.
+//
+//	s := grpccollector.NewServer(c)
+//	go s.Serve(lis)
+//
+// The messages are encoded by trace.TraceData.Marshal, so no generated code
+// is needed on either side.
+package grpccollector
+
+import (
+	"context"
+	"io"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/collector"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	serviceName  = "mucolud.trace.Collector"
+	exportMethod = "/" + serviceName + "/Export"
+)
+
+// message is an already encoded request or response.
+type message []byte
+
+// codec passes messages through, the Span is encoded by trace itself and
+// ExportResponse is empty.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return *v.(*message), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*message) = append((*v.(*message))[:0], data...)
+	return nil
+}
+
+func (codec) Name() string { return "proto" }
+
+var _ encoding.Codec = codec{}
+
+type server interface {
+	Receive(t trace.TraceData) error
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*server)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Export",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			var req message
+			if err := dec(&req); err != nil {
+				return nil, err
+			}
+			var t trace.TraceData
+			if err := t.Unmarshal(req); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			if err := srv.(server).Receive(t); err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			return &message{}, nil
+		},
+	}},
+	Metadata: "trace.proto",
+}
+
+// NewServer returns a gRPC server serving c. The server decodes its requests
+// itself, so it can not host services using generated code.
+func NewServer(c *collector.Collector, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(append(opts, grpc.ForceServerCodec(codec{}))...)
+	s.RegisterService(&serviceDesc, c)
+	return s
+}
+
+// client exports every write to a collector.
+type client struct {
+	conn *grpc.ClientConn
+}
+
+// NewClient returns a sink exporting every trace to the collector on conn.
+func NewClient(conn *grpc.ClientConn) io.Writer {
+	return trace.Formatted(&client{conn: conn}, trace.FormatProtobuf)
+}
+
+func (c *client) Write(p []byte) (int, error) {
+	req, resp := message(p), message(nil)
+	if err := c.conn.Invoke(context.Background(), exportMethod, &req, &resp, grpc.ForceCodec(codec{})); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,58 @@
+package grpccollector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/collector"
+	"github.com/mucolud/trace/tracestore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestExport(t *testing.T) {
+	store := tracestore.New(tracestore.NewMemory(10))
+	sink := &bytes.Buffer{}
+	lis := bufconn.Listen(1 << 20)
+	s := NewServer(collector.New(collector.Config{Store: store, Sink: sink}))
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	local := &bytes.Buffer{}
+	tc := trace.NewTraceContext(context.Background(), trace.MultiLogger(local, NewClient(conn)))
+	child := tc.Trace()
+	child.Info("user", 42)
+	_ = child.Error(errors.New("timeout"))
+	tc.Log()
+
+	got, err := store.Get(tc.TraceID())
+	if err != nil || !got.HasError() || len(got.Children) != 1 {
+		t.Fatalf("trace should be stored: %+v, %v", got, err)
+	}
+	if sink.String() != local.String() {
+		t.Fatalf("sink should receive the trace as logged locally:\n%s\nwant:\n%s", sink, local)
+	}
+
+	req, resp := message{0xff}, message(nil)
+	err = conn.Invoke(context.Background(), exportMethod, &req, &resp, grpc.ForceCodec(codec{}))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("an invalid span should be rejected, got %v", err)
+	}
+}
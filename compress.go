@@ -0,0 +1,55 @@
+package trace
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// compressWriter gzips the writes of at least threshold bytes.
+type compressWriter struct {
+	w         io.Writer
+	threshold int
+}
+
+// Compressed returns a writer compressing every trace of at least threshold
+// bytes with gzip before writing it to w, leaving the smaller ones as they
+// are so that they stay greppable. Each trace is compressed on its own, a
+// gzip member written in a single call to w. w may be any logger, such as
+// an exporter or a MultiLogger, whose sinks then all compress:
+//
+//	trace.Compressed(trace.NewOTLPExporter(url), 64<<10)
+//
+// The exporters of the package post compressed traces with a
+// "Content-Encoding: gzip" header.
+//
+// There is no zstd: the standard library has no zstd encoder, and the
+// package, the collector reading what it posts included, depends on nothing
+// else.
+func Compressed(w io.Writer, threshold int) io.Writer {
+	return &compressWriter{w: w, threshold: threshold}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if len(p) < c.threshold {
+		return c.w.Write(p)
+	}
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(p); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	if _, err := c.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Gzipped reports whether p starts with the gzip header, as the traces
+// Compressed compresses do. No format of the package does.
+func Gzipped(p []byte) bool {
+	return len(p) >= 2 && p[0] == 0x1f && p[1] == 0x8b
+}
@@ -0,0 +1,52 @@
+package trace
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressed(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), Compressed(Formatted(buf, FormatJSON), 1<<10))
+	tc.Info("small")
+	tc.Log()
+	if Gzipped(buf.Bytes()) || !strings.Contains(buf.String(), `"small"`) {
+		t.Fatalf("small traces should stay as they are: %q", buf.String())
+	}
+
+	buf.Reset()
+	tc = NewTraceContext(context.Background(), Compressed(Formatted(buf, FormatJSON), 1<<10))
+	tc.Info(strings.Repeat("verbose ", 1000))
+	tc.Log()
+	if !Gzipped(buf.Bytes()) {
+		t.Fatalf("large traces should be compressed")
+	}
+	zr, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil || !strings.Contains(string(b), "verbose verbose") {
+		t.Fatalf("unexpected trace %v %.100q", err, b)
+	}
+}
+
+func TestCompressed_exporter(t *testing.T) {
+	encodings := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings <- r.Header.Get("Content-Encoding")
+	}))
+	defer srv.Close()
+
+	tc := NewTraceContext(context.Background(), Compressed(NewOTLPExporter(srv.URL), 0))
+	tc.Log()
+	if got := <-encodings; got != "gzip" {
+		t.Fatalf("the exporter should post gzip, got %q", got)
+	}
+}
@@ -0,0 +1,91 @@
+package trace
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Config is the configuration of every trace of the process that can change
+// while it runs, for an admin endpoint or a config watcher to turn the
+// verbosity up or down without a restart. It applies on top of the options
+// of each trace and is read when records are made and traces logged.
+type Config struct {
+	// SampleRate is the fraction of the traces without errors that Log
+	// writes, picked by trace id so that all services keep the same ones.
+	// 0 and 1 and above write them all.
+	SampleRate float64
+	// MinLevel drops Info records below it: LevelWarn keeps only Warn and
+	// errors. Errors are always recorded.
+	MinLevel Level
+	// ErrorOnly makes Log write only traces with errors, like
+	// WithErrorOnly.
+	ErrorOnly bool
+}
+
+var (
+	config    atomic.Value // Config
+	configMux sync.Mutex   // serializes UpdateConfig
+)
+
+func init() {
+	config.Store(Config{})
+}
+
+// CurrentConfig returns the configuration set with SetConfig or
+// UpdateConfig, the zero Config changing nothing by default.
+func CurrentConfig() Config {
+	return config.Load().(Config)
+}
+
+// SetConfig replaces the configuration of every trace. It is safe to call
+// while traces are recorded and logged.
+func SetConfig(c Config) {
+	configMux.Lock()
+	defer configMux.Unlock()
+	config.Store(c)
+}
+
+// UpdateConfig changes the configuration with fn, which gets a copy of the
+// current one, so that concurrent updates of different fields do not undo
+// each other.
+//
+//	trace.UpdateConfig(func(c *trace.Config) { c.MinLevel = trace.LevelWarn })
+func UpdateConfig(fn func(c *Config)) {
+	configMux.Lock()
+	defer configMux.Unlock()
+	c := CurrentConfig()
+	fn(&c)
+	config.Store(c)
+}
+
+// enabled reports whether records of level are kept.
+func (c *Config) enabled(level Level) bool {
+	return level >= c.MinLevel || level >= LevelError
+}
+
+// enabled reports whether tc keeps records of level, according to the
+// configuration and WithMinLevel.
+func (tc *TraceContext) enabled(level Level) bool {
+	cfg := CurrentConfig()
+	return cfg.enabled(level) && (level >= tc.opts.minLevel || level >= LevelError)
+}
+
+// sampled reports whether Log writes tc without errors, according to the
+// configuration and WithSampleRate.
+func (tc *TraceContext) sampled() bool {
+	cfg := CurrentConfig()
+	own := Config{SampleRate: tc.opts.sampleRate}
+	return cfg.sampled(tc.traceId) && own.sampled(tc.traceId)
+}
+
+// sampled reports whether the trace with id is among the ones SampleRate
+// keeps.
+func (c *Config) sampled(traceId int64) bool {
+	if c.SampleRate <= 0 || c.SampleRate >= 1 {
+		return true
+	}
+	// spread sequential ids over the whole range before comparing
+	h := uint64(traceId) * 0x9e3779b97f4a7c15
+	return float64(h) < c.SampleRate*math.MaxUint64
+}
@@ -0,0 +1,59 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUpdateConfig(t *testing.T) {
+	defer SetConfig(Config{})
+	UpdateConfig(func(c *Config) { c.MinLevel = LevelWarn })
+	UpdateConfig(func(c *Config) { c.ErrorOnly = true })
+	if c := CurrentConfig(); c.MinLevel != LevelWarn || !c.ErrorOnly {
+		t.Fatalf("updates should add up: %+v", c)
+	}
+
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	tc.Info("dropped")
+	tc.Warn("kept")
+	tc.Log()
+	if buf.Len() != 0 {
+		t.Fatalf("a trace without errors should not be logged: %s", buf.String())
+	}
+	_ = tc.Error(errors.New("boom"))
+	tc.Log()
+	if entries := tc.Entries(); len(entries) != 2 || entries[0].Level != LevelWarn || buf.Len() == 0 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
+
+func TestConfig_SampleRate(t *testing.T) {
+	defer SetConfig(Config{})
+	SetConfig(Config{SampleRate: 0.25})
+	logged := 0
+	for i := 0; i < 1000; i++ {
+		buf := &bytes.Buffer{}
+		tc := NewTraceContext(context.Background(), buf, WithTraceID(formatTraceID(int64(i+1))))
+		tc.Info("x")
+		tc.Log()
+		if buf.Len() > 0 {
+			logged++
+		}
+	}
+	if logged < 200 || logged > 300 {
+		t.Fatalf("expected about a quarter of the traces, got %d", logged)
+	}
+
+	buf := &bytes.Buffer{}
+	for i := 0; i < 10; i++ {
+		tc := NewTraceContext(context.Background(), buf, WithTraceID(formatTraceID(int64(i+1))))
+		_ = tc.Error("x")
+		tc.Log()
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("┌ ")); n != 10 {
+		t.Fatalf("traces with errors should always be logged, got %d", n)
+	}
+}
@@ -0,0 +1,22 @@
+package trace
+
+import "context"
+
+type traceKey struct{}
+
+// NewContext returns a copy of parent carrying tc, for libraries that only
+// pass an ordinary context.Context along. FromContext gets it back.
+func NewContext(parent context.Context, tc *TraceContext) context.Context {
+	return context.WithValue(parent, traceKey{}, tc)
+}
+
+// FromContext returns the span carried by ctx: the one stored with
+// NewContext, or the innermost span ctx derives from, as a span is a
+// context itself.
+func FromContext(ctx context.Context) (*TraceContext, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tc, ok := ctx.Value(traceKey{}).(*TraceContext)
+	return tc, ok && tc != nil
+}
@@ -0,0 +1,28 @@
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewContext(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	ctx := NewContext(context.Background(), tc)
+	if got, ok := FromContext(ctx); !ok || got != tc {
+		t.Fatalf("FromContext should return the stored trace")
+	}
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatalf("a plain context carries no trace")
+	}
+
+	child := tc.Trace()
+	ctx, cancel := context.WithTimeout(child, time.Second)
+	defer cancel()
+	if got, ok := FromContext(ctx); !ok || got != child {
+		t.Fatalf("contexts derived from a span should carry it")
+	}
+	if got, _ := FromContext(tc.Trace()); got == tc {
+		t.Fatalf("FromContext should return the innermost span")
+	}
+}
@@ -0,0 +1,150 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Converter turns a recorded param into the text used in the message of
+// the errors returned by Error and ErrorCustom.
+type Converter func(v interface{}) string
+
+// toString converts common scalar values without going through fmt.
+func toString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	case int:
+		return strconv.Itoa(val)
+	case int8:
+		return strconv.FormatInt(int64(val), 10)
+	case int16:
+		return strconv.FormatInt(int64(val), 10)
+	case int32:
+		return strconv.FormatInt(int64(val), 10)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case uint:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// appendParam appends the error text of a resolved param to b. Nil
+// pointers render as nil; errors, fmt.Stringer and json.Marshaler are asked
+// for their text, and a panic in one of them is rendered instead of
+// propagated.
+func (tc *TraceContext) appendParam(b []byte, v interface{}) []byte {
+	if tc.opts.converter == nil {
+		switch val := v.(type) {
+		case string:
+			return append(b, val...)
+		case []byte:
+			return append(b, val...)
+		case bool:
+			return strconv.AppendBool(b, val)
+		case int:
+			return strconv.AppendInt(b, int64(val), 10)
+		case int8:
+			return strconv.AppendInt(b, int64(val), 10)
+		case int16:
+			return strconv.AppendInt(b, int64(val), 10)
+		case int32:
+			return strconv.AppendInt(b, int64(val), 10)
+		case int64:
+			return strconv.AppendInt(b, val, 10)
+		case uint:
+			return strconv.AppendUint(b, uint64(val), 10)
+		case uint8:
+			return strconv.AppendUint(b, uint64(val), 10)
+		case uint16:
+			return strconv.AppendUint(b, uint64(val), 10)
+		case uint32:
+			return strconv.AppendUint(b, uint64(val), 10)
+		case uint64:
+			return strconv.AppendUint(b, val, 10)
+		case float32:
+			return strconv.AppendFloat(b, float64(val), 'g', -1, 32)
+		case float64:
+			return strconv.AppendFloat(b, val, 'g', -1, 64)
+		}
+	}
+	return tc.appendValue(b, v)
+}
+
+// appendValue appends the params that are not plain scalars, calling into
+// their methods.
+func (tc *TraceContext) appendValue(b []byte, v interface{}) (res []byte) {
+	if isNilParam(v) {
+		return append(b, tc.nilString()...)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			res = fmt.Appendf(b, "(PANIC=%v)", r)
+		}
+	}()
+	if err, ok := v.(error); ok {
+		return append(b, err.Error()...)
+	}
+	if tc.opts.converter != nil {
+		return append(b, tc.opts.converter(v)...)
+	}
+	switch val := v.(type) {
+	case fmt.Stringer:
+		return append(b, val.String()...)
+	case json.Marshaler:
+		if res, ok := appendJSON(b, val); ok {
+			return res
+		}
+	}
+	// fmt prints unexported fields and recovers from panics on its own
+	return fmt.Appendf(b, "%+v", reflect.Indirect(reflect.ValueOf(v)).Interface())
+}
+
+// isNilParam reports whether v is nil or a nil pointer, on which methods
+// such as Error or String would likely panic.
+func isNilParam(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// appendJSON appends the JSON of m, unquoted if it is a string. It reports
+// false if m fails to marshal.
+func appendJSON(b []byte, m json.Marshaler) ([]byte, bool) {
+	res, err := m.MarshalJSON()
+	if err != nil {
+		return b, false
+	}
+	var s string
+	if len(res) > 0 && res[0] == '"' && json.Unmarshal(res, &s) == nil {
+		return append(b, s...), true
+	}
+	return append(b, res...), true
+}
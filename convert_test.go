@@ -0,0 +1,118 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendParam(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	type point struct{ X, Y int }
+	var nilPoint *point
+	params := []interface{}{
+		"text", true, -7, int8(8), int16(16), int32(32), int64(1 << 40),
+		uint(1), uint8(2), uint16(3), uint32(4), uint64(1 << 50),
+		float32(0.1), 1.5, 1e21, point{1, 2}, &point{3, 4}, time.Second,
+	}
+	for _, v := range params {
+		want := fmt.Sprintf("%+v", reflect.Indirect(reflect.ValueOf(v)).Interface())
+		if got := string(tc.appendParam(nil, v)); got != want {
+			t.Errorf("%T: got %q, want %q", v, got, want)
+		}
+	}
+	if got := string(tc.appendParam(nil, nilPoint)); got != "nil" {
+		t.Errorf("nil pointer: got %q", got)
+	}
+	if got := string(tc.appendParam(nil, errors.New("boom"))); got != "boom" {
+		t.Errorf("error: got %q", got)
+	}
+}
+
+type userID int
+
+func (u *userID) String() string { return "user-" + strconv.Itoa(int(*u)) }
+
+type money struct{ cents int }
+
+func (m money) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.Itoa(m.cents/100) + " EUR" + `"`), nil
+}
+
+type raw struct{}
+
+func (raw) MarshalJSON() ([]byte, error) { return []byte(`{"a":1}`), nil }
+
+type brokenStringer struct{}
+
+func (brokenStringer) String() string { panic("boom") }
+
+type nilError struct{}
+
+func (*nilError) Error() string { return "never called" }
+
+func TestAppendParamInterfaces(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	id := userID(7)
+	var err *nilError
+	var errIface error = err
+	cases := []struct {
+		param interface{}
+		want  string
+	}{
+		{&id, "user-7"},
+		{money{1200}, "12 EUR"},
+		{raw{}, `{"a":1}`},
+		{[]byte("bytes"), "bytes"},
+		{brokenStringer{}, "(PANIC=boom)"},
+		{errIface, "nil"},
+		{struct{ id, name string }{"1", "ann"}, "{id:1 name:ann}"},
+	}
+	for _, c := range cases {
+		if got := string(tc.appendParam([]byte("x="), c.param)); got != "x="+c.want {
+			t.Errorf("%T: got %q, want %q", c.param, got, "x="+c.want)
+		}
+	}
+}
+
+func TestConvertToErrorAllocs(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	err := errors.New("timeout")
+	params := []interface{}{"query failed", 42, err}
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = tc.convertToError(params)
+	})
+	// the message and the error value itself
+	if allocs > 2 {
+		t.Fatalf("convertToError allocated %v times", allocs)
+	}
+	if got := tc.convertToError(params).Error(); got != "query failed,42,timeout" {
+		t.Fatalf("unexpected message %q", got)
+	}
+}
+
+type derefError struct{ msg string }
+
+func (e *derefError) Error() string { return e.msg }
+
+func TestRenderParams_nilErrorAndBytes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), Formatted(buf, FormatJSON))
+	var e *derefError
+	tc.Info(e, []byte("payload"))
+	tc.Log()
+
+	data := tc.Snapshot()
+	if got := data.Infos[0].Data; len(got) != 2 || got[0] != nil || got[1] != "payload" {
+		t.Fatalf("unexpected rendered params %#v", got)
+	}
+	if !strings.Contains(buf.String(), `"data":[null,"payload"]`) {
+		t.Fatalf("want the nil error as null and the bytes as text:\n%s", buf.String())
+	}
+}
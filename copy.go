@@ -0,0 +1,143 @@
+package trace
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// detach replaces the values t shares with the live trace by copies, so
+// that t can be used from another goroutine while the trace goes on.
+func (t *TraceData) detach() {
+	for _, nodes := range [][]NodeData{t.Infos, t.Errors, t.Events} {
+		for i := range nodes {
+			copyValues(nodes[i].Data)
+			copyAttrs(nodes[i].Attrs)
+		}
+	}
+	for i := range t.Links {
+		copyAttrs(t.Links[i].Attrs)
+	}
+	if len(t.Args) > 0 {
+		args := make([]Arg, len(t.Args))
+		for i, v := range t.Args {
+			args[i] = Arg{Name: v.Name, Value: copyValue(v.Value)}
+		}
+		t.Args = args
+	}
+	copyAttrs(t.Tags)
+	if len(t.Attachments) > 0 {
+		attachments := make([]Attachment, len(t.Attachments))
+		for i, v := range t.Attachments {
+			v.Data = append([]byte(nil), v.Data...)
+			attachments[i] = v
+		}
+		t.Attachments = attachments
+	}
+	for i := range t.Children {
+		t.Children[i].detach()
+	}
+}
+
+func copyValues(values []interface{}) {
+	for i, v := range values {
+		values[i] = copyValue(v)
+	}
+}
+
+func copyAttrs(m map[string]interface{}) {
+	for k, v := range m {
+		m[k] = copyValue(v)
+	}
+}
+
+// copyValue returns a copy of v, a rendered param, sharing nothing with it
+// that JSON would read. Values marshaling themselves are rendered the way
+// Log renders them, as their JSON or text.
+func copyValue(v interface{}) (res interface{}) {
+	switch v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	}
+	if isNilParam(v) {
+		return v
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			res = fmt.Sprintf("(PANIC=%v)", r)
+		}
+	}()
+	switch val := v.(type) {
+	case json.Marshaler:
+		if b, err := val.MarshalJSON(); err == nil {
+			return json.RawMessage(b)
+		}
+	case encoding.TextMarshaler:
+		if b, err := val.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return copyReflect(reflect.ValueOf(v), map[uintptr]reflect.Value{}).Interface()
+}
+
+// copyReflect copies v deeply. Unexported struct fields, which JSON leaves
+// out, are copied shallowly. copied holds the pointers already copied, so
+// that cycles and shared values are copied once.
+func copyReflect(v reflect.Value, copied map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if c, ok := copied[v.Pointer()]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		copied[v.Pointer()] = c
+		c.Elem().Set(copyReflect(v.Elem(), copied))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyReflect(v.Elem(), copied))
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), copyReflect(iter.Value(), copied))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyReflect(v.Index(i), copied))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyReflect(v.Index(i), copied))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := c.Field(i); f.CanSet() {
+				f.Set(copyReflect(v.Field(i), copied))
+			}
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,271 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// TraceData is the exported form of a span and its subtree, with params
+// rendered the same way Log prints them. It holds no references to the live
+// trace and can be marshaled and stored freely.
+type TraceData struct {
+	TraceId      string                 `json:"trace_id"`
+	SpanId       string                 `json:"span_id"`
+	ParentSpanId string                 `json:"parent_span_id,omitempty"`
+	Func         string                 `json:"func"`
+	Peer         *Peer                  `json:"peer,omitempty"`
+	Dependency   *Dependency            `json:"dependency,omitempty"`
+	Kind         SpanKind               `json:"kind,omitempty"`
+	Args         []Arg                  `json:"args,omitempty"`
+	Label        string                 `json:"label,omitempty"`
+	Tags         map[string]interface{} `json:"tags,omitempty"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	Running      bool                   `json:"running,omitempty"`
+	Slow         bool                   `json:"slow,omitempty"`
+	Budget       time.Duration          `json:"budget,omitempty"` // left before the deadline at the start, 0 for none
+	Cause        string                 `json:"cause,omitempty"`
+	Status       Status                 `json:"status"`
+	Warned       bool                   `json:"warned,omitempty"` // failed by WithWarnThreshold
+	Suppressed   int                    `json:"suppressed,omitempty"`
+	Infos        []NodeData             `json:"infos,omitempty"`
+	Errors       []NodeData             `json:"errors,omitempty"`
+	Events       []NodeData             `json:"events,omitempty"`
+	Links        []LinkData             `json:"links,omitempty"`
+	Attachments  []Attachment           `json:"attachments,omitempty"`
+	Resource     *Resource              `json:"resource,omitempty"` // root only
+	Violations   []string               `json:"violations,omitempty"`
+	Children     []TraceData            `json:"children,omitempty"`
+}
+
+// NodeData is one recorded info, error or event of a TraceData.
+type NodeData struct {
+	Level    Level                  `json:"level"`
+	Code     int                    `json:"code,omitempty"`     // errors only
+	Category Category               `json:"category,omitempty"` // errors only
+	Func     string                 `json:"func"`
+	Line     int                    `json:"line"`
+	Name     string                 `json:"name,omitempty"` // events only
+	Time     time.Time              `json:"time"`
+	Data     []interface{}          `json:"data,omitempty"`  // infos and errors
+	Attrs    map[string]interface{} `json:"attrs,omitempty"` // events only
+	Stack    []string               `json:"stack,omitempty"`
+	Tags     map[string]string      `json:"tags,omitempty"` // errors only
+	// Suppressed is the number of nodes of the same call site dropped
+	// after this one, see WithRateLimit.
+	Suppressed int `json:"suppressed,omitempty"`
+	// Seq is the order of the record in the trace, see TraceData.Timeline.
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// Duration is the time between the start and the end of the span.
+func (t *TraceData) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// HasError reports whether the span or any of its descendants recorded an
+// error or failed by WithWarnThreshold.
+func (t *TraceData) HasError() bool {
+	if len(t.Errors) > 0 || t.Warned {
+		return true
+	}
+	for i := range t.Children {
+		if t.Children[i].HasError() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFunc reports whether the span or any of its descendants belongs to a
+// function whose name contains name.
+func (t *TraceData) HasFunc(name string) bool {
+	if strings.Contains(t.Func, name) {
+		return true
+	}
+	for i := range t.Children {
+		if t.Children[i].HasFunc(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot returns the current state of tc and its subtree, even while spans
+// are still running, e.g. to dump a stuck request from a debug endpoint. It
+// may be called from any goroutine; the result is a copy and does not change
+// as the trace goes on. Params are rendered as Log would and copied, values
+// marshaling themselves to JSON or text are kept as what they marshal to.
+func (tc *TraceContext) Snapshot() TraceData {
+	data := tc.snapshot().data()
+	data.detach()
+	return data
+}
+
+func (s *spanSnapshot) data() TraceData {
+	res := TraceData{
+		TraceId:      formatTraceID(s.traceId),
+		SpanId:       formatSpanID(s.spanId),
+		ParentSpanId: formatSpanID(s.parentId),
+		Func:         s.funcName,
+		Dependency:   s.dependency,
+		Kind:         s.kind,
+		Args:         s.args,
+		Label:        s.label,
+		Tags:         s.tags,
+		Start:        s.start,
+		End:          s.end,
+		Running:      s.running,
+		Slow:         s.slow,
+		Budget:       s.budget,
+		Cause:        s.cause,
+		Status:       s.status,
+		Warned:       s.warned,
+		Suppressed:   s.suppressed,
+		Infos:        nodeData(s.infos),
+		Errors:       nodeData(s.errors),
+		Links:        linkData(s.links),
+		Attachments:  s.attachments,
+		Resource:     s.resource,
+		Violations:   s.violations,
+	}
+	if !s.peer.IsZero() {
+		p := s.peer
+		res.Peer = &p
+	}
+	for _, v := range s.events {
+		res.Events = append(res.Events, NodeData{
+			Level: v.Level,
+			Func:  v.Func,
+			Line:  v.Line,
+			Name:  v.Name,
+			Time:  v.Time,
+			Attrs: v.Data[0].(map[string]interface{}),
+			Seq:   v.seq,
+		})
+	}
+	if len(s.children) > 0 {
+		res.Children = make([]TraceData, 0, len(s.children))
+		for _, v := range s.children {
+			res.Children = append(res.Children, v.data())
+		}
+	}
+	return res
+}
+
+func nodeData(nodes []*node) []NodeData {
+	if len(nodes) == 0 {
+		return nil
+	}
+	res := make([]NodeData, 0, len(nodes))
+	for _, v := range nodes {
+		res = append(res, NodeData{
+			Level:      v.Level,
+			Code:       v.Code,
+			Category:   v.Category,
+			Func:       v.Func,
+			Line:       v.Line,
+			Time:       v.Time,
+			Data:       v.Data,
+			Stack:      v.Stack,
+			Tags:       v.Tags,
+			Suppressed: v.Suppressed,
+			Seq:        v.seq,
+		})
+	}
+	return res
+}
+
+// writeTraceData writes the trace as a single line of JSON.
+func writeTraceData(w io.Writer, s *spanSnapshot) (int, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s.data()); err != nil {
+		return 0, err
+	}
+	return w.Write(buf.Bytes())
+}
+
+func writeProtobuf(w io.Writer, s *spanSnapshot) (int, error) {
+	data := s.data()
+	b, err := data.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(b)
+}
+
+// snapshot turns t back into the form the formatters work on.
+func (t *TraceData) snapshot() *spanSnapshot {
+	traceId, _ := ParseTraceID(t.TraceId)
+	spanId, _ := ParseTraceID(t.SpanId)
+	parentId, _ := ParseTraceID(t.ParentSpanId)
+	s := &spanSnapshot{
+		traceId:    traceId,
+		spanId:     spanId,
+		parentId:   parentId,
+		funcName:   t.Func,
+		dependency: t.Dependency,
+		kind:       t.Kind,
+		args:       t.Args,
+		label:      t.Label,
+		tags:       t.Tags,
+		start:      t.Start,
+		end:        t.End,
+		running:    t.Running,
+		slow:       t.Slow,
+		budget:     t.Budget,
+		cause:      t.Cause,
+		suppressed: t.Suppressed,
+		status:     t.Status,
+		warned:     t.Warned,
+		infos:      dataNodes(t.Infos),
+		errors:     dataNodes(t.Errors),
+		links:      t.linkNodes(),
+		resource:   t.Resource,
+		violations: t.Violations,
+	}
+	if t.Peer != nil {
+		s.peer = *t.Peer
+	}
+	s.attachments = t.Attachments
+	for _, v := range t.Events {
+		s.events = append(s.events, &node{
+			Level: v.Level,
+			Func:  v.Func,
+			Line:  v.Line,
+			Name:  v.Name,
+			Time:  v.Time,
+			Data:  []interface{}{v.Attrs},
+			seq:   v.Seq,
+		})
+	}
+	for i := range t.Children {
+		s.children = append(s.children, t.Children[i].snapshot())
+	}
+	return s
+}
+
+func dataNodes(nodes []NodeData) []*node {
+	res := make([]*node, 0, len(nodes))
+	for _, v := range nodes {
+		res = append(res, &node{
+			Level:      v.Level,
+			Code:       v.Code,
+			Category:   v.Category,
+			Func:       v.Func,
+			Line:       v.Line,
+			Time:       v.Time,
+			Data:       v.Data,
+			Stack:      v.Stack,
+			Tags:       v.Tags,
+			Suppressed: v.Suppressed,
+			seq:        v.Seq,
+		})
+	}
+	return res
+}
@@ -0,0 +1,100 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFormatTraceData(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), Formatted(buf, FormatTraceData))
+	tc.SetTag("user", "ann")
+	child := tc.Trace(WithPeer("db", 5432))
+	child.Event("query", "rows", 3)
+	_ = child.Error(errors.New("timeout"))
+	tc.Log()
+
+	var data TraceData
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.TraceId != tc.TraceID() || data.Tags["user"] != "ann" || len(data.Children) != 1 {
+		t.Fatalf("unexpected root %+v", data)
+	}
+	sub := data.Children[0]
+	if sub.Peer == nil || sub.Peer.Port != 5432 || sub.Errors[0].Data[0] != "timeout" || sub.Errors[0].Level != LevelError {
+		t.Fatalf("unexpected child %+v", sub)
+	}
+	if sub.Events[0].Name != "query" || sub.Events[0].Attrs["rows"] != 3.0 {
+		t.Fatalf("unexpected event %+v", sub.Events[0])
+	}
+	if !data.HasError() || !data.HasFunc("TestFormatTraceData") || data.HasFunc("nowhere") {
+		t.Fatalf("unexpected HasError/HasFunc")
+	}
+}
+
+func TestTraceContext_Snapshot(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	tc.SetTag("user", "ann")
+	started, release := make(chan struct{}), make(chan struct{})
+	tc.Go(func(child *TraceContext) {
+		child.Info("waiting on the lock")
+		close(started)
+		<-release
+		child.Info("done")
+	})
+	<-started
+
+	snap := tc.Snapshot()
+	tc.SetTag("user", "bob")
+	close(release)
+	tc.Log()
+
+	if _, err := json.Marshal(snap); err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Children) != 1 || !snap.Children[0].Running || len(snap.Children[0].Infos) != 1 {
+		t.Fatalf("snapshot should show the running child as it was: %+v", snap)
+	}
+	if snap.Tags["user"] != "ann" {
+		t.Fatalf("snapshot must not change afterwards: %+v", snap.Tags)
+	}
+}
+
+type cart struct {
+	Items []string
+	Owner *cart
+}
+
+func TestTraceContext_Snapshot_copies(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	c := &cart{Items: []string{"book"}}
+	c.Owner = c // a cycle is copied once
+	counts := map[string]interface{}{"book": 1}
+	tc.Info(c)
+	tc.SetTag("counts", counts)
+	tc.Args("items", c.Items)
+	tc.Attach("body", []byte("{}"), "application/json")
+
+	data := tc.Snapshot()
+	c.Items[0] = "pen"
+	counts["book"] = 2
+	want := `{"Items":["book"],"Owner":null}`
+	got := data.Infos[0].Data[0].(*cart)
+	if got == c || got.Owner != got || got.Items[0] != "book" {
+		t.Fatalf("the param should be copied, got %+v", got)
+	}
+	if b, _ := json.Marshal(cart{Items: got.Items}); string(b) != want {
+		t.Fatalf("unexpected copy %s", b)
+	}
+	if data.Tags["counts"].(map[string]interface{})["book"] != 1 || data.Args[0].Value.([]string)[0] != "book" {
+		t.Fatalf("tags and args should be copied: %v %v", data.Tags, data.Args)
+	}
+	data.Attachments[0].Data[0] = 'x'
+	if again := tc.Snapshot(); string(again.Attachments[0].Data) != "{}" {
+		t.Fatalf("attachments should be copied")
+	}
+}
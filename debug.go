@@ -0,0 +1,53 @@
+package trace
+
+import (
+	"context"
+	"strconv"
+)
+
+// DebugHeader asks for a request to be traced in full when set to a true
+// value such as "1" or "true". See WithDebug.
+const DebugHeader = "X-Trace-Debug"
+
+type debugKey struct{}
+
+// ContextWithDebug marks ctx so that the traces created from it with
+// NewTraceContext are debug traces, as with WithDebug.
+func ContextWithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+func debugFromContext(ctx context.Context) bool {
+	debug, _ := ctx.Value(debugKey{}).(bool)
+	return debug
+}
+
+// ParseDebug reports whether the value of a DebugHeader asks for debugging.
+func ParseDebug(v string) bool {
+	debug, err := strconv.ParseBool(v)
+	return err == nil && debug
+}
+
+// WithDebug traces this request in full whatever the other options say: the
+// trace is logged even with WithErrorOnly or LogIfError, no info is left out
+// by WithIncludeFuncs or WithExcludeFuncs, and the tree is neither pruned nor
+// collapsed. The root span is tagged trace.debug=true.
+func WithDebug() Option {
+	return func(o *options) {
+		o.debug = true
+	}
+}
+
+// enableDebug overrides the options reducing what a debug trace logs.
+func (o *options) enableDebug() {
+	o.debug = true
+	o.errorOnly = false
+	o.funcs = nil
+	o.prune = PruneNone
+	o.collapse = false
+}
+
+// IsDebug reports whether the trace was created with WithDebug.
+func (tc *TraceContext) IsDebug() bool {
+	return tc.opts.debug
+}
@@ -0,0 +1,42 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWithDebug(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithErrorOnly(), WithExcludeFuncs("*TestWithDebug"), WithDebug())
+	tc.Info("verbose")
+	tc.Trace()
+	tc.Log()
+	out := buf.String()
+	if !strings.Contains(out, `"verbose"`) || !strings.Contains(out, "trace.debug=true") {
+		t.Fatalf("debug trace should be logged in full: %s", out)
+	}
+	if strings.Count(out, "TestWithDebug") != 3 {
+		t.Fatalf("empty spans should not be pruned: %s", out)
+	}
+
+	buf.Reset()
+	tc = NewTraceContext(ContextWithDebug(context.Background()), buf, WithErrorOnly())
+	if !tc.IsDebug() || !tc.Trace().IsDebug() {
+		t.Fatalf("a debug context should make a debug trace")
+	}
+	tc.LogIfError()
+	if buf.Len() == 0 {
+		t.Fatalf("LogIfError should log debug traces")
+	}
+
+	if NewTraceContext(context.Background(), nil).IsDebug() {
+		t.Fatalf("traces are not debug traces by default")
+	}
+	for v, want := range map[string]bool{"1": true, "true": true, "0": false, "": false, "yes": false} {
+		if ParseDebug(v) != want {
+			t.Errorf("ParseDebug(%q) != %v", v, want)
+		}
+	}
+}
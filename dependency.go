@@ -0,0 +1,91 @@
+package trace
+
+// DependencyKind is the kind of downstream system a dependency span calls.
+type DependencyKind string
+
+const (
+	DependencyDB    DependencyKind = "db"
+	DependencyHTTP  DependencyKind = "http"
+	DependencyCache DependencyKind = "cache"
+	DependencyQueue DependencyKind = "queue"
+)
+
+// Dependency describes the downstream system called by a span, so that
+// dependency maps can be built from the traces.
+type Dependency struct {
+	// Name is the system called, such as "postgres" or "billing-api".
+	Name string         `json:"name"`
+	Kind DependencyKind `json:"kind"`
+	// Target is what is called in it: an address, URL, database or queue.
+	Target string `json:"target,omitempty"`
+}
+
+// String renders the dependency as "kind name@target".
+func (d Dependency) String() string {
+	s := string(d.Kind) + " " + d.Name
+	if d.Target != "" {
+		s += "@" + d.Target
+	}
+	return s
+}
+
+// WithDependency marks the span as a call to the dependency name of the
+// given kind.
+func WithDependency(name string, kind DependencyKind, target string) SpanOption {
+	return func(tc *TraceContext) {
+		tc.dependency = &Dependency{Name: name, Kind: kind, Target: target}
+	}
+}
+
+// Dependency creates a child span, named after the caller like Trace, for a
+// call to the downstream system name of the given kind. Exports map it to a
+// client span, or a producer span for queues, with the OTel attributes of
+// the kind.
+//
+//	db := tc.Dependency("postgres", trace.DependencyDB, "orders")
+//	defer db.End()
+func (tc *TraceContext) Dependency(name string, kind DependencyKind, target string, opts ...SpanOption) *TraceContext {
+	return tc.trace(2, append([]SpanOption{WithDependency(name, kind, target)}, opts...))
+}
+
+func (s *spanSnapshot) formatDependency() string {
+	if s.dependency == nil {
+		return ""
+	}
+	return " => " + s.dependency.String()
+}
+
+// otlpKind returns the OTLP span kind of the dependency.
+func (d *Dependency) otlpKind() int {
+	if d.Kind == DependencyQueue {
+		return otlpKindProducer
+	}
+	return otlpKindClient
+}
+
+// otlpAttrs returns the OTel semantic convention attributes of the
+// dependency.
+func (d *Dependency) otlpAttrs() []otlpKeyValue {
+	res := []otlpKeyValue{otlpAttr("peer.service", d.Name)}
+	switch d.Kind {
+	case DependencyDB, DependencyCache:
+		res = append(res, otlpAttr("db.system", d.Name))
+		if d.Target != "" {
+			res = append(res, otlpAttr("db.name", d.Target))
+		}
+	case DependencyHTTP:
+		if d.Target != "" {
+			res = append(res, otlpAttr("url.full", d.Target))
+		}
+	case DependencyQueue:
+		res = append(res, otlpAttr("messaging.system", d.Name))
+		if d.Target != "" {
+			res = append(res, otlpAttr("messaging.destination.name", d.Target))
+		}
+	default:
+		if d.Target != "" {
+			res = append(res, otlpAttr("server.address", d.Target))
+		}
+	}
+	return res
+}
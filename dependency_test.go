@@ -0,0 +1,54 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_Dependency(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	db := tc.Dependency("postgres", DependencyDB, "orders")
+	db.End()
+	tc.Dependency("orders", DependencyQueue, "orders.created", WithPeerAddr("kafka:9092")).End()
+	tc.Log()
+
+	if !strings.Contains(buf.String(), "TestTraceContext_Dependency => db postgres@orders\n") {
+		t.Fatalf("dependency should be rendered even without records: %s", buf.String())
+	}
+
+	data := tc.Snapshot()
+	want := Dependency{Name: "postgres", Kind: DependencyDB, Target: "orders"}
+	if d := data.Children[0].Dependency; d == nil || *d != want {
+		t.Fatalf("unexpected dependency %+v", d)
+	}
+	b, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back TraceData
+	if err := back.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back.Children[1].Dependency, data.Children[1].Dependency) {
+		t.Fatalf("dependency lost on the wire: %+v", back.Children[1].Dependency)
+	}
+
+	spans := tc.snapshot().otlpSpans(nil)
+	attrs := map[string]string{}
+	for _, kv := range spans[2].Attributes {
+		if kv.Value.StringValue != nil {
+			attrs[kv.Key] = *kv.Value.StringValue
+		}
+	}
+	if spans[1].Kind != otlpKindClient || spans[2].Kind != otlpKindProducer {
+		t.Fatalf("unexpected span kinds %d %d", spans[1].Kind, spans[2].Kind)
+	}
+	if attrs["messaging.system"] != "orders" || attrs["messaging.destination.name"] != "orders.created" ||
+		attrs["net.peer.name"] != "kafka" || attrs["peer.service"] != "orders" {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+}
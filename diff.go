@@ -0,0 +1,202 @@
+package trace
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiffKind is how a span differs between the traces compared by Diff.
+type DiffKind int
+
+const (
+	// SpanRemoved is a span of the first trace missing in the second,
+	// along with its subtree.
+	SpanRemoved DiffKind = iota
+	// SpanAdded is a span of the second trace missing in the first.
+	SpanAdded
+	// SpanSlower is a span taking longer in the second trace than the
+	// tolerance allows.
+	SpanSlower
+	// SpanFaster is a span taking less time in the second trace than the
+	// tolerance allows.
+	SpanFaster
+	// ErrorAdded is a span recording errors in the second trace only.
+	ErrorAdded
+	// ErrorRemoved is a span recording errors in the first trace only.
+	ErrorRemoved
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case SpanRemoved:
+		return "removed"
+	case SpanAdded:
+		return "added"
+	case SpanSlower:
+		return "slower"
+	case SpanFaster:
+		return "faster"
+	case ErrorAdded:
+		return "error added"
+	case ErrorRemoved:
+		return "error removed"
+	}
+	return "unknown"
+}
+
+// SpanDiff is a difference found by Diff.
+type SpanDiff struct {
+	Kind DiffKind
+	// Path names the span by the functions from the root down to it,
+	// separated by " > ". A function called several times by the same
+	// parent is numbered from the second call on, as in "pkg.query#2".
+	Path string
+	// A and B are the durations of the span in each trace, 0 in the one
+	// missing it.
+	A, B time.Duration
+}
+
+func (d SpanDiff) String() string {
+	switch d.Kind {
+	case SpanRemoved:
+		return "- " + d.Path + " " + d.A.String()
+	case SpanAdded:
+		return "+ " + d.Path + " " + d.B.String()
+	case SpanSlower, SpanFaster:
+		return "~ " + d.Path + " " + d.Kind.String() + ": " + d.A.String() + " -> " + d.B.String()
+	}
+	return "! " + d.Path + " " + d.Kind.String()
+}
+
+// DiffReport lists the differences between two traces, parents before
+// their children.
+type DiffReport struct {
+	Diffs []SpanDiff
+}
+
+// Equal reports whether no difference was found.
+func (r DiffReport) Equal() bool {
+	return len(r.Diffs) == 0
+}
+
+// String renders the report one difference per line, for test failures:
+//
+//	~ pkg.Checkout > pkg.pay slower: 12ms -> 40ms
+//	! pkg.Checkout > pkg.pay error added
+//	- pkg.Checkout > pkg.audit 2ms
+func (r DiffReport) String() string {
+	if r.Equal() {
+		return "no differences"
+	}
+	lines := make([]string, 0, len(r.Diffs))
+	for _, d := range r.Diffs {
+		lines = append(lines, d.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiffOption configures Diff.
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	ratio float64
+	min   time.Duration
+}
+
+// DiffTolerance reports a duration as changed only once it differs by more
+// than ratio of the first one and by more than min. The default is 0.5 and
+// one millisecond, enough to tell regressions from noise in most tests.
+func DiffTolerance(ratio float64, min time.Duration) DiffOption {
+	return func(o *diffOptions) {
+		o.ratio, o.min = ratio, min
+	}
+}
+
+// Diff compares the span trees of a and b, such as traces of the same test
+// run against two versions of the code, and reports the spans added or
+// removed, whose duration changed beyond the tolerance, and which started
+// or stopped recording errors. Children are matched by function name, in
+// order; spans still running are not compared by duration.
+//
+//	if r := trace.Diff(baseline, tc.Snapshot()); !r.Equal() {
+//		t.Fatalf("trace changed:\n%s", r)
+//	}
+func Diff(a, b TraceData, opts ...DiffOption) DiffReport {
+	o := diffOptions{ratio: 0.5, min: time.Millisecond}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var r DiffReport
+	r.diff(&a, &b, a.Func, &o)
+	return r
+}
+
+func (r *DiffReport) diff(a, b *TraceData, path string, o *diffOptions) {
+	if a.Func != b.Func {
+		r.Diffs = append(r.Diffs, SpanDiff{Kind: SpanRemoved, Path: path, A: a.Duration()},
+			SpanDiff{Kind: SpanAdded, Path: b.Func, B: b.Duration()})
+		return
+	}
+	da, db := a.Duration(), b.Duration()
+	if !a.Running && !b.Running {
+		delta := db - da
+		if delta < 0 {
+			delta = -delta
+		}
+		if delta > o.min && float64(delta) > o.ratio*float64(da) {
+			kind := SpanSlower
+			if db < da {
+				kind = SpanFaster
+			}
+			r.Diffs = append(r.Diffs, SpanDiff{Kind: kind, Path: path, A: da, B: db})
+		}
+	}
+	switch {
+	case len(a.Errors) == 0 && len(b.Errors) > 0:
+		r.Diffs = append(r.Diffs, SpanDiff{Kind: ErrorAdded, Path: path, A: da, B: db})
+	case len(a.Errors) > 0 && len(b.Errors) == 0:
+		r.Diffs = append(r.Diffs, SpanDiff{Kind: ErrorRemoved, Path: path, A: da, B: db})
+	}
+
+	bs := diffNames(b.Children)
+	matched := make([]bool, len(b.Children))
+	for i, name := range diffNames(a.Children) {
+		j := indexOf(bs, name)
+		if j < 0 {
+			r.Diffs = append(r.Diffs, SpanDiff{Kind: SpanRemoved, Path: path + " > " + name, A: a.Children[i].Duration()})
+			continue
+		}
+		matched[j] = true
+		r.diff(&a.Children[i], &b.Children[j], path+" > "+name, o)
+	}
+	for j, name := range bs {
+		if !matched[j] {
+			r.Diffs = append(r.Diffs, SpanDiff{Kind: SpanAdded, Path: path + " > " + name, B: b.Children[j].Duration()})
+		}
+	}
+}
+
+// diffNames names children by their function, numbering repeated ones.
+func diffNames(children []TraceData) []string {
+	seen := make(map[string]int, len(children))
+	res := make([]string, 0, len(children))
+	for _, c := range children {
+		seen[c.Func]++
+		name := c.Func
+		if n := seen[c.Func]; n > 1 {
+			name += "#" + strconv.Itoa(n)
+		}
+		res = append(res, name)
+	}
+	return res
+}
+
+func indexOf(names []string, name string) int {
+	for i, v := range names {
+		if v == name {
+			return i
+		}
+	}
+	return -1
+}
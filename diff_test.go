@@ -0,0 +1,52 @@
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func checkoutTrace(clock *stepClock, pay time.Duration, fail bool, audit bool) TraceData {
+	tc := NewTraceContext(context.Background(), nil, WithClock(clock))
+	for i := 0; i < 2; i++ {
+		span := tc.Trace(WithSpanName("query"))
+		clock.now = clock.now.Add(10 * time.Millisecond)
+		span.End()
+	}
+	span := tc.Trace(WithSpanName("pay"))
+	clock.now = clock.now.Add(pay)
+	if fail {
+		_ = span.Error("declined")
+	}
+	span.End()
+	if audit {
+		tc.Trace(WithSpanName("audit")).End()
+	}
+	tc.End()
+	return tc.Snapshot()
+}
+
+func TestDiff(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	a := checkoutTrace(clock, 20*time.Millisecond, false, true)
+	if r := Diff(a, checkoutTrace(clock, 25*time.Millisecond, false, true)); !r.Equal() {
+		t.Fatalf("changes within the tolerance should not count:\n%s", r)
+	}
+
+	b := checkoutTrace(clock, 50*time.Millisecond, true, false)
+	r := Diff(a, b)
+	want := "~ github.com/mucolud/trace.checkoutTrace slower: 40ms -> 70ms\n" +
+		"~ github.com/mucolud/trace.checkoutTrace > pay slower: 20ms -> 50ms\n" +
+		"! github.com/mucolud/trace.checkoutTrace > pay error added\n" +
+		"- github.com/mucolud/trace.checkoutTrace > audit 0s"
+	if r.String() != want {
+		t.Fatalf("unexpected report:\n%s\nwant\n%s", r, want)
+	}
+	if r := Diff(a, b, DiffTolerance(2, 0)); len(r.Diffs) != 2 || r.Diffs[0].Kind != ErrorAdded {
+		t.Fatalf("a wider tolerance should only leave the structure:\n%s", r)
+	}
+	if r := Diff(b, a, DiffTolerance(2, 0)); r.Diffs[0].Kind != ErrorRemoved || r.Diffs[1].Kind != SpanAdded ||
+		r.Diffs[1].Path != "github.com/mucolud/trace.checkoutTrace > audit" {
+		t.Fatalf("unexpected report:\n%s", r)
+	}
+}
@@ -0,0 +1,47 @@
+package trace
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Encoder renders a recorded param of a domain type as the value written in
+// traces, such as a time.Time as a Unix timestamp or an ID type as its
+// string.
+type Encoder interface {
+	Encode(v interface{}) interface{}
+}
+
+// EncoderFunc adapts a function to the Encoder interface.
+type EncoderFunc func(v interface{}) interface{}
+
+func (f EncoderFunc) Encode(v interface{}) interface{} {
+	return f(v)
+}
+
+// encodeParams applies the encoders of WithEncoder to resolved params, in
+// place.
+func (tc *TraceContext) encodeParams(params []interface{}) []interface{} {
+	for i, v := range params {
+		params[i] = tc.encode(v)
+	}
+	return params
+}
+
+// encode renders v with the encoder registered for its type. Values JSON
+// cannot represent are rendered as their type rather than failing the
+// whole record. Errors are left to be rendered by their text.
+func (tc *TraceContext) encode(v interface{}) interface{} {
+	if _, ok := v.(error); ok || v == nil {
+		return v
+	}
+	t := reflect.TypeOf(v)
+	if e, ok := tc.opts.encoders[t]; ok {
+		return e.Encode(v)
+	}
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return fmt.Sprintf("%T", v)
+	}
+	return v
+}
@@ -0,0 +1,37 @@
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type accountID int
+
+func TestWithEncoder(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	tc := NewTraceContext(context.Background(), nil,
+		WithEncoder(time.Time{}, EncoderFunc(func(v interface{}) interface{} {
+			return v.(time.Time).Format(time.RFC3339Nano)
+		})),
+		WithEncoder(accountID(0), EncoderFunc(func(v interface{}) interface{} {
+			return "account-" + toString(int(v.(accountID)))
+		})),
+	)
+	tc.Info("at", at, "user", accountID(42), "done", make(chan int), "cb", func() {})
+	err := tc.Error("user", accountID(7))
+
+	got := tc.Snapshot().Infos[0].Data
+	want := []interface{}{"at", "2024-05-01T12:00:00.123456789Z", "user", "account-42", "done", "chan int", "cb", "func()"}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected data %#v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected data %#v", got)
+		}
+	}
+	if err.Error() != "user,account-7" {
+		t.Fatalf("encoder not applied to the error text: %q", err)
+	}
+}
@@ -0,0 +1,136 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Level is the severity of a recorded node.
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelWarn
+	LevelError
+	LevelPanic
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	case LevelPanic:
+		return "panic"
+	}
+	return "unknown"
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "info":
+		*l = LevelInfo
+	case "warn":
+		*l = LevelWarn
+	case "error":
+		*l = LevelError
+	case "panic":
+		*l = LevelPanic
+	default:
+		return fmt.Errorf("trace: unknown level %q", text)
+	}
+	return nil
+}
+
+// Entry is one recorded node in the flattened form of a trace.
+type Entry struct {
+	TraceId      string        `json:"trace_id"`
+	SpanId       string        `json:"span_id"`
+	ParentSpanId string        `json:"parent_span_id,omitempty"`
+	Span         string        `json:"span"`
+	Peer         *Peer         `json:"peer,omitempty"`
+	Dependency   *Dependency   `json:"dependency,omitempty"`
+	Slow         bool          `json:"slow,omitempty"` // the span is slow
+	Resource     *Resource     `json:"resource,omitempty"`
+	Depth        int           `json:"depth"`
+	Level        Level         `json:"level"`
+	Code         int           `json:"code,omitempty"`     // errors only
+	Category     Category      `json:"category,omitempty"` // errors only
+	Func         string        `json:"func"`
+	Line         string        `json:"line"`
+	Name         string        `json:"name,omitempty"` // set for events only
+	Time         time.Time     `json:"time"`
+	Data         []interface{} `json:"data"`
+	Stack        []string      `json:"stack,omitempty"`
+	// Tags are set with ErrorTagged
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// Entries returns the nodes of the trace in tree order, with their params
+// rendered the same way Log would print them.
+func (tc *TraceContext) Entries() []Entry {
+	return tc.snapshot().entries(make([]Entry, 0, 16), 0)
+}
+
+func (s *spanSnapshot) entries(res []Entry, depth int) []Entry {
+	return s.entriesOf(res, depth, s.resource)
+}
+
+func (s *spanSnapshot) entriesOf(res []Entry, depth int, resource *Resource) []Entry {
+	var peer *Peer
+	if !s.peer.IsZero() {
+		p := s.peer
+		peer = &p
+	}
+	for _, v := range s.timeline() {
+		res = append(res, Entry{
+			TraceId:      formatTraceID(s.traceId),
+			SpanId:       formatSpanID(s.spanId),
+			ParentSpanId: formatSpanID(s.parentId),
+			Span:         s.funcName,
+			Peer:         peer,
+			Dependency:   s.dependency,
+			Slow:         s.slow,
+			Resource:     resource,
+			Depth:        depth,
+			Level:        v.Level,
+			Code:         v.Code,
+			Category:     v.Category,
+			Func:         v.Func,
+			Line:         strconv.Itoa(v.Line),
+			Name:         v.Name,
+			Time:         v.Time,
+			Data:         v.Data,
+			Stack:        v.Stack,
+			Tags:         v.Tags,
+		})
+	}
+	for _, v := range s.children {
+		res = v.entriesOf(res, depth+1, resource)
+	}
+	return res
+}
+
+// writeEntries writes entries as JSON lines.
+func writeEntries(w io.Writer, entries []Entry) (int, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return 0, err
+		}
+	}
+	return w.Write(buf.Bytes())
+}
@@ -0,0 +1,58 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_Entries(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	tc.Info("start")
+	child := tc.Trace()
+	_ = child.Error("failed", Lazy(func() interface{} { return 42 }))
+	tc.Info("end")
+
+	entries := tc.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(entries))
+	}
+	if entries[0].Level != LevelInfo || entries[1].Data[0] != "end" {
+		t.Fatalf("root nodes should come first: %+v", entries)
+	}
+	if e := entries[2]; e.Level != LevelError || e.Depth != 1 || e.Data[1] != 42 || e.TraceId != tc.TraceID() {
+		t.Fatalf("unexpected child entry %+v", e)
+	}
+}
+
+func TestWithFlatLogger(t *testing.T) {
+	tree, flat := &bytes.Buffer{}, &bytes.Buffer{}
+	calls := 0
+	tc := NewTraceContext(context.Background(), tree, WithFlatLogger(flat))
+	tc.Info("load", Lazy(func() interface{} {
+		calls++
+		return calls
+	}))
+	_ = tc.Trace().Error("failed")
+	tc.Log()
+
+	if calls != 1 {
+		t.Fatalf("both views must share one snapshot, lazy evaluated %d times", calls)
+	}
+	if !strings.Contains(tree.String(), `["load",1]`) {
+		t.Fatalf("unexpected tree: %s", tree.String())
+	}
+	lines := strings.Split(strings.TrimSpace(flat.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 json lines, got %q", flat.String())
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(lines[0], `"data":["load",1]`) || !strings.Contains(lines[1], `"level":"error"`) || e.Depth != 1 {
+		t.Fatalf("unexpected flat output: %s", flat.String())
+	}
+}
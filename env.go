@@ -0,0 +1,97 @@
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envFormats are the values of TRACE_FORMAT.
+var envFormats = map[string]Format{
+	"tree":      FormatTree,
+	"json":      FormatJSON,
+	"otlp":      FormatOTLP,
+	"tracedata": FormatTraceData,
+	"protobuf":  FormatProtobuf,
+	"logfmt":    FormatLogfmt,
+	"zipkin":    FormatZipkin,
+}
+
+// FromEnv returns the options set by the environment, so that deployments
+// can tune traces without changing code:
+//
+//	TRACE_LEVEL   lowest level of the records kept: info, warn or error, see WithMinLevel
+//	TRACE_FORMAT  tree, json, otlp, tracedata, protobuf, logfmt or zipkin, see WithFormat
+//	TRACE_SAMPLE  fraction of the traces without errors written, in (0,1], see WithSampleRate
+//	TRACE_COLOR   false to write the tree without colors, see WithoutColor
+//	TRACE_OUTPUT  stdout, stderr or the path of a file to append to, see WithOutput
+//
+// Unset or empty variables add no option. Options given after the returned
+// ones override them:
+//
+//	env, err := trace.FromEnv()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	tc := trace.NewTraceContext(ctx, os.Stderr, append(env, trace.WithRecent())...)
+//
+// An invalid value is an error naming the variable. The file of
+// TRACE_OUTPUT is opened once, by FromEnv, and stays open.
+func FromEnv() ([]Option, error) {
+	var opts []Option
+	if v := os.Getenv("TRACE_LEVEL"); v != "" {
+		var level Level
+		if err := level.UnmarshalText([]byte(strings.ToLower(v))); err != nil {
+			return nil, envError("TRACE_LEVEL", v, err)
+		}
+		opts = append(opts, WithMinLevel(level))
+	}
+	if v := os.Getenv("TRACE_FORMAT"); v != "" {
+		f, ok := envFormats[strings.ToLower(v)]
+		if !ok {
+			return nil, envError("TRACE_FORMAT", v, errors.New("unknown format"))
+		}
+		opts = append(opts, WithFormat(f))
+	}
+	if v := os.Getenv("TRACE_SAMPLE"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		// a rate of 0 would write them all, see Config.SampleRate
+		if err == nil && (rate <= 0 || rate > 1) {
+			err = errors.New("not above 0 and at most 1")
+		}
+		if err != nil {
+			return nil, envError("TRACE_SAMPLE", v, err)
+		}
+		opts = append(opts, WithSampleRate(rate))
+	}
+	if v := os.Getenv("TRACE_COLOR"); v != "" {
+		color, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, envError("TRACE_COLOR", v, err)
+		}
+		if !color {
+			opts = append(opts, WithoutColor())
+		}
+	}
+	if v := os.Getenv("TRACE_OUTPUT"); v != "" {
+		switch v {
+		case "stdout":
+			opts = append(opts, WithOutput(os.Stdout))
+		case "stderr":
+			opts = append(opts, WithOutput(os.Stderr))
+		default:
+			f, err := os.OpenFile(v, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+			if err != nil {
+				return nil, envError("TRACE_OUTPUT", v, err)
+			}
+			opts = append(opts, WithOutput(f))
+		}
+	}
+	return opts, nil
+}
+
+func envError(name, value string, err error) error {
+	return fmt.Errorf("trace: %s=%q: %w", name, value, err)
+}
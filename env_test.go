@@ -0,0 +1,103 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.log")
+	t.Setenv("TRACE_LEVEL", "warn")
+	t.Setenv("TRACE_FORMAT", "json")
+	t.Setenv("TRACE_OUTPUT", path)
+	opts, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTraceContext(context.Background(), os.Stderr, opts...)
+	tc.Info("dropped below warn")
+	tc.Warn("kept")
+	tc.Log()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("want the warning only, as JSON lines in the file:\n%s", b)
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Level != LevelWarn {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+}
+
+func TestFromEnv_color(t *testing.T) {
+	t.Setenv("TRACE_COLOR", "false")
+	opts, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, opts...)
+	_ = tc.Error("boom")
+	tc.Log()
+	if strings.Contains(buf.String(), "\x1b[") || !strings.Contains(buf.String(), "boom") {
+		t.Fatalf("want the tree without colors:\n%q", buf.String())
+	}
+}
+
+func TestFromEnv_sample(t *testing.T) {
+	t.Setenv("TRACE_SAMPLE", "0.000001")
+	opts, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	logged := 0
+	for i := 0; i < 20; i++ {
+		tc := NewTraceContext(context.Background(), buf, opts...)
+		tc.Log()
+		if buf.Len() > 0 {
+			logged++
+			buf.Reset()
+		}
+	}
+	if logged > 1 {
+		t.Fatalf("%d of 20 traces logged at a rate of 0.000001", logged)
+	}
+}
+
+func TestFromEnv_sampleAboveOne(t *testing.T) {
+	t.Setenv("TRACE_SAMPLE", "2")
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("want an error for a rate above 1")
+	}
+}
+
+func TestFromEnv_invalid(t *testing.T) {
+	for name, value := range map[string]string{
+		"TRACE_LEVEL":  "verbose",
+		"TRACE_FORMAT": "xml",
+		"TRACE_SAMPLE": "0",
+		"TRACE_COLOR":  "maybe",
+		"TRACE_OUTPUT": filepath.Join(t.TempDir(), "missing", "traces.log"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := FromEnv(); err == nil || !strings.Contains(err.Error(), name) {
+				t.Fatalf("want an error naming %s, got %v", name, err)
+			}
+		})
+	}
+}
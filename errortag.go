@@ -0,0 +1,70 @@
+package trace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrorTagged records err like Error, with tags such as retryable=true or
+// owner=payments carried on the error node: they are exported with it and,
+// for the keys allowed by WithMetricLabels, given as labels to the
+// trace_errors_total counter of WithMetrics, so that routing and alerting
+// policies can act on them. Keep their values to a small set, metrics
+// systems do not cope with unbounded labels.
+//
+//	return tc.ErrorTagged(err, map[string]string{"retryable": "true", "owner": "payments"})
+func (tc *TraceContext) ErrorTagged(err error, tags map[string]string) error {
+	var own map[string]string
+	if len(tags) > 0 {
+		own = make(map[string]string, len(tags))
+		for k, v := range tags {
+			own[k] = v
+		}
+	}
+	return tc.errorTagged(2, 0, own, []interface{}{err})
+}
+
+// sortedTags returns the keys of tags in order, so that exports are
+// deterministic.
+func sortedTags(tags map[string]string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// redactTags returns tags with their values redacted by key, as the tags
+// of spans are.
+func (tc *TraceContext) redactTags(tags map[string]string) map[string]string {
+	if len(tags) == 0 || tc.opts.redactor == nil {
+		return tags
+	}
+	res := make(map[string]string, len(tags))
+	for k, v := range tags {
+		res[k] = fmt.Sprint(tc.redactKey(k, v))
+	}
+	return res
+}
+
+// formatTags renders the tags of an error node in the tree.
+func (n *node) formatTags() string {
+	if len(n.Tags) == 0 {
+		return ""
+	}
+	var str strings.Builder
+	str.WriteString(" [")
+	for i, k := range sortedTags(n.Tags) {
+		if i > 0 {
+			str.WriteString(" ")
+		}
+		str.WriteString(k + "=" + n.Tags[k])
+	}
+	str.WriteString("]")
+	return str.String()
+}
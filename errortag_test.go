@@ -0,0 +1,84 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_ErrorTagged(t *testing.T) {
+	var labels []string
+	metrics := WithMetrics(MetricsFunc(func(name string, delta int64, l ...string) {
+		if name == "trace_errors_total" {
+			labels = l
+		}
+	}))
+	tree, flat, logfmt := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	logger := MultiLogger(tree, Formatted(flat, FormatJSON), Formatted(logfmt, FormatLogfmt))
+	tc := NewTraceContext(context.Background(), logger, metrics, WithMetricLabels("retryable", "region"))
+	tags := map[string]string{"retryable": "true", "owner": "payments"}
+	err := errors.New("card declined")
+	if got := tc.ErrorTagged(err, tags); !errors.Is(got, err) {
+		t.Fatalf("ErrorTagged returned %v", got)
+	}
+	tags["owner"] = "changed"
+	tc.Log()
+
+	if want := []string{"category", "unknown", "code", "0", "retryable", "true"}; !reflect.DeepEqual(labels, want) {
+		t.Fatalf("metric labels %v, want %v", labels, want)
+	}
+	if !strings.Contains(tree.String(), `["card declined"] [owner=payments retryable=true]`) {
+		t.Fatalf("tags missing from the tree:\n%s", tree.String())
+	}
+	if !strings.Contains(flat.String(), `"tags":{"owner":"payments","retryable":"true"}`) {
+		t.Fatalf("tags missing from the entries:\n%s", flat.String())
+	}
+	if !strings.HasSuffix(strings.TrimSpace(logfmt.String()), "tag.owner=payments tag.retryable=true") {
+		t.Fatalf("tags missing from logfmt:\n%s", logfmt.String())
+	}
+
+	data := tc.Snapshot()
+	if !reflect.DeepEqual(data.Errors[0].Tags, map[string]string{"retryable": "true", "owner": "payments"}) {
+		t.Fatalf("unexpected tags %v", data.Errors[0].Tags)
+	}
+	b, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got TraceData
+	if err := got.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Errors[0].Tags, data.Errors[0].Tags) {
+		t.Fatalf("tags lost on the wire: %v", got.Errors[0].Tags)
+	}
+
+	span := data.snapshot().otlpSpan()
+	attrs := map[string]string{}
+	for _, kv := range span.Events[0].Attributes {
+		attrs[kv.Key] = kv.Value.String()
+	}
+	if attrs["error.tag.owner"] != "payments" || attrs["error.tag.retryable"] != "true" {
+		t.Fatalf("tags missing from the OTLP event: %v", attrs)
+	}
+}
+
+func TestTraceContext_ErrorTaggedHook(t *testing.T) {
+	hook := WithRecordHook(RecordHookFunc(func(span *TraceContext, r *NodeData) bool {
+		if r.Level >= LevelError && r.Tags["owner"] == "" {
+			r.Tags = map[string]string{"owner": "platform"}
+		}
+		return true
+	}))
+	tc := NewTraceContext(context.Background(), nil, hook)
+	_ = tc.Error("untagged")
+	_ = tc.ErrorTagged(errors.New("tagged"), map[string]string{"owner": "payments"})
+
+	data := tc.Snapshot()
+	if data.Errors[0].Tags["owner"] != "platform" || data.Errors[1].Tags["owner"] != "payments" {
+		t.Fatalf("record hooks should see and set tags: %v %v", data.Errors[0].Tags, data.Errors[1].Tags)
+	}
+}
@@ -0,0 +1,41 @@
+package trace
+
+// escalated is an info recorded with WithEscalationBuffer before the trace
+// failed, waiting to be added to its span.
+type escalated struct {
+	span *TraceContext
+	n    *node
+}
+
+// buffer keeps n, recorded on span before the trace failed, among the last
+// size infos to add once it fails. It reports false if the trace failed in
+// the meantime, n is then to be recorded right away.
+func (s *traceState) buffer(span *TraceContext, n *node, size int) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.failed() {
+		return false
+	}
+	if len(s.escalation) < size {
+		s.escalation = append(s.escalation, escalated{span, n})
+		return true
+	}
+	s.escalation[s.escalationNext] = escalated{span, n}
+	s.escalationNext = (s.escalationNext + 1) % size
+	return true
+}
+
+// flushEscalation adds the infos buffered until the first error to their
+// spans, oldest first.
+func (s *traceState) flushEscalation() {
+	s.mux.Lock()
+	buffered, next := s.escalation, s.escalationNext
+	s.escalation, s.escalationNext = nil, 0
+	s.mux.Unlock()
+	for i := range buffered {
+		v := buffered[(next+i)%len(buffered)]
+		v.span.mux.Lock()
+		v.span.infos = append(v.span.infos, v.n)
+		v.span.mux.Unlock()
+	}
+}
@@ -0,0 +1,61 @@
+package trace
+
+import (
+	"fmt"
+)
+
+// Event records a point in time on the span, such as "cache miss" or
+// "retry scheduled". Unlike Trace it does not open a nested span. attrs are
+// key/value pairs.
+func (tc *TraceContext) Event(name string, attrs ...interface{}) {
+	tc.event(2, name, attrs)
+}
+
+// event records an event attributed to the caller skip frames up.
+func (tc *TraceContext) event(skip int, name string, attrs []interface{}) {
+	funcName, line := caller(skip + tc.opts.callerSkip)
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.events = append(tc.events, newNode(node{
+		Line: line,
+		Func: funcName,
+		Name: name,
+		Time: tc.now(),
+		Data: attrs,
+		seq:  tc.state.nextSeq(),
+	}))
+}
+
+// renderAttrs turns recorded key/value pairs into a map ready to be
+// serialized. Only the values count against limits.
+func (tc *TraceContext) renderAttrs(attrs []interface{}, limits *sizeLimits) map[string]interface{} {
+	keys, values := tc.renderPairs(attrs)
+	limits.apply(values, 0, 1)
+	res := make(map[string]interface{}, len(keys))
+	for i, k := range keys {
+		res[k] = values[i]
+	}
+	return res
+}
+
+// renderPairs splits recorded key/value pairs and renders every value like
+// renderParams, the redactor getting its key. A pair whose value the zero
+// mode drops is dropped whole; a key without value gets nil.
+func (tc *TraceContext) renderPairs(kv []interface{}) (keys []string, values []interface{}) {
+	keys = make([]string, 0, len(kv)/2+1)
+	values = make([]interface{}, 0, len(kv)/2+1)
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprint(resolveParam(kv[i]))
+		var v interface{}
+		if i+1 < len(kv) {
+			v = kv[i+1]
+		}
+		rendered := tc.applyZeroMode(tc.encodeParams([]interface{}{resolveParam(v)}))
+		if len(rendered) == 0 {
+			continue
+		}
+		keys = append(keys, key)
+		values = append(values, tc.redactKey(key, rendered[0]))
+	}
+	return keys, values
+}
@@ -0,0 +1,42 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestTraceContext_Event(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	child := tc.Trace()
+	child.Event("cache miss", "key", "user:1", "ttl", 30)
+	tc.Log()
+
+	if !regexp.MustCompile(`├\* \d\d:\d\d:\d\d\.\d{3} cache miss \{"key":"user:1","ttl":30\}\n`).Match(buf.Bytes()) {
+		t.Fatalf("event not rendered inline: %s", buf.String())
+	}
+	entries := tc.Entries()
+	if len(entries) != 1 || entries[0].Name != "cache miss" || entries[0].Time.IsZero() {
+		t.Fatalf("event missing from entries: %+v", entries)
+	}
+	if len(child.children) != 0 {
+		t.Fatalf("an event must not open a child span")
+	}
+}
+
+func TestTraceContext_EventZeroModeAndRedaction(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil, WithZeroMode(ZeroSkip), WithRedactor(DefaultRedactor))
+	tc.Event("cache", "key", "", "hits", 3)
+	tc.Event("login", "user", "ann", "password", "hunter2")
+
+	data := tc.Snapshot()
+	if got := data.Events[0].Attrs; !reflect.DeepEqual(got, map[string]interface{}{"hits": 3}) {
+		t.Fatalf("zero values should drop their pair only: %v", got)
+	}
+	if got := data.Events[1].Attrs; !reflect.DeepEqual(got, map[string]interface{}{"user": "ann", "password": "***"}) {
+		t.Fatalf("values of secret keys should be masked: %v", got)
+	}
+}
@@ -0,0 +1,65 @@
+package trace
+
+import (
+	"strings"
+	"sync"
+)
+
+// funcFilter decides which functions may record infos. It is built once per
+// option, so its cache of results per function is shared by every trace
+// using that option.
+type funcFilter struct {
+	patterns []string
+	exclude  bool
+	cache    sync.Map // func name -> bool
+}
+
+func (f *funcFilter) allows(funcName string) bool {
+	if v, ok := f.cache.Load(funcName); ok {
+		return v.(bool)
+	}
+	ok := matchAny(f.patterns, funcName) != f.exclude
+	f.cache.Store(funcName, ok)
+	return ok
+}
+
+// funcsAllowed reports whether funcName passes every filter.
+func (o *options) funcsAllowed(funcName string) bool {
+	for _, f := range o.funcs {
+		if !f.allows(funcName) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches s against pattern, where * stands for any run of
+// characters, dots and slashes included.
+func matchGlob(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, p)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(p):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	for _, c := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"pkg.F", "pkg.F", true},
+		{"pkg.F", "pkg.Foo", false},
+		{"*", "", true},
+		{"github.com/acme/*", "github.com/acme/shop/cart.Add", true},
+		{"*.(*Cart).*", "github.com/acme/shop/cart.(*Cart).Add", true},
+		{"*cart*Add", "github.com/acme/shop/cart.(*Cart).Add", true},
+		{"*cart*Remove", "github.com/acme/shop/cart.(*Cart).Add", false},
+		{"a*a", "a", false},
+	} {
+		if got := matchGlob(c.pattern, c.s); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v", c.pattern, c.s, got)
+		}
+	}
+}
+
+func helper(tc *TraceContext) {
+	tc.Info("noise")
+	_ = tc.Error("still recorded")
+}
+
+func TestFuncFilters(t *testing.T) {
+	exclude := WithExcludeFuncs("*.helper")
+	for i := 0; i < 2; i++ {
+		tc := NewTraceContext(context.Background(), nil, exclude)
+		tc.Info("kept")
+		helper(tc)
+		if len(tc.infos) != 1 || len(tc.errors) != 1 {
+			t.Fatalf("only the helper's infos should be dropped: %d infos %d errors", len(tc.infos), len(tc.errors))
+		}
+	}
+
+	tc := NewTraceContext(context.Background(), nil, WithIncludeFuncs("*.helper"))
+	tc.Info("dropped")
+	helper(tc)
+	if len(tc.infos) != 1 || tc.infos[0].Data[0] != "noise" {
+		t.Fatalf("only the helper's infos should be kept: %+v", tc.infos)
+	}
+}
@@ -0,0 +1,82 @@
+package trace
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// FuncNames selects how much of the function names recorded by the runtime,
+// such as "github.com/org/repo/pkg.(*T).Method", the trace keeps.
+type FuncNames int
+
+const (
+	// FullFuncNames keeps the names as the runtime reports them.
+	FullFuncNames FuncNames = iota
+	// ModuleFuncNames trims the path of the main module, keeping
+	// "pkg.(*T).Method" for its packages and the full name of the others.
+	ModuleFuncNames
+	// ShortFuncNames keeps the last element of the package path only:
+	// "pkg.(*T).Method".
+	ShortFuncNames
+)
+
+// mainModule is the path of the main module, empty if the binary was built
+// without module support.
+var mainModule = func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Path
+	}
+	return ""
+}()
+
+// trim returns the function to trim names with, nil for FullFuncNames.
+func (f FuncNames) trim() func(string) string {
+	switch f {
+	case ModuleFuncNames:
+		return TrimModule
+	case ShortFuncNames:
+		return ShortFuncName
+	default:
+		return nil
+	}
+}
+
+// TrimModule trims the path of the main module from the function name. The
+// functions of the package at the root of the module keep its last element,
+// like "repo.Func".
+func TrimModule(name string) string {
+	if mainModule == "" || !strings.HasPrefix(name, mainModule) {
+		return name
+	}
+	switch rest := name[len(mainModule):]; {
+	case strings.HasPrefix(rest, "/"):
+		return rest[1:]
+	case strings.HasPrefix(rest, "."):
+		return ShortFuncName(name)
+	default:
+		return name
+	}
+}
+
+// ShortFuncName trims the package path of the function name but its last
+// element, "github.com/org/repo/pkg.Func" becoming "pkg.Func". The type
+// arguments of generic functions, which may hold paths, are left as they
+// are.
+func ShortFuncName(name string) string {
+	path := name
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		path = path[:i]
+	}
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// funcNameOf returns name trimmed as set with WithFuncNames.
+func (tc *TraceContext) funcNameOf(name string) string {
+	if trim := tc.opts.trimFunc; trim != nil {
+		return trim(name)
+	}
+	return name
+}
@@ -0,0 +1,53 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestShortFuncName(t *testing.T) {
+	cases := map[string]string{
+		"github.com/org/repo/pkg.Func":              "pkg.Func",
+		"github.com/org/repo/pkg.(*T).Method.func1": "pkg.(*T).Method.func1",
+		"main.main": "main.main",
+		"github.com/org/repo/pkg.Map[go.shape.*example.com/x.T]": "pkg.Map[go.shape.*example.com/x.T]",
+	}
+	for name, want := range cases {
+		if got := ShortFuncName(name); got != want {
+			t.Errorf("ShortFuncName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWithFuncNames(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithFuncNames(ShortFuncNames))
+	load(tc)
+	tc.Event("loaded")
+	tc.Log()
+
+	if strings.Contains(buf.String(), "github.com/") {
+		t.Fatalf("function names should be trimmed: %s", buf.String())
+	}
+	data := tc.Snapshot()
+	if data.Func != "trace.TestWithFuncNames" || data.Children[0].Func != "trace.load" ||
+		data.Children[0].Infos[0].Func != "trace.load" || data.Events[0].Func != "trace.TestWithFuncNames" {
+		t.Fatalf("every format should get the trimmed names: %+v", data)
+	}
+
+	tc = NewTraceContext(context.Background(), nil, WithFuncNames(ModuleFuncNames))
+	load(tc)
+	if got := tc.Snapshot().Children[0].Func; got != "trace.load" {
+		t.Fatalf("the root package should keep its name: %q", got)
+	}
+
+	buf.Reset()
+	tc = NewTraceContext(context.Background(), buf, WithFuncNameTrim(strings.ToUpper))
+	load(tc)
+	tc.Log()
+	if !strings.Contains(buf.String(), "GITHUB.COM/MUCOLUD/TRACE.LOAD:") {
+		t.Fatalf("the custom trim should apply: %s", buf.String())
+	}
+}
@@ -0,0 +1,73 @@
+// Package gintrace creates a TraceContext for every request served by gin.
+package gintrace
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mucolud/trace"
+)
+
+// Config configures the middleware returned by New.
+type Config struct {
+	// Logger receives the finished traces.
+	Logger io.Writer
+	// Options are passed to trace.NewTraceContext for every request.
+	Options []trace.Option
+	// TraceIDHeader writes the trace id to the response in the
+	// trace.TraceIDHeader header, so users can quote it to support.
+	TraceIDHeader bool
+	// DebugHeader traces the requests carrying a true trace.DebugHeader
+	// in full, see trace.WithDebug. Only enable it where clients are
+	// trusted, or strip the header at the edge.
+	DebugHeader bool
+}
+
+// Middleware creates a TraceContext per request logging to logger, see New.
+func Middleware(logger io.Writer, opts ...trace.Option) gin.HandlerFunc {
+	return New(Config{Logger: logger, Options: opts})
+}
+
+// New creates a TraceContext per request and stores it in the request's
+// context with trace.NewContext. The route, status and any errors
+// pushed to c.Errors are recorded on it, and the trace is logged once the
+// handlers returned and the response was written.
+func New(cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts := cfg.Options
+		if cfg.DebugHeader && trace.ParseDebug(c.GetHeader(trace.DebugHeader)) {
+			opts = append(opts[:len(opts):len(opts)], trace.WithDebug())
+		}
+		tc := trace.NewTraceContext(c.Request.Context(), cfg.Logger, opts...)
+		tc.SetKind(trace.SpanKindServer)
+		tc.SetTag("http.method", c.Request.Method)
+		c.Request = c.Request.WithContext(trace.NewContext(c.Request.Context(), tc))
+		if cfg.TraceIDHeader {
+			c.Header(trace.TraceIDHeader, tc.TraceID())
+		}
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		tc.SetTag("http.route", route)
+		tc.SetTag("http.status", c.Writer.Status())
+		for _, e := range c.Errors {
+			_ = tc.Error(e.Err)
+		}
+		tc.Log()
+	}
+}
+
+// FromGin returns the request's TraceContext, which also travels in
+// c.Request.Context() for code that only takes a context.Context, see
+// trace.FromContext. Outside of Middleware it returns a trace that is never
+// logged, so handlers need not check for nil.
+func FromGin(c *gin.Context) *trace.TraceContext {
+	if tc, ok := trace.FromContext(c.Request.Context()); ok {
+		return tc
+	}
+	return trace.NewTraceContext(c.Request.Context(), nil)
+}
@@ -0,0 +1,86 @@
+package gintrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mucolud/trace"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve runs a request through the middleware configured with cfg and a
+// route calling handler, and returns the response and the logged trace.
+func serve(t *testing.T, cfg Config, req *http.Request, handler gin.HandlerFunc) (*httptest.ResponseRecorder, trace.TraceData) {
+	buf := &bytes.Buffer{}
+	cfg.Logger = trace.Formatted(buf, trace.FormatTraceData)
+	w := httptest.NewRecorder()
+	c, engine := gin.CreateTestContext(w)
+	engine.Use(New(cfg))
+	engine.GET("/users/:id", handler)
+	c.Request = req
+	engine.HandleContext(c)
+
+	var data trace.TraceData
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("no trace logged: %v %q", err, buf.String())
+	}
+	return w, data
+}
+
+func TestNew(t *testing.T) {
+	var inHandler *trace.TraceContext
+	w, data := serve(t, Config{}, httptest.NewRequest(http.MethodGet, "/users/7", nil), func(c *gin.Context) {
+		inHandler = FromGin(c)
+		inHandler.Info("loading")
+		_ = c.Error(errors.New("not found"))
+		c.Status(http.StatusNotFound)
+	})
+
+	if w.Code != http.StatusNotFound || w.Header().Get(trace.TraceIDHeader) != "" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	if data.TraceId != inHandler.TraceID() || data.Kind != trace.SpanKindServer {
+		t.Fatalf("unexpected trace %+v", data)
+	}
+	if data.Tags["http.method"] != "GET" || data.Tags["http.route"] != "/users/:id" || data.Tags["http.status"] != float64(404) {
+		t.Fatalf("unexpected tags %v", data.Tags)
+	}
+	if len(data.Infos) != 1 || len(data.Errors) != 1 || data.Errors[0].Data[0] != "not found" {
+		t.Fatalf("the records of the handler and c.Errors should be logged: %+v", data)
+	}
+}
+
+func TestNew_traceIDHeader(t *testing.T) {
+	w, data := serve(t, Config{TraceIDHeader: true}, httptest.NewRequest(http.MethodGet, "/users/7", nil), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	if got := w.Header().Get(trace.TraceIDHeader); got == "" || got != data.TraceId {
+		t.Fatalf("want the trace id %q in the response, got %q", data.TraceId, got)
+	}
+}
+
+func TestNew_debugHeader(t *testing.T) {
+	debug := func(cfg Config) bool {
+		req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+		req.Header.Set(trace.DebugHeader, "1")
+		var isDebug bool
+		serve(t, cfg, req, func(c *gin.Context) {
+			isDebug = FromGin(c).IsDebug()
+		})
+		return isDebug
+	}
+	if debug(Config{}) {
+		t.Fatal("the debug header must be ignored unless enabled")
+	}
+	if !debug(Config{DebugHeader: true}) {
+		t.Fatal("the debug header should make a debug trace")
+	}
+}
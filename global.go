@@ -0,0 +1,126 @@
+package trace
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// The package level Info, Error and Event are for code that cannot be
+// handed a *TraceContext. They record to the trace bound to the calling
+// goroutine with Bind:
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		tc := trace.NewTraceContext(r.Context(), os.Stderr)
+//		defer tc.Log()
+//		defer trace.Bind(tc)()
+//		legacy.Process() // calls trace.Info deep down
+//	}
+//
+// Finding the calling goroutine costs a few microseconds, so prefer passing
+// the trace where possible.
+
+var (
+	goroutines sync.Map // goroutine id -> *TraceContext
+	bound      int32    // number of goroutines with a bound trace
+	keyed      sync.Map // user key -> *TraceContext
+
+	// unbound builds the errors returned by Error when no trace is bound
+	unbound = &TraceContext{opts: newOptions(nil)}
+)
+
+// Bind makes tc the trace of the calling goroutine until the returned func
+// is called. Goroutines started with Go from a bound goroutine are bound to
+// their own span.
+func Bind(tc *TraceContext) (unbind func()) {
+	id := goid()
+	prev, ok := goroutines.Swap(id, tc)
+	if !ok {
+		atomic.AddInt32(&bound, 1)
+	}
+	return func() {
+		if ok {
+			goroutines.Store(id, prev)
+		} else {
+			goroutines.Delete(id)
+			atomic.AddInt32(&bound, -1)
+		}
+	}
+}
+
+// Current returns the trace bound to the calling goroutine, or nil.
+func Current() *TraceContext {
+	if atomic.LoadInt32(&bound) == 0 {
+		return nil
+	}
+	if tc, ok := goroutines.Load(goid()); ok {
+		return tc.(*TraceContext)
+	}
+	return nil
+}
+
+// Set stores tc under key, for code sharing a key rather than a goroutine
+// with the owner of the trace. A nil tc removes the key.
+func Set(key interface{}, tc *TraceContext) {
+	if tc == nil {
+		keyed.Delete(key)
+		return
+	}
+	keyed.Store(key, tc)
+}
+
+// Get returns the trace stored under key with Set, or nil.
+func Get(key interface{}) *TraceContext {
+	if tc, ok := keyed.Load(key); ok {
+		return tc.(*TraceContext)
+	}
+	return nil
+}
+
+// Info records params on the trace bound to the calling goroutine. It does
+// nothing if there is none.
+func Info(params ...interface{}) {
+	if tc := Current(); tc != nil {
+		tc.info(2, params)
+	}
+}
+
+// Warn records params as a warning on the trace bound to the calling
+// goroutine, like TraceContext.Warn. It does nothing if there is none.
+func Warn(params ...interface{}) {
+	if tc := Current(); tc != nil {
+		tc.warn(2, params)
+	}
+}
+
+// Error records params on the trace bound to the calling goroutine and
+// returns them as an error, like TraceContext.Error. Without a bound trace
+// it only builds the error.
+func Error(params ...interface{}) error {
+	if tc := Current(); tc != nil {
+		return tc.error(2, 0, params)
+	}
+	return unbound.convertToError(params)
+}
+
+// Event records an event on the trace bound to the calling goroutine. It
+// does nothing if there is none.
+func Event(name string, attrs ...interface{}) {
+	if tc := Current(); tc != nil {
+		tc.event(2, name, attrs)
+	}
+}
+
+// goid returns the id of the calling goroutine, parsed from the header of
+// its stack trace: "goroutine 42 [running]:".
+func goid() int64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
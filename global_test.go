@@ -0,0 +1,64 @@
+package trace
+
+import (
+	"context"
+	"testing"
+)
+
+func legacy() {
+	Info("legacy", 1)
+	Event("cache miss")
+	_ = Error("legacy failed")
+}
+
+func TestBind(t *testing.T) {
+	legacy() // nothing bound, nothing recorded
+	if err := Error("x", 1); err == nil || err.Error() != "x,1" {
+		t.Fatalf("Error should build the error even without a trace: %v", err)
+	}
+
+	tc := NewTraceContext(context.Background(), nil)
+	unbind := Bind(tc)
+	if Current() != tc {
+		t.Fatalf("tc should be bound to the goroutine")
+	}
+	legacy()
+	tc.Go(func(child *TraceContext) {
+		if Current() != child {
+			t.Errorf("goroutine should be bound to its own span")
+		}
+		Info("from goroutine")
+	})
+	tc.state.wait(0)
+	unbind()
+	legacy()
+
+	if Current() != nil {
+		t.Fatalf("unbind should clear the goroutine")
+	}
+	entries := tc.Entries()
+	if len(entries) != 4 || entries[0].Func != "github.com/mucolud/trace.legacy" || entries[0].Line != "9" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if entries[3].Data[0] != "from goroutine" || entries[3].Depth != 1 {
+		t.Fatalf("goroutine record should land on the child span: %+v", entries[3])
+	}
+
+	go func() {
+		if Current() != nil {
+			t.Errorf("other goroutines must not see the binding")
+		}
+	}()
+}
+
+func TestSetGet(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	Set("job-1", tc)
+	if Get("job-1") != tc || Get("job-2") != nil {
+		t.Fatalf("unexpected lookup")
+	}
+	Set("job-1", nil)
+	if Get("job-1") != nil {
+		t.Fatalf("nil should remove the key")
+	}
+}
@@ -1,5 +1,3 @@
 module github.com/mucolud/trace
 
-go 1.15
-
-require github.com/mucolud/lib v0.0.0-20190107094413-0ce73ba07ea2
+go 1.20
@@ -0,0 +1,125 @@
+package trace
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Go runs fn in a new goroutine with its own child span. A panic in fn is
+// recovered and recorded as an error on that span instead of crashing the
+// process. Log marks the span as running until fn returns, or waits for it
+// with WithWaitGoroutines. The span is labelled with the id of the new
+// goroutine, see Label.
+func (tc *TraceContext) Go(fn func(child *TraceContext)) {
+	child := tc.trace(2, nil)
+	atomic.StoreInt32(&child.running, 1)
+	tc.state.running.Add(1)
+	bind := Current() != nil
+	go func() {
+		defer tc.state.running.Done()
+		if bind {
+			defer Bind(child)()
+		}
+		defer atomic.StoreInt32(&child.running, 0)
+		defer child.End()
+		child.labelGoroutine()
+		defer func() {
+			if r := recover(); r != nil {
+				child.recordPanic(r)
+			}
+		}()
+		fn(child)
+	}()
+}
+
+func (tc *TraceContext) isRunning() bool {
+	return atomic.LoadInt32(&tc.running) == 1
+}
+
+// RecordPanic records r, the value of a recovered panic, as an error of
+// level LevelPanic attributed to the function that panicked, for code
+// recovering panics itself rather than running fn with Go. It must be
+// called from the deferred function calling recover.
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			tc.RecordPanic(r)
+//		}
+//	}()
+func (tc *TraceContext) RecordPanic(r interface{}) {
+	tc.recordPanic(r)
+}
+
+// recordPanic records r as an error attributed to the function that
+// panicked. It must be called from the deferred recover.
+func (tc *TraceContext) recordPanic(r interface{}) {
+	stack := panicStack()
+	var funcName string
+	var line int
+	if len(stack) > 0 {
+		funcName, line = stack[0].Function, stack[0].Line
+	}
+	if depth := tc.opts.stackDepth; depth < len(stack) {
+		stack = stack[:depth]
+	}
+	n := node{
+		Level:    LevelPanic,
+		Category: CategoryInternal,
+		Line:     line,
+		Func:     funcName,
+		Time:     tc.now(),
+		Data:     []interface{}{"panic", fmt.Sprint(r)},
+		Stack:    formatFrames(stack),
+		seq:      tc.state.nextSeq(),
+	}
+	if !tc.onRecord(&n) {
+		return
+	}
+	tc.mux.Lock()
+	tc.errors = append(tc.errors, newNode(n))
+	tc.mux.Unlock()
+	tc.countError(&n)
+	tc.state.markError()
+}
+
+// panicStack returns the frames below runtime.gopanic, starting at the
+// function that panicked.
+func panicStack() []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	var res []runtime.Frame
+	afterPanic := false
+	for {
+		f, more := frames.Next()
+		if afterPanic && (len(res) > 0 || !strings.HasPrefix(f.Function, "runtime.")) {
+			res = append(res, f)
+		}
+		if f.Function == "runtime.gopanic" {
+			afterPanic = true
+		}
+		if !more {
+			return res
+		}
+	}
+}
+
+// wait blocks until all goroutines started with Go returned, or timeout
+// passed when it is positive.
+func (s *traceState) wait(timeout time.Duration) {
+	if timeout <= 0 {
+		s.running.Wait()
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
@@ -0,0 +1,48 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTraceContext_Go(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithWaitGoroutines(time.Second))
+	tc.Go(func(child *TraceContext) {
+		child.Info("working")
+	})
+	tc.Go(func(child *TraceContext) {
+		var m map[string]int
+		m["boom"]++
+	})
+	tc.Log()
+
+	out := buf.String()
+	if !strings.Contains(out, `"working"`) {
+		t.Fatalf("goroutine record missing: %s", out)
+	}
+	if !strings.Contains(out, `├P github.com/mucolud/trace.TestTraceContext_Go.func2:`) ||
+		!strings.Contains(out, `"panic","assignment to entry in nil map"`) {
+		t.Fatalf("panic not recorded at its origin: %s", out)
+	}
+	if !tc.hasError() {
+		t.Fatalf("recovered panic should count as an error")
+	}
+}
+
+func TestTraceContext_GoRunning(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	release := make(chan struct{})
+	tc.Go(func(child *TraceContext) {
+		<-release
+	})
+	tc.Log()
+	close(release)
+	if !strings.Contains(buf.String(), "TestTraceContext_GoRunning (running)\n") {
+		t.Fatalf("in-flight span not marked: %s", buf.String())
+	}
+}
@@ -0,0 +1,132 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+)
+
+// MarshalBinary encodes the span and its subtree in the format of
+// TraceData.Marshal, so that another process can continue the trace with
+// Resume. Params are encoded as rendered. It implements
+// encoding.BinaryMarshaler, which gob uses to encode a *TraceContext.
+func (tc *TraceContext) MarshalBinary() ([]byte, error) {
+	data := tc.Snapshot()
+	return data.Marshal()
+}
+
+// UnmarshalBinary decodes a trace encoded by MarshalBinary into tc, which
+// must be a new zero TraceContext such as the one gob allocates. The trace
+// is logged nowhere until SetLogger is called; use Resume to choose the
+// context, logger and options.
+func (tc *TraceContext) UnmarshalBinary(b []byte) error {
+	var data TraceData
+	if err := data.Unmarshal(b); err != nil {
+		return err
+	}
+	tc.init(context.Background(), nil, newOptions(nil), &traceState{})
+	data.resumeInto(tc)
+	return nil
+}
+
+// Resume continues in this process a trace encoded by MarshalBinary in
+// another one. The spans, their ids, parent-child relationships and records
+// are restored as they were; the spans below the root keep their end, the
+// root stays open. Spans created from the returned root become part of the
+// same trace, which Log writes to logger as a whole.
+func Resume(ctx context.Context, logger io.Writer, b []byte, opts ...Option) (*TraceContext, error) {
+	var data TraceData
+	if err := data.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	tc := newTraceContext(ctx, logger, newOptions(opts), &traceState{})
+	data.resumeInto(tc)
+	if tc.opts.inFlight {
+		tc.registerInFlight()
+	}
+	return tc, nil
+}
+
+// resumeInto restores t into tc, a span just started, and rebuilds its
+// children.
+func (t *TraceData) resumeInto(tc *TraceContext) {
+	tc.traceId, _ = ParseTraceID(t.TraceId)
+	tc.spanId, _ = ParseTraceID(t.SpanId)
+	tc.parentId, _ = ParseTraceID(t.ParentSpanId)
+	tc.funcName = t.Func
+	tc.start = t.Start
+	tc.budget = t.Budget
+	tc.suppressed = t.Suppressed
+	tc.dependency = t.Dependency
+	tc.kind = t.Kind
+	tc.label = t.Label
+	if len(t.Args) > 0 {
+		tc.args = flattenArgs(t.Args)
+	}
+	if t.Peer != nil {
+		tc.peer = *t.Peer
+	}
+	if len(t.Tags) > 0 {
+		tc.tags = make(map[string]interface{}, len(t.Tags))
+		for k, v := range t.Tags {
+			tc.tags[k] = v
+		}
+	}
+	tc.infos = dataNodes(t.Infos)
+	for _, v := range tc.infos {
+		if v.Level == LevelWarn {
+			tc.warnings++
+		}
+	}
+	tc.errors = dataNodes(t.Errors)
+	// a resumed failure fails the trace here too, for WithEscalation and
+	// WithErrorOnly
+	if len(tc.errors) > 0 || t.Warned || tc.warned() {
+		tc.state.markError()
+	}
+	for _, v := range t.Events {
+		tc.events = append(tc.events, &node{
+			Level: v.Level,
+			Func:  v.Func,
+			Line:  v.Line,
+			Name:  v.Name,
+			Time:  v.Time,
+			Data:  flattenAttrs(v.Attrs),
+		})
+	}
+	tc.sequence()
+	tc.attachments = t.Attachments
+	for _, v := range t.Links {
+		tc.links = append(tc.links, &node{Name: v.TraceId, Time: v.Time, Data: flattenAttrs(v.Attrs)})
+	}
+	// only a status that cannot be inferred again was set explicitly
+	if t.Status != inferStatus(StatusUnset, len(t.Errors) > 0, nil) {
+		tc.status = t.Status
+	}
+	for i := range t.Children {
+		child := newTraceContext(tc, tc.logger, tc.opts, tc.state)
+		t.Children[i].resumeInto(child)
+		child.end = t.Children[i].End
+		if cause := t.Children[i].Cause; cause != "" {
+			child.endCause = errors.New(cause)
+		}
+		child.parent = tc
+		tc.children = append(tc.children, child)
+	}
+}
+
+// flattenAttrs turns attributes back into the key/value list Event records,
+// sorted by key.
+func flattenAttrs(m map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res := make([]interface{}, 0, 2*len(keys))
+	for _, k := range keys {
+		res = append(res, k, m[k])
+	}
+	return res
+}
@@ -0,0 +1,97 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"strings"
+	"testing"
+)
+
+func cli(tc *TraceContext) {
+	tc.SetTag("cmd", "deploy")
+	child := tc.Trace()
+	child.Info("plan", 3)
+	child.Event("uploaded", "bytes", 1024)
+	_ = child.Error("rejected")
+	child.End()
+}
+
+func daemon(tc *TraceContext) {
+	tc.Trace().Info("applied")
+}
+
+func TestResume(t *testing.T) {
+	src := NewTraceContext(context.Background(), nil)
+	cli(src)
+	b, err := src.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	tc, err := Resume(context.Background(), buf, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon(tc)
+	tc.Log()
+
+	data := tc.Snapshot()
+	if data.TraceId != src.TraceID() || data.SpanId != src.SpanID() || len(data.Children) != 2 {
+		t.Fatalf("the trace should be continued: %+v", data)
+	}
+	resumed, added := data.Children[0], data.Children[1]
+	if resumed.ParentSpanId != src.SpanID() || added.ParentSpanId != src.SpanID() || added.TraceId != src.TraceID() {
+		t.Fatalf("parent-child relationships should be kept: %+v", data.Children)
+	}
+	if resumed.Status != StatusError || len(resumed.Events) != 1 || resumed.Events[0].Attrs["bytes"] != 1024 {
+		t.Fatalf("records should be restored: %+v", resumed)
+	}
+	out := buf.String()
+	for _, want := range []string{"cmd=deploy", `["plan",3]`, `["rejected"]`, "uploaded {\"bytes\":1024}", `["applied"]`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("resumed trace should contain %s: %s", want, out)
+		}
+	}
+	if _, err := Resume(context.Background(), nil, []byte{0xff}); err == nil {
+		t.Fatal("invalid input should fail")
+	}
+}
+
+func TestResume_escalation(t *testing.T) {
+	src := NewTraceContext(context.Background(), nil)
+	cli(src)
+	b, err := src.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	tc, err := Resume(context.Background(), buf, b, WithEscalation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon(tc)
+	tc.Log()
+
+	if !strings.Contains(buf.String(), `["applied"]`) {
+		t.Fatalf("a resumed trace holding an error should keep later infos: %s", buf.String())
+	}
+}
+
+func TestTraceContext_Gob(t *testing.T) {
+	src := NewTraceContext(context.Background(), nil)
+	cli(src)
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(src); err != nil {
+		t.Fatal(err)
+	}
+	var tc *TraceContext
+	if err := gob.NewDecoder(buf).Decode(&tc); err != nil {
+		t.Fatal(err)
+	}
+	if tc.TraceID() != src.TraceID() || len(tc.Snapshot().Children) != 1 {
+		t.Fatalf("gob should round trip the trace: %+v", tc.Snapshot())
+	}
+}
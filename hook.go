@@ -0,0 +1,67 @@
+package trace
+
+// RecordHook is called for every info and error about to be recorded on a
+// span, panics included. It may change r, e.g. to add params, or return
+// false to drop it. The error returned by Error is built before the hooks
+// run and is not affected.
+type RecordHook interface {
+	OnRecord(span *TraceContext, r *NodeData) bool
+}
+
+// RecordHookFunc adapts a function to the RecordHook interface.
+type RecordHookFunc func(span *TraceContext, r *NodeData) bool
+
+func (f RecordHookFunc) OnRecord(span *TraceContext, r *NodeData) bool {
+	return f(span, r)
+}
+
+// LogHook is called by Log on the span being logged before the trace is
+// formatted, to enrich it, e.g. with tags such as the host or pod name, or to
+// forward it elsewhere. Returning false skips writing the trace.
+type LogHook interface {
+	OnLog(tc *TraceContext) bool
+}
+
+// LogHookFunc adapts a function to the LogHook interface.
+type LogHookFunc func(tc *TraceContext) bool
+
+func (f LogHookFunc) OnLog(tc *TraceContext) bool {
+	return f(tc)
+}
+
+// onRecord runs the record hooks on n and reports whether to record it.
+func (tc *TraceContext) onRecord(n *node) bool {
+	if len(tc.opts.recordHooks) == 0 {
+		return true
+	}
+	r := NodeData{
+		Level:    n.Level,
+		Code:     n.Code,
+		Category: n.Category,
+		Func:     n.Func,
+		Line:     n.Line,
+		Time:     n.Time,
+		Data:     n.Data,
+		Stack:    n.Stack,
+		Tags:     n.Tags,
+	}
+	for _, h := range tc.opts.recordHooks {
+		if !h.OnRecord(tc, &r) {
+			return false
+		}
+	}
+	n.Level, n.Code, n.Category = r.Level, r.Code, r.Category
+	n.Func, n.Line, n.Time = r.Func, r.Line, r.Time
+	n.Data, n.Stack, n.Tags = r.Data, r.Stack, r.Tags
+	return true
+}
+
+// onLog runs the log hooks and reports whether to write the trace.
+func (tc *TraceContext) onLog() bool {
+	for _, h := range tc.opts.logHooks {
+		if !h.OnLog(tc) {
+			return false
+		}
+	}
+	return true
+}
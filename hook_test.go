@@ -0,0 +1,43 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enrich := RecordHookFunc(func(span *TraceContext, r *NodeData) bool {
+		if len(r.Data) > 0 && r.Data[0] == "noise" {
+			return false
+		}
+		r.Data = append(r.Data, "pod=web-1")
+		return true
+	})
+	var logged int
+	tc := NewTraceContext(context.Background(), buf, WithRecordHook(enrich),
+		WithLogHook(LogHookFunc(func(tc *TraceContext) bool {
+			logged++
+			tc.SetTag("host", "h1")
+			return logged == 1
+		})))
+	tc.Info("noise")
+	tc.Info("kept")
+	if err := tc.Error("failed"); err == nil || err.Error() != "failed" {
+		t.Fatalf("hooks must not change the returned error: %v", err)
+	}
+	tc.Log()
+
+	out := buf.String()
+	if strings.Contains(out, "noise") || !strings.Contains(out, `["kept","pod=web-1"]`) ||
+		!strings.Contains(out, `["failed","pod=web-1"]`) || !strings.Contains(out, "host=h1") {
+		t.Fatalf("hooks not applied: %s", out)
+	}
+	buf.Reset()
+	tc.Log()
+	if buf.Len() != 0 || logged != 2 {
+		t.Fatalf("log hook returning false should skip the trace: %s", buf.String())
+	}
+}
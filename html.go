@@ -0,0 +1,192 @@
+package trace
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExportHTML writes the trace as a standalone HTML page, to be shared in an
+// incident review: spans are collapsible, those with an error expanded and
+// in red, every span has a bar showing when it ran within the trace, and a
+// search box filters spans by function, label, tag or record. The page
+// loads nothing from elsewhere.
+func (tc *TraceContext) ExportHTML(w io.Writer) error {
+	data := tc.Snapshot()
+	tree, err := data.HTMLTree("")
+	if err != nil {
+		return err
+	}
+	return htmlTemplate.Execute(w, htmlPage{TraceData: &data, Tree: tree})
+}
+
+// HTMLTree renders t and its subtree as the collapsible spans of
+// ExportHTML, for pages embedding a trace such as those of traceui. The
+// records of a span are listed in the order they were made. Links to other
+// traces point to linkURL followed by their trace id, or are plain text if
+// linkURL is empty. The styles of the tree come with it.
+func (t *TraceData) HTMLTree(linkURL string) (template.HTML, error) {
+	buf := &strings.Builder{}
+	root := newHTMLSpan(t, t.Start, t.Duration(), linkURL, true)
+	if err := htmlTemplate.ExecuteTemplate(buf, "tree", root); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// Record is an info, error or event of a span, see TraceData.Timeline.
+type Record struct {
+	Kind string // "info", "error" or "event"
+	NodeData
+}
+
+// Timeline returns the infos, errors and events of the span in the order
+// they were recorded.
+func (t *TraceData) Timeline() []Record {
+	res := make([]Record, 0, len(t.Infos)+len(t.Errors)+len(t.Events))
+	for _, group := range []struct {
+		kind  string
+		nodes []NodeData
+	}{{"info", t.Infos}, {"error", t.Errors}, {"event", t.Events}} {
+		for _, v := range group.nodes {
+			res = append(res, Record{group.kind, v})
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Seq != 0 && b.Seq != 0 {
+			return a.Seq < b.Seq
+		}
+		return a.Time.Before(b.Time)
+	})
+	return res
+}
+
+// htmlPage is the data of the page ExportHTML writes.
+type htmlPage struct {
+	*TraceData
+	Tree template.HTML
+}
+
+// htmlSpan is a span with its records in timeline order, where its bar
+// starts and how wide it is, in percent of the trace.
+type htmlSpan struct {
+	*TraceData
+	Open    bool
+	Offset  float64
+	Width   float64
+	LinkURL string
+	Records []Record
+	Spans   []htmlSpan
+}
+
+func newHTMLSpan(t *TraceData, start time.Time, total time.Duration, linkURL string, root bool) htmlSpan {
+	s := htmlSpan{TraceData: t, Open: root || t.HasError(), Width: 100, LinkURL: linkURL, Records: t.Timeline()}
+	if total > 0 {
+		s.Offset = percent(t.Start.Sub(start), total)
+		s.Width = percent(t.Duration(), total)
+		if s.Offset+s.Width > 100 {
+			s.Width = 100 - s.Offset
+		}
+	}
+	for i := range t.Children {
+		s.Spans = append(s.Spans, newHTMLSpan(&t.Children[i], start, total, linkURL, false))
+	}
+	return s
+}
+
+// percent returns d in percent of total, between 0 and 100.
+func percent(d, total time.Duration) float64 {
+	p := 100 * float64(d) / float64(total)
+	if p < 0 {
+		return 0
+	}
+	if p > 100 {
+		return 100
+	}
+	return p
+}
+
+var htmlTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"duration": func(t *TraceData) time.Duration {
+		return t.Duration().Round(time.Microsecond)
+	},
+	"json": func(v interface{}) string {
+		// escaped by the template
+		buf := &strings.Builder{}
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(v)
+		return strings.TrimSuffix(buf.String(), "\n")
+	},
+	"clock": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05.000")
+	},
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>trace {{.TraceId}}</title>
+<style>
+body{font-family:monospace;margin:1em}
+.hidden{display:none}.match>summary{background:#ff8}
+</style></head><body>
+<p>trace {{.TraceId}} started {{clock .Start}}, took {{duration .TraceData}}{{with .Resource}} · {{.}}{{end}}</p>
+<p><input id="search" placeholder="search" size="40"> <span id="matches"></span></p>
+{{.Tree}}
+<script>
+(function() {
+	var spans = document.querySelectorAll("details");
+	var input = document.getElementById("search");
+	input.addEventListener("input", function() {
+		var q = input.value.toLowerCase(), n = 0;
+		spans.forEach(function(d) {
+			d.classList.remove("match");
+			d.classList.toggle("hidden", q !== "");
+		});
+		if (q === "") {
+			document.getElementById("matches").textContent = "";
+			return;
+		}
+		spans.forEach(function(d) {
+			var own = d.querySelector(":scope>summary").textContent + " " + d.querySelector(":scope>ul").textContent;
+			if (own.toLowerCase().indexOf(q) < 0) {
+				return;
+			}
+			n++;
+			d.classList.add("match");
+			for (var p = d; p && p.tagName === "DETAILS"; p = p.parentElement) {
+				p.classList.remove("hidden");
+				p.open = true;
+			}
+			d.querySelectorAll("details").forEach(function(c) {
+				c.classList.remove("hidden");
+			});
+		});
+		document.getElementById("matches").textContent = n + " matching spans";
+	});
+})();
+</script>
+</body></html>
+{{define "tree"}}<style>
+.error>summary,li.error{color:#c00}.warn{color:#b80}.panic{color:#a0a}.event{color:#06c}
+details{margin-left:1.5em}summary{cursor:pointer;position:relative}ul{margin:0;list-style:none;padding-left:1.5em}
+.bar{display:inline-block;position:relative;width:20em;height:.8em;background:#eee;margin-right:1em;vertical-align:middle}
+.bar span{position:absolute;top:0;bottom:0;min-width:1px;background:#6a6}.error>summary .bar span{background:#c00}
+</style>
+{{template "span" .}}{{end}}
+{{define "span"}}<details{{if .Open}} open{{end}}{{if .HasError}} class="error"{{end}}>
+<summary><span class="bar" title="{{duration .TraceData}}"><span style="left:{{printf "%.2f" .Offset}}%;width:{{printf "%.2f" .Width}}%"></span></span>{{.Func}}{{if .Args}}({{range $i, $a := .Args}}{{if $i}}, {{end}}{{$a.Name}}={{$a.Value}}{{end}}){{end}}{{if .Label}} @{{.Label}}{{end}}{{if .Kind}} [{{.Kind}}]{{end}}{{if .Peer}} → {{.Peer}}{{end}}{{if .Dependency}} ⇒ {{.Dependency}}{{end}}{{range $k, $v := .Tags}} {{$k}}={{$v}}{{end}} <small>{{duration .TraceData}}{{if .Running}} (running){{end}}{{if .Cause}} (cancelled: {{.Cause}}){{end}}</small></summary>
+<ul>
+{{range .Records}}{{if eq .Kind "event"}}<li class="event">* {{clock .Time}} {{.Name}} {{json .Attrs}}</li>
+{{else if eq .Kind "error"}}<li class="{{.Level}}">{{.Level}} {{if .Code}}({{.Code}} {{.Category}}) {{end}}{{.Func}}:{{.Line}} {{json .Data}}{{range $k, $v := .Tags}} {{$k}}={{$v}}{{end}}{{if .Suppressed}} ({{.Suppressed}} more suppressed){{end}}{{range .Stack}}<br>&nbsp;&nbsp;at {{.}}{{end}}</li>
+{{else}}<li class="{{.Level}}">&gt; {{.Func}}:{{.Line}} {{json .Data}}{{if .Suppressed}} ({{.Suppressed}} more suppressed){{end}}</li>
+{{end}}{{end}}
+{{range .Links}}<li class="event">~ link {{if $.LinkURL}}<a href="{{$.LinkURL}}{{.TraceId}}">{{.TraceId}}</a>{{else}}{{.TraceId}}{{end}} {{json .Attrs}}</li>{{end}}
+{{range .Attachments}}<li class="event"># attachment {{.Name}} {{.ContentType}} {{.Size}} bytes</li>{{end}}
+{{range .Violations}}<li class="warn">! schema: {{.}}</li>{{end}}
+{{if .Suppressed}}<li>… {{.Suppressed}} more children suppressed</li>{{end}}
+</ul>
+{{range .Spans}}{{template "span" .}}{{end}}
+</details>{{end}}
+`))
@@ -0,0 +1,74 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTraceContext_ExportHTML(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	tc := NewTraceContext(context.Background(), nil, WithClock(clock))
+	ok := tc.Trace(WithSpanName("fetch"))
+	ok.Info("<script>alert(1)</script>")
+	clock.now = clock.now.Add(25 * time.Millisecond)
+	ok.End()
+	failed := tc.Trace(WithSpanName("store"))
+	_ = failed.Error(errors.New("disk full"))
+	clock.now = clock.now.Add(75 * time.Millisecond)
+	failed.End()
+	tc.End()
+
+	buf := &strings.Builder{}
+	if err := tc.ExportHTML(buf); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<details open class="error">`,
+		"fetch",
+		"disk full",
+		`left:0.00%;width:25.00%`,
+		`left:25.00%;width:75.00%`,
+		`id="search"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("page lacks %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<script>alert") {
+		t.Fatalf("records must be escaped:\n%s", page)
+	}
+	if strings.Count(page, "<details open") != 2 {
+		t.Fatalf("only the root and failed spans start expanded:\n%s", page)
+	}
+}
+
+func TestTraceData_HTMLTree(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	tc.Info("first")
+	_ = tc.Error("second")
+	tc.Event("third")
+	tc.Info("fourth")
+	tc.Link("0000000000000001")
+	data := tc.Snapshot()
+
+	tree, err := data.HTMLTree("trace?id=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := -1
+	for _, want := range []string{"first", "second", "third", "fourth"} {
+		i := strings.Index(string(tree), want)
+		if i <= last {
+			t.Fatalf("records out of order, %q too early:\n%s", want, tree)
+		}
+		last = i
+	}
+	if !strings.Contains(string(tree), `<a href="trace?id=0000000000000001">`) {
+		t.Fatalf("links should point to linkURL:\n%s", tree)
+	}
+}
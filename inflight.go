@@ -0,0 +1,58 @@
+package trace
+
+import (
+	"sort"
+	"sync"
+)
+
+// inFlight holds the roots created with WithInFlight that were not logged
+// yet.
+var inFlight = struct {
+	mux   sync.Mutex
+	roots map[*TraceContext]struct{}
+}{roots: map[*TraceContext]struct{}{}}
+
+func (tc *TraceContext) registerInFlight() {
+	inFlight.mux.Lock()
+	inFlight.roots[tc] = struct{}{}
+	inFlight.mux.Unlock()
+}
+
+func (tc *TraceContext) unregisterInFlight() {
+	inFlight.mux.Lock()
+	delete(inFlight.roots, tc)
+	inFlight.mux.Unlock()
+}
+
+// InFlight returns snapshots of the traces created with WithInFlight that
+// are still in progress, oldest first.
+func InFlight() []TraceData {
+	inFlight.mux.Lock()
+	// snapshot under the lock, Release waits for it before reusing a span
+	res := make([]TraceData, 0, len(inFlight.roots))
+	for tc := range inFlight.roots {
+		res = append(res, tc.Snapshot())
+	}
+	inFlight.mux.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Start.Before(res[j].Start)
+	})
+	return res
+}
+
+// InFlightTrace returns a snapshot of the trace in progress with the id, and
+// false if there is none.
+func InFlightTrace(traceID string) (TraceData, bool) {
+	id, err := ParseTraceID(traceID)
+	if err != nil {
+		return TraceData{}, false
+	}
+	inFlight.mux.Lock()
+	defer inFlight.mux.Unlock()
+	for tc := range inFlight.roots {
+		if tc.traceId == id {
+			return tc.Snapshot(), true
+		}
+	}
+	return TraceData{}, false
+}
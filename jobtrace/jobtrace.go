@@ -0,0 +1,63 @@
+// Package jobtrace traces the executions of cron and background jobs: every
+// run gets its own trace, logged once the job returned.
+//
+//	c.AddFunc("@hourly", func() {
+//		_ = jobtrace.Run("cleanup", os.Stderr, cleanup)
+//	})
+package jobtrace
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/mucolud/trace"
+)
+
+// Tags set on the root span of a job run.
+const (
+	NameTag    = "job.name"
+	OutcomeTag = "job.outcome"
+)
+
+// PanicError is returned by Run when the job panicked.
+type PanicError struct {
+	Job   string
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("jobtrace: job %s panicked: %v", e.Job, e.Value)
+}
+
+// Run is RunContext with a background context.
+func Run(name string, logger io.Writer, fn func(tc *trace.TraceContext) error, opts ...trace.Option) error {
+	return RunContext(context.Background(), name, logger, fn, opts...)
+}
+
+// RunContext runs fn in a new trace whose root span is named name and
+// logged to logger once fn returned. The error fn returns is recorded on the
+// root and returned; a panic in fn is recovered, recorded and returned as a
+// *PanicError. The root is tagged with the name and the outcome of the run,
+// "ok", "error" or "panic".
+func RunContext(ctx context.Context, name string, logger io.Writer, fn func(tc *trace.TraceContext) error, opts ...trace.Option) (err error) {
+	tc := trace.NewTraceContext(ctx, logger, opts...)
+	trace.WithSpanName(name)(tc)
+	tc.SetTag(NameTag, name)
+	defer func() {
+		if r := recover(); r != nil {
+			tc.RecordPanic(r)
+			tc.SetTag(OutcomeTag, "panic")
+			err = &PanicError{Job: name, Value: r}
+		}
+		tc.End()
+		tc.Log()
+	}()
+	if err = fn(tc); err != nil {
+		_ = tc.Error(err)
+		tc.SetTag(OutcomeTag, "error")
+	} else {
+		tc.SetTag(OutcomeTag, "ok")
+	}
+	return err
+}
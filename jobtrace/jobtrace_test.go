@@ -0,0 +1,54 @@
+package jobtrace
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mucolud/trace"
+)
+
+func TestRun(t *testing.T) {
+	buf := &bytes.Buffer{}
+	failure := errors.New("disk full")
+	err := Run("cleanup", buf, func(tc *trace.TraceContext) error {
+		tc.Info("removed", 3)
+		return failure
+	})
+	if err != failure {
+		t.Fatalf("Run should return the error of the job, got %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "cleanup job.name=cleanup") || !strings.Contains(out, "job.outcome=error") || !strings.Contains(out, `["disk full"]`) {
+		t.Fatalf("unexpected trace: %s", out)
+	}
+
+	buf.Reset()
+	if err := Run("cleanup", buf, func(tc *trace.TraceContext) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "job.outcome=ok") {
+		t.Fatalf("unexpected trace: %s", buf.String())
+	}
+}
+
+func TestRun_panic(t *testing.T) {
+	var data trace.TraceData
+	sink := trace.SinkLogger(trace.SinkFunc(func(d trace.TraceData) error {
+		data = d
+		return nil
+	}))
+	err := Run("report", sink, func(tc *trace.TraceContext) error {
+		var m map[string]int
+		m["x"] = 1
+		return nil
+	})
+	var perr *PanicError
+	if !errors.As(err, &perr) || perr.Job != "report" {
+		t.Fatalf("a panic should be returned as a PanicError, got %v", err)
+	}
+	if data.Func != "report" || data.Tags[OutcomeTag] != "panic" || len(data.Errors) != 1 || data.Errors[0].Level != trace.LevelPanic {
+		t.Fatalf("panic not recorded: %+v", data)
+	}
+}
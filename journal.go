@@ -0,0 +1,163 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Journal record kinds.
+const (
+	JournalSpan  byte = 1 // a span was started
+	JournalError byte = 2 // an error was recorded on a span
+	JournalDone  byte = 3 // the trace was logged, it is no longer in flight
+)
+
+// JournalRecord is one entry read back from a journal file.
+type JournalRecord struct {
+	Kind     byte
+	TraceId  int64
+	SpanId   int64 // of the span the record is about
+	ParentId int64 // span id of its parent, 0 for a root
+	Time     time.Time
+	Func     string
+	Data     string
+}
+
+// Journal is a write-ahead log of span starts and errors. Every record is
+// handed to the kernel with a single write as soon as it happens, so the
+// partial trace of in-flight requests can be rebuilt with ReadJournal even
+// after the process was killed: records carry the id of their span and of
+// its parent, which link the spans of a trace into a tree.
+type Journal struct {
+	mux sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+// OpenJournal opens (or creates) the journal file at path for appending.
+func OpenJournal(path string) (*Journal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewJournal(f), nil
+}
+
+// NewJournal returns a journal writing to w. w should be unbuffered.
+func NewJournal(w io.Writer) *Journal {
+	return &Journal{w: w, buf: make([]byte, 0, 256)}
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (j *Journal) Close() error {
+	if c, ok := j.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (j *Journal) span(now time.Time, tc *TraceContext) {
+	j.append(JournalSpan, now, tc, tc.funcName, "")
+}
+
+func (j *Journal) error(now time.Time, tc *TraceContext, funcName string, msg string) {
+	j.append(JournalError, now, tc, funcName, msg)
+}
+
+func (j *Journal) done(now time.Time, tc *TraceContext) {
+	j.append(JournalDone, now, tc, tc.funcName, "")
+}
+
+// append encodes a record about the span tc as
+// kind | varint traceId | varint spanId | varint parentId | varint unix nano |
+// uvarint len | func | uvarint len | data
+func (j *Journal) append(kind byte, now time.Time, tc *TraceContext, funcName, data string) {
+	if j == nil {
+		return
+	}
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	var tmp [binary.MaxVarintLen64]byte
+	b := append(j.buf[:0], kind)
+	b = append(b, tmp[:binary.PutVarint(tmp[:], tc.traceId)]...)
+	b = append(b, tmp[:binary.PutVarint(tmp[:], tc.spanId)]...)
+	b = append(b, tmp[:binary.PutVarint(tmp[:], tc.parentId)]...)
+	b = append(b, tmp[:binary.PutVarint(tmp[:], now.UnixNano())]...)
+	b = append(b, tmp[:binary.PutUvarint(tmp[:], uint64(len(funcName)))]...)
+	b = append(b, funcName...)
+	b = append(b, tmp[:binary.PutUvarint(tmp[:], uint64(len(data)))]...)
+	b = append(b, data...)
+	j.buf = b
+	_, _ = j.w.Write(b)
+}
+
+// ReadJournal decodes all records from r. A record torn by a crash at the
+// end of the file is ignored.
+func ReadJournal(r io.Reader) ([]JournalRecord, error) {
+	br := bufio.NewReader(r)
+	res := make([]JournalRecord, 0, 64)
+	for {
+		rec, err := readJournalRecord(br)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+		res = append(res, rec)
+	}
+}
+
+func readJournalRecord(r *bufio.Reader) (JournalRecord, error) {
+	var rec JournalRecord
+	kind, err := r.ReadByte()
+	if err != nil {
+		return rec, err
+	}
+	rec.Kind = kind
+	if rec.TraceId, err = binary.ReadVarint(r); err != nil {
+		return rec, unexpected(err)
+	}
+	if rec.SpanId, err = binary.ReadVarint(r); err != nil {
+		return rec, unexpected(err)
+	}
+	if rec.ParentId, err = binary.ReadVarint(r); err != nil {
+		return rec, unexpected(err)
+	}
+	nano, err := binary.ReadVarint(r)
+	if err != nil {
+		return rec, unexpected(err)
+	}
+	rec.Time = time.Unix(0, nano)
+	if rec.Func, err = readJournalString(r); err != nil {
+		return rec, err
+	}
+	if rec.Data, err = readJournalString(r); err != nil {
+		return rec, err
+	}
+	return rec, nil
+}
+
+func readJournalString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", unexpected(err)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", unexpected(err)
+	}
+	return string(b), nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), nil, WithJournal(NewJournal(buf)))
+	child := tc.Trace()
+	_ = child.Error("boom", 1)
+
+	recs, err := ReadJournal(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("want 3 records, got %d", len(recs))
+	}
+	if recs[0].Kind != JournalSpan || recs[1].Kind != JournalSpan || recs[2].Kind != JournalError {
+		t.Fatalf("unexpected kinds %v %v %v", recs[0].Kind, recs[1].Kind, recs[2].Kind)
+	}
+	if recs[2].TraceId != tc.traceId || recs[2].Data != "boom,1" {
+		t.Fatalf("unexpected error record %+v", recs[2])
+	}
+
+	// a record torn by a crash must not hide the ones before it
+	torn := buf.Bytes()[:buf.Len()-2]
+	recs, err = ReadJournal(bytes.NewReader(torn))
+	if err != nil || len(recs) != 2 {
+		t.Fatalf("want 2 records from torn journal, got %d (%v)", len(recs), err)
+	}
+}
+
+func TestJournal_rebuildTree(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), nil, WithJournal(NewJournal(buf)))
+	// the same function twice, only the second one fails
+	first, second := tc.Trace(), tc.Trace()
+	leaf := second.Trace()
+	_ = leaf.Error("boom")
+
+	recs, err := ReadJournal(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	children := map[int64][]int64{}
+	failed := map[int64]bool{}
+	for _, r := range recs {
+		switch r.Kind {
+		case JournalSpan:
+			children[r.ParentId] = append(children[r.ParentId], r.SpanId)
+		case JournalError:
+			failed[r.SpanId] = true
+		}
+	}
+	roots := children[0]
+	if len(roots) != 1 || roots[0] != tc.spanId {
+		t.Fatalf("want the root alone at the top, got %v", roots)
+	}
+	spans := children[tc.spanId]
+	if len(spans) != 2 || spans[0] != first.spanId || spans[1] != second.spanId {
+		t.Fatalf("unexpected children of the root %v", spans)
+	}
+	if leaves := children[second.spanId]; len(leaves) != 1 || leaves[0] != leaf.spanId || len(children[first.spanId]) != 0 {
+		t.Fatalf("the leaf should be under the second span only")
+	}
+	if !failed[leaf.spanId] || len(failed) != 1 {
+		t.Fatalf("the error should be on the leaf, got %v", failed)
+	}
+}
@@ -0,0 +1,63 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JournaldSocket is where systemd-journald takes entries in its native
+// protocol, one datagram each.
+const JournaldSocket = "/run/systemd/journal/socket"
+
+// JournaldSink writes every trace to w as one systemd-journald entry: the
+// tree without colors as MESSAGE, PRIORITY 3 if the trace has errors and 6
+// otherwise, the ids as TRACE_ID and SPAN_ID, the root function as
+// CODE_FUNC and the service of the Resource, if any, as SYSLOG_IDENTIFIER.
+// w must write a datagram per call, like a connection to JournaldSocket:
+//
+//	conn, err := net.Dial("unixgram", trace.JournaldSocket)
+//	tc := trace.NewTraceContext(ctx, trace.SinkLogger(trace.JournaldSink(conn)))
+//
+// An entry larger than the socket takes fails to write; bound traces with
+// WithMaxTraceBytes. opts configure the formatting, as for WriteTrace.
+func JournaldSink(w io.Writer, opts ...Option) Sink {
+	return SinkFunc(func(t TraceData) error {
+		_, err := w.Write(journalEntry(&t, opts))
+		return err
+	})
+}
+
+// journalEntry encodes t in the native protocol of journald.
+func journalEntry(t *TraceData, opts []Option) []byte {
+	priority := 6
+	if t.HasError() {
+		priority = 3
+	}
+	buf := &bytes.Buffer{}
+	journalField(buf, "MESSAGE", plainTree(t, opts))
+	journalField(buf, "PRIORITY", strconv.Itoa(priority))
+	journalField(buf, "TRACE_ID", t.TraceId)
+	journalField(buf, "SPAN_ID", t.SpanId)
+	journalField(buf, "CODE_FUNC", t.Func)
+	if t.Resource != nil && t.Resource.Service != "" {
+		journalField(buf, "SYSLOG_IDENTIFIER", t.Resource.Service)
+	}
+	return buf.Bytes()
+}
+
+// journalField appends name=value, in the binary form with the length of
+// value if it spans several lines.
+func journalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name + "=" + value + "\n")
+		return
+	}
+	buf.WriteString(name + "\n")
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(value)))
+	buf.Write(n[:])
+	buf.WriteString(value + "\n")
+}
@@ -0,0 +1,94 @@
+package trace
+
+import "fmt"
+
+// SpanKind is the role of a span in a request or message flow, mapped to
+// the OTel span kinds by the exporters.
+type SpanKind int
+
+const (
+	// SpanKindInternal is an operation within the process, the default.
+	SpanKindInternal SpanKind = iota
+	// SpanKindServer handles a request from a remote client.
+	SpanKindServer
+	// SpanKindClient is a request to a remote server.
+	SpanKindClient
+	// SpanKindProducer sends a message handled later, asynchronously.
+	SpanKindProducer
+	// SpanKindConsumer handles a message sent by a producer.
+	SpanKindConsumer
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindInternal:
+		return "internal"
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	case SpanKindProducer:
+		return "producer"
+	case SpanKindConsumer:
+		return "consumer"
+	}
+	return "unknown"
+}
+
+func (k SpanKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *SpanKind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "internal":
+		*k = SpanKindInternal
+	case "server":
+		*k = SpanKindServer
+	case "client":
+		*k = SpanKindClient
+	case "producer":
+		*k = SpanKindProducer
+	case "consumer":
+		*k = SpanKindConsumer
+	default:
+		return fmt.Errorf("trace: unknown span kind %q", text)
+	}
+	return nil
+}
+
+// WithSpanKind sets the kind of the span.
+func WithSpanKind(k SpanKind) SpanOption {
+	return func(tc *TraceContext) {
+		tc.kind = k
+	}
+}
+
+// SetKind sets the kind of the span, e.g. SpanKindServer on the root of a
+// request.
+func (tc *TraceContext) SetKind(k SpanKind) {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.kind = k
+}
+
+// formatKind renders the kind next to the span name, unless internal.
+func (s *spanSnapshot) formatKind() string {
+	if s.kind == SpanKindInternal {
+		return ""
+	}
+	return " [" + s.kind.String() + "]"
+}
+
+// otlpKind returns the OTLP span kind of the span: its own kind if set, or
+// else the one of its dependency.
+func (s *spanSnapshot) otlpKind() int {
+	switch {
+	case s.kind != SpanKindInternal:
+		// the OTLP kinds are ours shifted by one, 0 being unspecified
+		return int(s.kind) + 1
+	case s.dependency != nil:
+		return s.dependency.otlpKind()
+	}
+	return otlpKindInternal
+}
@@ -0,0 +1,42 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func publish(tc *TraceContext) {
+	tc.Trace(WithSpanKind(SpanKindProducer)).Info("order.created")
+}
+
+func TestWithSpanKind(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	tc.SetKind(SpanKindConsumer)
+	publish(tc)
+	tc.Dependency("redis", DependencyCache, "").End()
+	tc.Log()
+
+	out := buf.String()
+	if !strings.Contains(out, "TestWithSpanKind [consumer]\n") || !strings.Contains(out, "trace.publish [producer]\n") {
+		t.Fatalf("kinds should be rendered: %s", out)
+	}
+	spans := tc.snapshot().otlpSpans(nil)
+	if spans[0].Kind != 5 || spans[1].Kind != 4 || spans[2].Kind != otlpKindClient {
+		t.Fatalf("unexpected OTLP kinds %d %d %d", spans[0].Kind, spans[1].Kind, spans[2].Kind)
+	}
+
+	data := tc.Snapshot()
+	b, _ := json.Marshal(data)
+	var back TraceData
+	if err := json.Unmarshal(b, &back); err != nil || back.Kind != SpanKindConsumer || back.Children[0].Kind != SpanKindProducer {
+		t.Fatalf("kind should round trip through JSON: %v %s", err, b)
+	}
+	wire, _ := data.Marshal()
+	if err := back.Unmarshal(wire); err != nil || back.Children[0].Kind != SpanKindProducer {
+		t.Fatalf("kind should round trip through the wire format: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package trace
+
+import "strconv"
+
+// Label names the worker the span runs on, such as "worker-3", so that the
+// spans of parallel work interleaved in the tree can be attributed. Spans
+// started with Go are labelled with the id of their goroutine unless fn
+// labels them. It is rendered after the span name as "@worker-3".
+func (tc *TraceContext) Label(label string) *TraceContext {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.label = label
+	return tc
+}
+
+// labelGoroutine labels tc with the id of the calling goroutine.
+func (tc *TraceContext) labelGoroutine() {
+	label := "goroutine " + strconv.FormatInt(goid(), 10)
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	if tc.label == "" {
+		tc.label = label
+	}
+}
+
+// formatLabel renders the label after the span name.
+func (s *spanSnapshot) formatLabel() string {
+	if s.label == "" {
+		return ""
+	}
+	return " @" + s.label
+}
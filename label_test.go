@@ -0,0 +1,41 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_Label(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithWaitGoroutines(0))
+	tc.Trace().Label("worker-3").Info("job")
+	tc.Go(func(child *TraceContext) {})
+	tc.Go(func(child *TraceContext) {
+		child.Label("fetcher")
+	})
+	tc.Log()
+
+	out := buf.String()
+	if !strings.Contains(out, "TestTraceContext_Label @worker-3\n") {
+		t.Fatalf("the label should follow the span name: %s", out)
+	}
+	if !regexp.MustCompile(`Label @goroutine \d+\n`).MatchString(out) {
+		t.Fatalf("spans started with Go should be labelled with their goroutine: %s", out)
+	}
+	if !strings.Contains(out, "@fetcher\n") {
+		t.Fatalf("fn should be able to label its span: %s", out)
+	}
+
+	data := tc.Snapshot()
+	b, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back TraceData
+	if err := back.Unmarshal(b); err != nil || back.Children[0].Label != "worker-3" {
+		t.Fatalf("the label should round trip: %v %q", err, back.Children[0].Label)
+	}
+}
@@ -0,0 +1,59 @@
+package trace
+
+import "fmt"
+
+// LazyFunc is a parameter that is evaluated only when the trace is
+// formatted, so expensive values cost nothing when the record is dropped.
+type LazyFunc func() interface{}
+
+// Lazy wraps fn so it is called only when the trace is written out.
+// A plain func() interface{} parameter is treated the same way.
+func Lazy(fn func() interface{}) LazyFunc {
+	return fn
+}
+
+// resolveLazy evaluates v if it is a lazy param.
+func resolveLazy(v interface{}) interface{} {
+	switch fn := v.(type) {
+	case LazyFunc:
+		return fn()
+	case func() interface{}:
+		return fn()
+	}
+	return v
+}
+
+// resolveParam returns the value rendered for the param v: lazy params are
+// evaluated, errors replaced by their text and []byte by the text it holds.
+// Nil errors render as nil, like in error messages, see appendValue.
+func resolveParam(v interface{}) interface{} {
+	v = resolveLazy(v)
+	switch val := v.(type) {
+	case error:
+		if isNilParam(val) {
+			return nil
+		}
+		return errorText(val)
+	case []byte:
+		return string(val)
+	}
+	return v
+}
+
+// errorText returns the text of err, or the panic of its Error method.
+func errorText(err error) (res string) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = fmt.Sprintf("(PANIC=%v)", r)
+		}
+	}()
+	return err.Error()
+}
+
+func resolveParams(params []interface{}) []interface{} {
+	res := make([]interface{}, len(params))
+	for i, v := range params {
+		res[i] = resolveParam(v)
+	}
+	return res
+}
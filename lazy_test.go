@@ -0,0 +1,33 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	calls := 0
+	expensive := func() interface{} {
+		calls++
+		return map[string]int{"rows": 42}
+	}
+
+	tc := NewTraceContext(context.Background(), nil, WithEscalation())
+	tc.Info(Lazy(expensive))
+	if calls != 0 {
+		t.Fatalf("dropped record must not evaluate lazy params")
+	}
+
+	buf := &bytes.Buffer{}
+	tc = NewTraceContext(context.Background(), buf)
+	tc.Info("load", Lazy(expensive), expensive)
+	if calls != 0 {
+		t.Fatalf("lazy params must not be evaluated before Log")
+	}
+	tc.Log()
+	if calls != 2 || !strings.Contains(buf.String(), `"load",{"rows":42},{"rows":42}`) {
+		t.Fatalf("unexpected output (%d calls): %s", calls, buf.String())
+	}
+}
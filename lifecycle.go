@@ -0,0 +1,88 @@
+package trace
+
+import "sync/atomic"
+
+// SpanState is where a span is in its lifecycle.
+type SpanState int
+
+const (
+	// SpanOpen is a span that did not end yet.
+	SpanOpen SpanState = iota
+	// SpanEnded is a span ended with End, or whose trace was logged
+	// while it ran.
+	SpanEnded
+	// SpanLogged is a span of a trace already logged: what is recorded on
+	// it from now on is only written if Log is called again.
+	SpanLogged
+)
+
+func (s SpanState) String() string {
+	switch s {
+	case SpanOpen:
+		return "open"
+	case SpanEnded:
+		return "ended"
+	case SpanLogged:
+		return "logged"
+	}
+	return "unknown"
+}
+
+// LateSpans chooses what becomes of the spans created from a trace after it
+// was logged, with WithLateSpans. Either way they are counted as
+// Stats.LateSpans and trace_late_spans_total.
+type LateSpans int
+
+const (
+	// LateAttach adds them to the tree of the trace as usual, where only
+	// another Log writes them, the default.
+	LateAttach LateSpans = iota
+	// LateOrphan logs each of them as a trace of its own once it ends,
+	// tagged with LateTag and keeping the trace id and the parent it was
+	// created from, so it can be found next to the trace.
+	LateOrphan
+)
+
+// LateTag is set on the spans logged on their own by LateOrphan.
+const LateTag = "trace.late"
+
+// State returns where the span is in its lifecycle.
+func (tc *TraceContext) State() SpanState {
+	if tc.state.isLogged() && !tc.orphan {
+		return SpanLogged
+	}
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	if tc.end.IsZero() {
+		return SpanOpen
+	}
+	return SpanEnded
+}
+
+func (s *traceState) markLogged() {
+	atomic.StoreInt32(&s.logged, 1)
+}
+
+func (s *traceState) isLogged() bool {
+	return atomic.LoadInt32(&s.logged) == 1
+}
+
+// lateChild counts ntc, a child of tc created after its trace was logged,
+// and starts it as a trace of its own with LateOrphan. It reports whether
+// it did.
+func (tc *TraceContext) lateChild(ntc *TraceContext) bool {
+	atomic.AddUint64(&stats.LateSpans, 1)
+	if m := tc.opts.metrics; m != nil {
+		m.Count("trace_late_spans_total", 1)
+	}
+	if tc.opts.lateSpans != LateOrphan {
+		return false
+	}
+	ntc.state = &traceState{}
+	ntc.orphan = true
+	if ntc.tags == nil {
+		ntc.tags = make(map[string]interface{}, 1)
+	}
+	ntc.tags[LateTag] = true
+	return true
+}
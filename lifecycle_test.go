@@ -0,0 +1,65 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_State(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	child := tc.Trace()
+	if tc.State() != SpanOpen || child.State() != SpanOpen {
+		t.Fatalf("new spans should be open: %s %s", tc.State(), child.State())
+	}
+	child.End()
+	if child.State() != SpanEnded {
+		t.Fatalf("unexpected state %s", child.State())
+	}
+	tc.Log()
+	if tc.State() != SpanLogged || child.State() != SpanLogged {
+		t.Fatalf("spans of a logged trace should be logged: %s %s", tc.State(), child.State())
+	}
+}
+
+func TestWithLateSpans(t *testing.T) {
+	before := ReadStats().LateSpans
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	tc.Log()
+	attached := tc.Trace()
+	attached.Info("late")
+	if ReadStats().LateSpans != before+1 {
+		t.Fatalf("late span not counted")
+	}
+	buf.Reset()
+	tc.Log()
+	if !strings.Contains(buf.String(), `["late"]`) {
+		t.Fatalf("late span should be logged by the next Log by default: %s", buf.String())
+	}
+
+	buf.Reset()
+	tc = NewTraceContext(context.Background(), buf, WithLateSpans(LateOrphan))
+	tc.Info("on time")
+	tc.Log()
+	buf.Reset()
+	orphan := tc.Trace()
+	orphan.Info("late")
+	orphan.Trace().Info("nested")
+	if orphan.State() != SpanOpen || buf.Len() != 0 {
+		t.Fatalf("an orphan should be logged once it ends: %s %s", orphan.State(), buf.String())
+	}
+	orphan.End()
+	out := buf.String()
+	if !strings.Contains(out, "traceId:"+tc.TraceID()) || !strings.Contains(out, LateTag+"=true") ||
+		!strings.Contains(out, `["late"]`) || !strings.Contains(out, `["nested"]`) || strings.Contains(out, "on time") {
+		t.Fatalf("unexpected orphan trace: %s", out)
+	}
+	if data := orphan.Snapshot(); data.TraceId != tc.TraceID() || data.ParentSpanId != tc.SpanID() {
+		t.Fatalf("orphan should reference its parent: %+v", data)
+	}
+	if n := len(tc.Snapshot().Children); n != 0 {
+		t.Fatalf("orphan should not be part of the tree, got %d children", n)
+	}
+}
@@ -0,0 +1,49 @@
+package trace
+
+import "time"
+
+// LinkData is a reference from a span to another trace.
+type LinkData struct {
+	TraceId string                 `json:"trace_id"`
+	Time    time.Time              `json:"time"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// Link records that the span relates to the trace traceID, such as a batch
+// job referencing the request that queued it. attrs are key/value pairs
+// describing the relation. An invalid trace id is ignored.
+func (tc *TraceContext) Link(traceID string, attrs ...interface{}) {
+	id, err := ParseTraceID(traceID)
+	if err != nil {
+		return
+	}
+	funcName, line := caller(1 + tc.opts.callerSkip)
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.links = append(tc.links, newNode(node{
+		Line: line,
+		Func: funcName,
+		Name: formatTraceID(id),
+		Time: tc.now(),
+		Data: attrs,
+	}))
+}
+
+func linkData(links []*node) []LinkData {
+	if len(links) == 0 {
+		return nil
+	}
+	res := make([]LinkData, 0, len(links))
+	for _, v := range links {
+		res = append(res, LinkData{TraceId: v.Name, Time: v.Time, Attrs: v.Data[0].(map[string]interface{})})
+	}
+	return res
+}
+
+func (t *TraceData) linkNodes() []*node {
+	res := make([]*node, 0, len(t.Links))
+	for _, v := range t.Links {
+		res = append(res, &node{Name: v.TraceId, Time: v.Time, Data: []interface{}{v.Attrs}})
+	}
+	return res
+}
@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_Link(t *testing.T) {
+	origin := NewTraceContext(context.Background(), nil)
+	buf, otlp := &bytes.Buffer{}, &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), MultiLogger(buf, Formatted(otlp, FormatOTLP)))
+	child := tc.Trace()
+	child.Link(origin.TraceID(), "relation", "queued by")
+	child.Link("not an id")
+	tc.Log()
+
+	if !strings.Contains(buf.String(), `├~ link `+origin.TraceID()+` {"relation":"queued by"}`) {
+		t.Fatalf("link not rendered: %s", buf.String())
+	}
+	data := tc.Snapshot()
+	links := data.Children[0].Links
+	if len(links) != 1 || links[0].TraceId != origin.TraceID() || links[0].Attrs["relation"] != "queued by" {
+		t.Fatalf("unexpected links %+v", links)
+	}
+	b, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded TraceData
+	if err := decoded.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Children[0].Links; len(got) != 1 || got[0].TraceId != links[0].TraceId ||
+		!got[0].Time.Equal(links[0].Time) || !reflect.DeepEqual(got[0].Attrs, links[0].Attrs) {
+		t.Fatalf("links should survive the wire format: %+v %v", decoded.Children[0].Links, err)
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal(otlp.Bytes(), &req); err != nil {
+		t.Fatal(err)
+	}
+	otlpLinks := req.ResourceSpans[0].ScopeSpans[0].Spans[1].Links
+	if len(otlpLinks) != 1 || otlpLinks[0].TraceId[16:] != origin.TraceID() {
+		t.Fatalf("link should be exported as a span link: %+v", otlpLinks)
+	}
+}
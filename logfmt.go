@@ -0,0 +1,120 @@
+package trace
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// writeLogfmt writes one logfmt line per node, in tree order:
+//
+//	ts=2024-05-01T12:00:00Z trace_id=… span_id=… span=pkg.Handle level=info func=pkg.load:42 msg="user 42"
+//
+// Events have their name as msg followed by their attributes.
+func writeLogfmt(w io.Writer, s *spanSnapshot) (int, error) {
+	buf := &bytes.Buffer{}
+	s.appendLogfmt(buf, s.resource)
+	return w.Write(buf.Bytes())
+}
+
+func (s *spanSnapshot) appendLogfmt(buf *bytes.Buffer, resource *Resource) {
+	line := func(v *node, level string) {
+		logfmtPair(buf, "ts", v.Time.UTC().Format(time.RFC3339Nano))
+		if resource != nil && resource.Service != "" {
+			logfmtPair(buf, "service", resource.Service)
+		}
+		logfmtPair(buf, "trace_id", formatTraceID(s.traceId))
+		logfmtPair(buf, "span_id", formatSpanID(s.spanId))
+		if s.parentId != 0 {
+			logfmtPair(buf, "parent_span_id", formatSpanID(s.parentId))
+		}
+		logfmtPair(buf, "span", s.funcName)
+		logfmtPair(buf, "level", level)
+		logfmtPair(buf, "func", v.Func+":"+strconv.Itoa(v.Line))
+	}
+	for _, v := range s.timeline() {
+		switch v.kind {
+		case infoRecord:
+			line(v.node, v.Level.String())
+			logfmtPair(buf, "msg", logfmtParams(v.Data))
+		case errorRecord:
+			line(v.node, v.Level.String())
+			logfmtPair(buf, "msg", logfmtParams(v.Data))
+			if v.Code != 0 {
+				logfmtPair(buf, "code", strconv.Itoa(v.Code))
+			}
+			if v.Category != "" {
+				logfmtPair(buf, "category", string(v.Category))
+			}
+			for _, k := range sortedTags(v.Tags) {
+				logfmtPair(buf, logfmtKey("tag."+k), v.Tags[k])
+			}
+			if len(v.Stack) > 0 {
+				logfmtPair(buf, "stack", strings.Join(v.Stack, "\n"))
+			}
+		case eventRecord:
+			line(v.node, "event")
+			logfmtPair(buf, "msg", v.Name)
+			attrs := v.Data[0].(map[string]interface{})
+			keys := make([]string, 0, len(attrs))
+			for k := range attrs {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				logfmtPair(buf, logfmtKey(k), toString(attrs[k]))
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	for _, v := range s.children {
+		v.appendLogfmt(buf, resource)
+	}
+}
+
+func logfmtParams(params []interface{}) string {
+	parts := make([]string, 0, len(params))
+	for _, v := range params {
+		parts = append(parts, toString(v))
+	}
+	return strings.Join(parts, " ")
+}
+
+func logfmtPair(buf *bytes.Buffer, key, value string) {
+	if buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	if logfmtNeedsQuote(value) {
+		buf.WriteString(strconv.Quote(value))
+	} else {
+		buf.WriteString(value)
+	}
+}
+
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
+
+// logfmtKey makes an attribute key usable as a logfmt key.
+func logfmtKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+		return r
+	}, k)
+}
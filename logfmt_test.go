@@ -0,0 +1,38 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatLogfmt(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), Formatted(buf, FormatLogfmt), WithClock(clock))
+	tc.Info("user", 42)
+	child := tc.Trace()
+	child.Event("cache miss", "key", "user:42", "bad key", "x=y")
+	_ = child.ErrorCode(503, errors.New("timeout"))
+	tc.Log()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected one line per node: %q", buf.String())
+	}
+	want := "ts=2024-05-01T12:00:00Z trace_id=" + tc.TraceID() + " span_id=" + tc.SpanID() +
+		" span=github.com/mucolud/trace.TestFormatLogfmt level=info func=github.com/mucolud/trace.TestFormatLogfmt:16 msg=\"user 42\""
+	if lines[0] != want {
+		t.Fatalf("unexpected info line\n%s\nwant\n%s", lines[0], want)
+	}
+	if !strings.HasSuffix(lines[1], `level=event func=github.com/mucolud/trace.TestFormatLogfmt:18 msg="cache miss" bad_key="x=y" key=user:42`) {
+		t.Fatalf("unexpected event line %s", lines[1])
+	}
+	if !strings.Contains(lines[2], " parent_span_id="+tc.SpanID()+" ") ||
+		!strings.HasSuffix(lines[2], "level=error func=github.com/mucolud/trace.TestFormatLogfmt:19 msg=timeout code=503 category=dependency") {
+		t.Fatalf("unexpected error line %s", lines[2])
+	}
+}
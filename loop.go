@@ -0,0 +1,142 @@
+package trace
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Tags set by Loop.
+const (
+	LoopTotalTag     = "loop.total"
+	LoopIterationTag = "loop.iteration"
+	LoopFailedTag    = "loop.failed"
+)
+
+// defaultLoopSample is the number of first and last iterations a Loop
+// keeps unless set with WithLoopSample.
+const defaultLoopSample = 3
+
+// Loop records the iterations of a loop as spans, keeping only a sample of
+// them so that a batch of a million items does not make a million spans:
+// the first and last few, see WithLoopSample, and every iteration that
+// failed. The others are counted as suppressed children of the loop span.
+type Loop struct {
+	span   *TraceContext
+	sample int
+
+	mux     sync.Mutex
+	n       int // iterations started
+	failed  int
+	dropped int
+	kept    []loopIteration
+	last    []loopIteration // ring of the last iterations not kept yet
+	next    int
+}
+
+// loopIteration is the span of the i-th iteration of a Loop.
+type loopIteration struct {
+	i    int
+	span *TraceContext
+}
+
+// Loop starts a span named name for a loop of total iterations, 0 if
+// unknown. Start every iteration with Iteration, hand it back to Done when
+// it is over, and call End once the loop is:
+//
+//	loop := tc.Loop("import", len(rows))
+//	for i, row := range rows {
+//		it := loop.Iteration(i)
+//		_ = loop.Done(it, importRow(it, row))
+//	}
+//	loop.End()
+func (tc *TraceContext) Loop(name string, total int) *Loop {
+	span := tc.trace(2, []SpanOption{WithSpanName(name)})
+	if total > 0 {
+		span.SetTag(LoopTotalTag, total)
+	}
+	return &Loop{span: span, sample: tc.opts.loopSample}
+}
+
+// Iteration starts the span of the i-th iteration, named after the caller
+// and tagged with i. It is not part of the trace until Done decided to keep
+// it.
+func (l *Loop) Iteration(i int) *TraceContext {
+	parent := l.span
+	funcName, _ := caller(1 + parent.opts.callerSkip)
+	parent.mux.Lock()
+	logger := parent.logger
+	parent.mux.Unlock()
+	span := newTraceContext(parent, logger, parent.opts, parent.state)
+	span.traceId = parent.traceId
+	span.parentId = parent.spanId
+	span.budget = remaining(parent)
+	span.funcName = funcName
+	span.tags = map[string]interface{}{LoopIterationTag: i}
+	return span
+}
+
+// Done ends the iteration it, recording err on it if not nil, and returns
+// err. The iteration is kept if it is among the first ones or failed, here
+// or by recording an error itself; among the others, only the last ones
+// are kept by End.
+func (l *Loop) Done(it *TraceContext, err error) error {
+	if err != nil {
+		_ = it.error(2, 0, []interface{}{err})
+	}
+	it.End()
+	it.mux.Lock()
+	failed, i := len(it.errors) > 0, it.tags[LoopIterationTag].(int)
+	it.mux.Unlock()
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.n++
+	if failed {
+		l.failed++
+	}
+	if failed || l.n <= l.sample {
+		l.kept = append(l.kept, loopIteration{i, it})
+		return err
+	}
+	if len(l.last) < l.sample {
+		l.last = append(l.last, loopIteration{i, it})
+		return err
+	}
+	if l.sample == 0 {
+		l.dropped++
+		return err
+	}
+	l.last[l.next] = loopIteration{i, it}
+	l.next = (l.next + 1) % l.sample
+	l.dropped++
+	return err
+}
+
+// End adds the kept iterations to the loop span in the order of their
+// index, counts the others as suppressed, tags the span with the number of
+// failed iterations and ends it.
+func (l *Loop) End() {
+	l.mux.Lock()
+	kept := append(l.kept, l.last...)
+	failed, dropped := l.failed, l.dropped
+	l.kept, l.last, l.next, l.dropped = nil, nil, 0, 0
+	l.mux.Unlock()
+	sort.SliceStable(kept, func(a, b int) bool {
+		return kept[a].i < kept[b].i
+	})
+
+	span := l.span
+	span.mux.Lock()
+	for _, v := range kept {
+		v.span.parent = span
+		span.children = append(span.children, v.span)
+	}
+	span.suppressed += dropped
+	span.mux.Unlock()
+	atomic.AddUint64(&stats.Spans, uint64(len(kept)))
+	if failed > 0 {
+		span.SetTag(LoopFailedTag, failed)
+	}
+	span.End()
+}
@@ -0,0 +1,114 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_Loop(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithLoopSample(2))
+	loop := tc.Loop("import", 100)
+	for i := 0; i < 100; i++ {
+		it := loop.Iteration(i)
+		var err error
+		if i == 50 {
+			err = errors.New("bad row")
+		}
+		if got := loop.Done(it, err); got != err {
+			t.Fatalf("Done returned %v, want %v", got, err)
+		}
+	}
+	loop.End()
+	tc.Log()
+
+	data := tc.Snapshot()
+	if len(data.Children) != 1 {
+		t.Fatalf("want the loop span only: %+v", data.Children)
+	}
+	span := data.Children[0]
+	if span.Tags[LoopTotalTag] != 100 || span.Tags[LoopFailedTag] != 1 {
+		t.Fatalf("loop tags %v", span.Tags)
+	}
+	var kept []int
+	for _, child := range span.Children {
+		kept = append(kept, child.Tags[LoopIterationTag].(int))
+	}
+	if want := []int{0, 1, 50, 98, 99}; !equalInts(kept, want) {
+		t.Fatalf("kept iterations %v, want %v", kept, want)
+	}
+	if len(span.Children[2].Errors) != 1 {
+		t.Fatalf("the failed iteration should keep its error: %+v", span.Children[2])
+	}
+	if span.Suppressed != 95 {
+		t.Fatalf("suppressed %d, want 95", span.Suppressed)
+	}
+	if !strings.Contains(buf.String(), "95 more children suppressed") {
+		t.Fatalf("suppressed iterations not rendered:\n%s", buf.String())
+	}
+}
+
+func TestTraceContext_LoopShort(t *testing.T) {
+	tc := NewTraceContext(context.Background(), &bytes.Buffer{})
+	loop := tc.Loop("batch", 0)
+	for i := 0; i < 5; i++ {
+		it := loop.Iteration(i)
+		it.Error(errors.New("recorded in the iteration"))
+		_ = loop.Done(it, nil)
+	}
+	loop.End()
+
+	span := tc.Snapshot().Children[0]
+	if len(span.Children) != 5 || span.Suppressed != 0 {
+		t.Fatalf("short loops keep every iteration: %d kept, %d suppressed", len(span.Children), span.Suppressed)
+	}
+	if _, ok := span.Tags[LoopTotalTag]; ok {
+		t.Fatalf("unknown total should not be tagged")
+	}
+	if span.Tags[LoopFailedTag] != 5 {
+		t.Fatalf("errors recorded by iterations count as failures: %v", span.Tags)
+	}
+}
+
+func TestTraceContext_LoopNoSample(t *testing.T) {
+	for _, k := range []int{0, -1} {
+		tc := NewTraceContext(context.Background(), &bytes.Buffer{}, WithLoopSample(k))
+		loop := tc.Loop("batch", 0)
+		for i := 0; i < 3; i++ {
+			it := loop.Iteration(i)
+			var err error
+			if i == 1 {
+				err = errors.New("bad row")
+			}
+			_ = loop.Done(it, err)
+		}
+		loop.End()
+
+		span := tc.Snapshot().Children[0]
+		var kept []int
+		for _, child := range span.Children {
+			kept = append(kept, child.Tags[LoopIterationTag].(int))
+		}
+		if !equalInts(kept, []int{1}) {
+			t.Fatalf("WithLoopSample(%d): kept iterations %v, want the failed one only", k, kept)
+		}
+		if span.Suppressed != 2 {
+			t.Fatalf("WithLoopSample(%d): suppressed %d, want 2", k, span.Suppressed)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package trace
+
+import "time"
+
+// Merge is the join point of a fan-out. Call it once the goroutines working
+// on children have returned: it ends the children, places them in tc in the
+// order given (adopting spans created elsewhere), and records a "join" event
+// with the number of spans, how many failed, the wall time from the first
+// start to the last end and the busy time summed over all children.
+//
+// A span created elsewhere must be the root of its trace: it is moved into
+// the trace of tc with its descendants, which take the trace id of tc, and
+// must not be logged or released on its own any more. Spans that are part
+// of another tree, and tc itself or any of its ancestors, are ignored.
+func (tc *TraceContext) Merge(children ...*TraceContext) {
+	children = tc.mergeable(children)
+	if len(children) == 0 {
+		return
+	}
+	var first, last time.Time
+	var busy time.Duration
+	failed := 0
+	for _, c := range children {
+		c.End()
+		c.mux.Lock()
+		start, end, errs := c.start, c.end, len(c.errors)
+		c.mux.Unlock()
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if end.After(last) {
+			last = end
+		}
+		busy += end.Sub(start)
+		if errs > 0 {
+			failed++
+		}
+	}
+	for _, c := range children {
+		if c.parent == nil {
+			tc.adopt(c)
+		}
+	}
+
+	tc.mux.Lock()
+	merged := make(map[*TraceContext]bool, len(children))
+	for _, c := range children {
+		merged[c] = true
+	}
+	at := -1
+	rest := make([]*TraceContext, 0, len(tc.children))
+	for _, c := range tc.children {
+		if merged[c] {
+			if at < 0 {
+				at = len(rest)
+			}
+			continue
+		}
+		rest = append(rest, c)
+	}
+	if at < 0 {
+		at = len(rest)
+	}
+	ordered := make([]*TraceContext, 0, len(rest)+len(children))
+	ordered = append(ordered, rest[:at]...)
+	for _, c := range children {
+		ordered = append(ordered, c)
+	}
+	tc.children = append(ordered, rest[at:]...)
+	tc.mux.Unlock()
+
+	tc.event(2, "join", []interface{}{
+		"spans", len(children),
+		"errors", failed,
+		"wall", last.Sub(first).String(),
+		"busy", busy.String(),
+	})
+}
+
+// mergeable returns the spans of children Merge may place in tc: its own
+// children and the roots of other traces, each once.
+func (tc *TraceContext) mergeable(children []*TraceContext) []*TraceContext {
+	res := make([]*TraceContext, 0, len(children))
+	seen := make(map[*TraceContext]bool, len(children))
+	for _, c := range children {
+		if c == nil || seen[c] {
+			continue
+		}
+		c.mux.Lock()
+		parent := c.parent
+		c.mux.Unlock()
+		if parent != nil && parent != tc || parent == nil && tc.descendsFrom(c) {
+			continue
+		}
+		seen[c] = true
+		res = append(res, c)
+	}
+	return res
+}
+
+// descendsFrom reports whether tc is span or one of its descendants.
+func (tc *TraceContext) descendsFrom(span *TraceContext) bool {
+	for p := tc; p != nil; p = p.parent {
+		if p == span {
+			return true
+		}
+	}
+	return false
+}
+
+// adopt makes root, the root of another trace, a child of tc: root and its
+// descendants move to the trace of tc.
+func (tc *TraceContext) adopt(root *TraceContext) {
+	if root.opts.inFlight {
+		root.unregisterInFlight()
+	}
+	if root.state.failed() {
+		tc.state.markError()
+	}
+	root.mux.Lock()
+	root.parent = tc
+	root.parentId = tc.spanId
+	root.mux.Unlock()
+	root.moveTo(tc.traceId, tc.state)
+}
+
+// moveTo sets the trace id and the trace state of tc and its descendants.
+func (tc *TraceContext) moveTo(traceId int64, state *traceState) {
+	tc.mux.Lock()
+	tc.traceId = traceId
+	tc.state = state
+	children := tc.children
+	tc.mux.Unlock()
+	for _, c := range children {
+		c.moveTo(traceId, state)
+	}
+}
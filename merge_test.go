@@ -0,0 +1,93 @@
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTraceContext_Merge(t *testing.T) {
+	clock := &stepClock{now: time.Unix(0, 0)}
+	tc := NewTraceContext(context.Background(), nil, WithClock(clock))
+	before := tc.Trace()
+	a, b := tc.Trace(), tc.Trace()
+	after := tc.Trace()
+	orphan := NewTraceContext(context.Background(), nil, WithClock(clock))
+
+	clock.now = clock.now.Add(10 * time.Millisecond)
+	b.End()
+	_ = a.Error("failed")
+	clock.now = clock.now.Add(20 * time.Millisecond)
+	tc.Merge(b, a, orphan)
+
+	want := []*TraceContext{before, b, a, orphan, after}
+	if len(tc.children) != len(want) {
+		t.Fatalf("want %d children, got %d", len(want), len(tc.children))
+	}
+	for i, c := range want {
+		if tc.children[i] != c {
+			t.Fatalf("child %d out of order", i)
+		}
+	}
+	join := tc.Entries()
+	e := join[0]
+	attrs := e.Data[0].(map[string]interface{})
+	if e.Name != "join" || attrs["spans"] != 3 || attrs["errors"] != 1 ||
+		attrs["wall"] != "30ms" || attrs["busy"] != "70ms" {
+		t.Fatalf("unexpected join event %+v", e)
+	}
+	if e.Func != "github.com/mucolud/trace.TestTraceContext_Merge" {
+		t.Fatalf("join should be attributed to the caller, got %s", e.Func)
+	}
+}
+
+func TestTraceContext_Merge_adoptsRoot(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	other := NewTraceContext(context.Background(), nil, WithInFlight())
+	child := other.Trace()
+	grandchild := child.Trace()
+	_ = grandchild.Error("failed")
+	tc.Merge(other)
+
+	for _, s := range []*TraceContext{other, child, grandchild} {
+		if s.traceId != tc.traceId || s.state != tc.state {
+			t.Fatalf("span %s left in its trace", s.funcName)
+		}
+	}
+	if other.parentId != tc.spanId || other.parent != tc {
+		t.Fatalf("adopted root not parented to tc")
+	}
+	if !tc.state.failed() {
+		t.Fatalf("the error of the adopted trace should fail tc")
+	}
+	for _, v := range InFlight() {
+		if v.TraceId == other.TraceID() && v.SpanId == other.SpanID() {
+			t.Fatalf("adopted root still in flight")
+		}
+	}
+	data := tc.Snapshot()
+	if len(data.Children) != 1 || data.Children[0].ParentSpanId != tc.SpanID() ||
+		data.Children[0].Children[0].TraceId != tc.TraceID() {
+		t.Fatalf("unexpected tree %+v", data)
+	}
+}
+
+func TestTraceContext_Merge_rejects(t *testing.T) {
+	root := NewTraceContext(context.Background(), nil)
+	tc := root.Trace()
+	other := NewTraceContext(context.Background(), nil)
+	foreign := other.Trace()
+
+	tc.Merge(tc, root, foreign)
+	if len(tc.children) != 0 || len(tc.Entries()) != 0 {
+		t.Fatalf("nothing should be merged, got %d children", len(tc.children))
+	}
+	if foreign.parent != other || foreign.traceId != other.traceId || len(other.children) != 1 {
+		t.Fatalf("a span of another tree must stay there")
+	}
+	// the trees are free of cycles and shared spans
+	root.Log()
+	other.Log()
+	root.Release()
+	other.Release()
+}
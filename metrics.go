@@ -0,0 +1,42 @@
+package trace
+
+import "strconv"
+
+// Metrics receives counters of what happens in traces, to forward them to
+// the metrics system of the application (Prometheus, expvar, statsd...).
+//
+// Counters:
+//
+//	trace_errors_total{category, code}  error nodes recorded, panics included,
+//	                                    with the tags of ErrorTagged allowed by
+//	                                    WithMetricLabels as labels
+//	trace_write_errors_total            traces a writer failed to take
+//	trace_dropped_total                 traces an AsyncWriter dropped
+//	trace_late_spans_total              spans created after their trace was
+//	                                    logged, see WithLateSpans
+type Metrics interface {
+	Count(name string, delta int64, labels ...string)
+}
+
+// MetricsFunc adapts a function to the Metrics interface.
+type MetricsFunc func(name string, delta int64, labels ...string)
+
+func (f MetricsFunc) Count(name string, delta int64, labels ...string) {
+	f(name, delta, labels...)
+}
+
+func (tc *TraceContext) countError(n *node) {
+	if m := tc.opts.metrics; m != nil {
+		category := n.Category
+		if category == "" {
+			category = CategoryUnknown
+		}
+		labels := []string{"category", string(category), "code", strconv.Itoa(n.Code)}
+		for _, k := range tc.opts.metricLabels {
+			if v, ok := n.Tags[k]; ok {
+				labels = append(labels, k, v)
+			}
+		}
+		m.Count("trace_errors_total", 1, labels...)
+	}
+}
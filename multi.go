@@ -0,0 +1,176 @@
+package trace
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Format is how a trace is serialized for a sink.
+type Format int
+
+const (
+	// FormatTree is the box drawing tree Log writes by default.
+	FormatTree Format = iota
+	// FormatJSON is one JSON line per node, as written by WithFlatLogger.
+	FormatJSON
+	// FormatOTLP is an OTLP/JSON export request holding every span.
+	FormatOTLP
+	// FormatTraceData is the whole trace as one JSON encoded TraceData.
+	FormatTraceData
+	// FormatProtobuf is the whole trace encoded by TraceData.Marshal.
+	FormatProtobuf
+	// FormatLogfmt is one logfmt line per node, for pipelines such as Loki.
+	FormatLogfmt
+	// FormatZipkin is a Zipkin v2 JSON array holding every span.
+	FormatZipkin
+)
+
+// formatWriter is a sink receiving traces in a format other than the tree.
+type formatWriter struct {
+	io.Writer
+	format Format
+}
+
+// Formatted makes Log write traces to w in format f instead of the tree.
+func Formatted(w io.Writer, f Format) io.Writer {
+	return &formatWriter{Writer: w, format: f}
+}
+
+// logger returns the logger of a new trace given logger, after WithOutput
+// and WithFormat.
+func (o *options) logger(logger io.Writer) io.Writer {
+	if o.output != nil {
+		logger = o.output
+	}
+	if o.format == FormatTree {
+		return logger
+	}
+	switch logger.(type) {
+	case nil, *multiLogger, *formatWriter, *sinkLogger, *compressWriter:
+		return logger
+	}
+	return Formatted(logger, o.format)
+}
+
+// multiLogger fans a trace out to several sinks.
+type multiLogger struct {
+	sinks []io.Writer
+}
+
+// MultiLogger returns a logger writing every trace to all of w, each in its
+// own format (see Formatted). A failing sink does not keep the trace from
+// the others.
+//
+//	tc := trace.NewTraceContext(ctx, trace.MultiLogger(
+//		os.Stderr,
+//		trace.Formatted(file, trace.FormatJSON),
+//		trace.NewOTLPExporter("http://collector:4318/v1/traces"),
+//	))
+func MultiLogger(w ...io.Writer) io.Writer {
+	return &multiLogger{sinks: w}
+}
+
+// Write writes p to every sink as is, for callers other than Log.
+func (m *multiLogger) Write(p []byte) (int, error) {
+	var firstErr error
+	for _, w := range m.sinks {
+		if _, err := w.Write(p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return len(p), firstErr
+}
+
+// sink is a writer with the format it expects, or a Sink taking the trace
+// as is.
+type sink struct {
+	w          io.Writer
+	format     Format
+	structured Sink
+}
+
+// sinks flattens w into the sinks Log has to serve.
+func sinks(w io.Writer, res []sink) []sink {
+	switch val := w.(type) {
+	case *multiLogger:
+		for _, v := range val.sinks {
+			res = sinks(v, res)
+		}
+	case *formatWriter:
+		res = append(res, sink{w: val.Writer, format: val.format})
+	case *sinkLogger:
+		res = append(res, sink{structured: val.sink})
+	case *compressWriter:
+		for _, s := range sinks(val.w, nil) {
+			if s.structured == nil {
+				s.w = &compressWriter{w: s.w, threshold: val.threshold}
+			}
+			res = append(res, s)
+		}
+	case nil:
+	default:
+		res = append(res, sink{w: w, format: FormatTree})
+	}
+	return res
+}
+
+// writeSinks writes snap to every sink of w in its format. start is when Log
+// began, to account the formatting time.
+func (tc *TraceContext) writeSinks(w io.Writer, snap *spanSnapshot, start time.Time) error {
+	var tree []byte
+	var data *TraceData
+	var firstErr error
+	for _, s := range sinks(w, nil) {
+		var n int
+		var err error
+		if s.structured != nil {
+			if data == nil {
+				d := snap.data()
+				data = &d
+			}
+			if err := s.structured.WriteTrace(*data); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		s.w = tc.sinkWriter(s.w)
+		switch s.format {
+		case FormatTree:
+			if tree == nil {
+				tree = tc.formatTree(snap)
+				atomic.AddUint64(&stats.FormatNanos, uint64(time.Since(start)))
+			}
+			n, err = s.w.Write(tree)
+		case FormatJSON:
+			n, err = writeEntries(s.w, snap.entries(nil, 0))
+		case FormatOTLP:
+			n, err = writeOTLP(s.w, snap)
+		case FormatTraceData:
+			n, err = writeTraceData(s.w, snap)
+		case FormatProtobuf:
+			n, err = writeProtobuf(s.w, snap)
+		case FormatLogfmt:
+			n, err = writeLogfmt(s.w, snap)
+		case FormatZipkin:
+			n, err = writeZipkin(s.w, snap)
+		}
+		atomic.AddUint64(&stats.BytesLogged, uint64(n))
+		if err == ErrDropped {
+			tc.countDropped()
+			continue
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteTrace writes a trace received from elsewhere, such as another
+// process, to w the way Log would, in the format of each of its sinks. opts
+// configure the formatting, e.g. WithPalette or WithPruning.
+func WriteTrace(w io.Writer, t *TraceData, opts ...Option) error {
+	tc := &TraceContext{opts: newOptions(opts)}
+	return tc.writeSinks(w, t.snapshot(), time.Now())
+}
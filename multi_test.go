@@ -0,0 +1,73 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestMultiLogger(t *testing.T) {
+	tree, flat, otlp := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), MultiLogger(
+		failWriter{},
+		tree,
+		Formatted(flat, FormatJSON),
+		Formatted(otlp, FormatOTLP),
+	))
+	tc.Info("hello")
+	tc.Trace().Info("child")
+	tc.Log()
+
+	if !strings.Contains(tree.String(), "┌ traceId:"+tc.TraceID()) {
+		t.Fatalf("tree sink missing the trace: %q", tree.String())
+	}
+	if lines := strings.Split(strings.TrimSpace(flat.String()), "\n"); len(lines) != 2 ||
+		!strings.Contains(lines[1], `"data":["child"]`) {
+		t.Fatalf("JSON sink should get one line per node: %q", flat.String())
+	}
+	if !strings.Contains(otlp.String(), `"resourceSpans"`) {
+		t.Fatalf("OTLP sink missing the export request: %q", otlp.String())
+	}
+
+	w := MultiLogger(tree, failWriter{}, flat)
+	tree.Reset()
+	flat.Reset()
+	if n, err := w.Write([]byte("raw")); n != 3 || err == nil || tree.String() != "raw" || flat.String() != "raw" {
+		t.Fatalf("plain writes should reach every sink and report the failure: %d %v", n, err)
+	}
+}
+
+func TestLogE(t *testing.T) {
+	tc := NewTraceContext(context.Background(), &bytes.Buffer{})
+	tc.Info("fine")
+	if err := tc.LogE(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	fallback := &bytes.Buffer{}
+	var handled error
+	var counted int64
+	tc = NewTraceContext(context.Background(), failWriter{}, WithFallbackLogger(fallback),
+		WithWriteErrorHandler(func(err error) { handled = err }),
+		WithMetrics(MetricsFunc(func(name string, delta int64, labels ...string) {
+			if name == "trace_write_errors_total" {
+				counted += delta
+			}
+		})))
+	tc.Info("saved")
+	err := tc.LogE()
+	if err == nil || err.Error() != "disk full" || handled != err || counted != 1 {
+		t.Fatalf("failure should be returned, handled and counted: %v %v %d", err, handled, counted)
+	}
+	if !strings.Contains(fallback.String(), `["saved"]`) {
+		t.Fatalf("trace should go to the fallback logger: %q", fallback.String())
+	}
+}
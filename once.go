@@ -0,0 +1,43 @@
+package trace
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Once is sync.Once for initializations worth seeing in traces: the trace
+// of the request that actually ran fn records its duration, and requests
+// that had to wait for it record how long they were blocked. Once
+// initialized, it records nothing. Name identifies it in the records.
+//
+//	var loadConfig = trace.Once{Name: "config"}
+//	...
+//	loadConfig.Do(tc, readConfig)
+//
+// A Once must not be copied after first use.
+type Once struct {
+	Name string
+
+	once sync.Once
+	done uint32
+}
+
+// Do calls fn only the first time it is invoked on o, like sync.Once.Do.
+func (o *Once) Do(tc *TraceContext, fn func()) {
+	start := tc.now()
+	if atomic.LoadUint32(&o.done) == 1 {
+		return
+	}
+	ran := false
+	o.once.Do(func() {
+		defer atomic.StoreUint32(&o.done, 1)
+		ran = true
+		fn()
+	})
+	elapsed := tc.now().Sub(start)
+	if ran {
+		tc.info(2, []interface{}{"once", o.Name, "initialized", elapsed.String()})
+	} else {
+		tc.info(2, []interface{}{"once", o.Name, "waited", elapsed.String()})
+	}
+}
@@ -0,0 +1,60 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnce(t *testing.T) {
+	calls := 0
+	started := make(chan struct{})
+	init := func() {
+		calls++
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+	}
+	once := &Once{Name: "cache"}
+
+	first := NewTraceContext(context.Background(), nil)
+	waiter := NewTraceContext(context.Background(), nil)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-started
+		once.Do(waiter, init)
+	}()
+	once.Do(first, init)
+	wg.Wait()
+
+	late := NewTraceContext(context.Background(), nil)
+	once.Do(late, init)
+
+	if calls != 1 {
+		t.Fatalf("init should run once, ran %d times", calls)
+	}
+	if len(first.infos) != 1 || first.infos[0].Data[1] != "cache" || first.infos[0].Data[2] != "initialized" {
+		t.Fatalf("first trace should record the initialization")
+	}
+	if first.infos[0].Func != "github.com/mucolud/trace.TestOnce" {
+		t.Fatalf("record should be attributed to the caller, got %s", first.infos[0].Func)
+	}
+	if len(waiter.infos) != 1 || waiter.infos[0].Data[2] != "waited" {
+		t.Fatalf("concurrent trace should record the wait")
+	}
+	if waited, _ := time.ParseDuration(waiter.infos[0].Data[3].(string)); waited <= 0 {
+		t.Fatalf("the wait should be measured from before blocking, got %v", waiter.infos[0].Data[3])
+	}
+
+	if len(late.infos) != 0 {
+		t.Fatalf("later traces should record nothing")
+	}
+
+	other := NewTraceContext(context.Background(), nil)
+	(&Once{Name: "cache"}).Do(other, func() {})
+	if len(other.infos) != 1 || other.infos[0].Data[2] != "initialized" {
+		t.Fatalf("a Once with the same name should be initialized on its own")
+	}
+}
@@ -0,0 +1,660 @@
+package trace
+
+import (
+	"io"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Option configures a trace. Options are given to NewTraceContext and are
+// shared by every span created from it with Trace.
+type Option func(*options)
+
+// SpanOption configures a single span created with Trace.
+type SpanOption func(*TraceContext)
+
+type options struct {
+	journal  *Journal
+	escalate bool
+	redactor Redactor
+
+	development bool
+	schemas     []schemaRule
+
+	zeroMode        ZeroMode
+	zeroPlaceholder string
+
+	errorLogger io.Writer
+
+	waitGoroutines bool
+	waitTimeout    time.Duration
+
+	converter Converter
+	encoders  map[reflect.Type]Encoder
+
+	maxChildren int
+	lateSpans   LateSpans
+	loopSample  int
+
+	flatLogger io.Writer
+
+	output  io.Writer
+	format  Format
+	noColor bool
+
+	clock Clock
+
+	errorOnly bool
+
+	minLevel   Level
+	sampleRate float64
+
+	slowThreshold time.Duration
+	logSlow       bool
+
+	funcs []*funcFilter
+
+	inFlight bool
+
+	maxNodeBytes  int
+	maxTraceBytes int
+
+	resource *Resource
+
+	recordHooks []RecordHook
+	logHooks    []LogHook
+
+	fallbackLogger    io.Writer
+	writeErrorHandler func(error)
+	writeTimeout      time.Duration
+
+	alignColumns  bool
+	ambiguousWide bool
+	closedTree    bool
+	treeIndent    int
+
+	rateLimit  int
+	ratePeriod time.Duration
+
+	trimFunc func(name string) string
+
+	attachmentLimit int
+
+	escalationBuffer int
+
+	warnThreshold int
+
+	summary bool
+
+	debug bool
+
+	recent    bool
+	aggregate bool
+
+	collapse          bool
+	collapseThreshold time.Duration
+
+	stackDepth int
+	callerSkip int
+
+	traceId      int64
+	parentSpanId int64
+	palette      Palette
+
+	prune         PruneMode
+	chainCollapse bool
+
+	categories   func(code int) Category
+	metrics      Metrics
+	metricLabels []string // tags of ErrorTagged given as labels
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{clock: systemClock{}, loopSample: defaultLoopSample}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.debug {
+		o.enableDebug()
+	}
+	return o
+}
+
+// WithJournal appends span starts and errors to j as soon as they happen.
+func WithJournal(j *Journal) Option {
+	return func(o *options) {
+		o.journal = j
+	}
+}
+
+// WithEscalation drops Info records until the first Error anywhere in the
+// trace. From then on Info is recorded in full, so a healthy trace only
+// carries its span structure while a failing one gets the details.
+func WithEscalation() Option {
+	return func(o *options) {
+		o.escalate = true
+	}
+}
+
+// WithEscalationBuffer is WithEscalation keeping the last n Info records
+// of the trace aside instead of dropping them: the first Error adds them to
+// their spans, so a failing trace also gets the details leading to the
+// failure. Until then they cost a slot of the buffer and are never
+// rendered.
+func WithEscalationBuffer(n int) Option {
+	return func(o *options) {
+		o.escalate = true
+		o.escalationBuffer = n
+	}
+}
+
+// WithWarnThreshold fails a span once n warnings were recorded on it with
+// Warn: its status becomes StatusError and, for WithEscalation, WithErrorOnly
+// and WithErrorLogger, the trace fails as if it recorded an error. 0, the
+// default, never fails a span on warnings.
+func WithWarnThreshold(n int) Option {
+	return func(o *options) {
+		o.warnThreshold = n
+	}
+}
+
+// WithRedactor applies r to every recorded parameter before it is written.
+func WithRedactor(r Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+// WithSchema validates the tags of every span whose function name matches
+// pattern (see path.Match, applied to the name without its import path,
+// e.g. "repo.*"). Schemas are only checked in development mode.
+func WithSchema(pattern string, v Validator) Option {
+	return func(o *options) {
+		o.schemas = append(o.schemas, schemaRule{pattern: pattern, validator: v})
+	}
+}
+
+// WithDevelopment enables checks that are too costly or noisy for
+// production, such as schema validation.
+func WithDevelopment() Option {
+	return func(o *options) {
+		o.development = true
+	}
+}
+
+// WithZeroMode sets how nil and zero params are recorded.
+func WithZeroMode(mode ZeroMode) Option {
+	return func(o *options) {
+		o.zeroMode = mode
+	}
+}
+
+// WithZeroPlaceholder records nil and zero params as placeholder.
+func WithZeroPlaceholder(placeholder string) Option {
+	return func(o *options) {
+		o.zeroMode = ZeroPlaceholder
+		o.zeroPlaceholder = placeholder
+	}
+}
+
+// WithErrorLogger logs traces containing at least one error to w instead of
+// the trace's own writer, e.g. to keep an error-only file.
+func WithErrorLogger(w io.Writer) Option {
+	return func(o *options) {
+		o.errorLogger = w
+	}
+}
+
+// WithWaitGoroutines makes Log wait for goroutines started with Go before
+// formatting the trace, for at most timeout (0 waits forever). Without it
+// Log marks their spans as still running.
+func WithWaitGoroutines(timeout time.Duration) Option {
+	return func(o *options) {
+		o.waitGoroutines = true
+		o.waitTimeout = timeout
+	}
+}
+
+// WithEncoder renders the params whose dynamic type is the one of sample
+// with e, in log data as well as in the text of returned errors. Errors are
+// recorded by their text whatever their type; the fields of maps and
+// structs are left to their JSON encoding.
+//
+//	trace.WithEncoder(time.Time{}, trace.EncoderFunc(func(v interface{}) interface{} {
+//		return v.(time.Time).UnixMilli()
+//	}))
+func WithEncoder(sample interface{}, e Encoder) Option {
+	return func(o *options) {
+		if o.encoders == nil {
+			o.encoders = make(map[reflect.Type]Encoder)
+		}
+		o.encoders[reflect.TypeOf(sample)] = e
+	}
+}
+
+// WithConverter formats the params of returned errors with c instead of
+// the default %+v rendering.
+func WithConverter(c Converter) Option {
+	return func(o *options) {
+		o.converter = c
+	}
+}
+
+// WithMaxChildren limits every span to n children. Further calls to Trace
+// still return a usable span, but it is left out of the tree and counted in
+// a single "N more children suppressed" line instead.
+func WithMaxChildren(n int) Option {
+	return func(o *options) {
+		o.maxChildren = n
+	}
+}
+
+// WithLoopSample sets how many of the first and of the last iterations
+// of a Loop are kept, 3 by default. With 0, or less, only the iterations
+// that failed are.
+func WithLoopSample(k int) Option {
+	if k < 0 {
+		k = 0
+	}
+	return func(o *options) {
+		o.loopSample = k
+	}
+}
+
+// WithLateSpans chooses what becomes of the spans created from the trace
+// after it was logged, by default added to its tree like the others.
+func WithLateSpans(l LateSpans) Option {
+	return func(o *options) {
+		o.lateSpans = l
+	}
+}
+
+// WithFlatLogger makes Log also write the flattened form of the trace, one
+// JSON Entry per line, to w. The tree and the flattened form are produced
+// from the same snapshot, so they always describe the same state.
+func WithFlatLogger(w io.Writer) Option {
+	return func(o *options) {
+		o.flatLogger = w
+	}
+}
+
+// WithOutput makes the trace log to w instead of the logger given to
+// NewTraceContext.
+func WithOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.output = w
+	}
+}
+
+// WithFormat makes Log write the trace in format f to a logger that is a
+// plain writer. Loggers made with Formatted, MultiLogger, SinkLogger or
+// Compressed keep the formats they were built with.
+func WithFormat(f Format) Option {
+	return func(o *options) {
+		o.format = f
+	}
+}
+
+// WithoutColor writes the tree without ANSI colors, for logs that are not
+// read on a terminal.
+func WithoutColor() Option {
+	return func(o *options) {
+		o.noColor = true
+	}
+}
+
+// WithClock makes the trace take trace ids, timestamps and durations from c
+// instead of the system clock.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithErrorOnly makes Log write the trace only if it contains at least one
+// error, see LogIfError.
+func WithErrorOnly() Option {
+	return func(o *options) {
+		o.errorOnly = true
+	}
+}
+
+// WithMinLevel drops the Info records of the trace below level, like
+// Config.MinLevel does for every trace. Errors are always recorded.
+func WithMinLevel(level Level) Option {
+	return func(o *options) {
+		o.minLevel = level
+	}
+}
+
+// WithSampleRate makes Log write only the given fraction of the traces
+// without errors, like Config.SampleRate does for every trace.
+func WithSampleRate(rate float64) Option {
+	return func(o *options) {
+		o.sampleRate = rate
+	}
+}
+
+// WithSlowThreshold flags every span taking longer than d as slow: its
+// header is marked and structured outputs carry slow=true. Spans can
+// override it with WithSpanSlowThreshold.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = d
+	}
+}
+
+// WithLogSlow makes WithErrorOnly and LogIfError write traces with a slow
+// span too, to catch latency regressions.
+func WithLogSlow() Option {
+	return func(o *options) {
+		o.logSlow = true
+	}
+}
+
+// WithIncludeFuncs records infos only from the functions matching one of
+// patterns, to silence everything but the code under investigation.
+// Patterns are matched against the full function name, e.g.
+// "github.com/acme/shop/cart.(*Cart).Add", and * matches any run of
+// characters. Errors and events are always recorded.
+func WithIncludeFuncs(patterns ...string) Option {
+	f := &funcFilter{patterns: patterns}
+	return func(o *options) {
+		o.funcs = append(o.funcs, f)
+	}
+}
+
+// WithExcludeFuncs drops the infos of the functions matching one of
+// patterns, such as hot helpers not worth reading, without touching their
+// call sites. See WithIncludeFuncs for the patterns.
+func WithExcludeFuncs(patterns ...string) Option {
+	f := &funcFilter{patterns: patterns, exclude: true}
+	return func(o *options) {
+		o.funcs = append(o.funcs, f)
+	}
+}
+
+// WithInFlight lists the trace in InFlight from its creation until it is
+// logged, so a debug endpoint such as traceui.InFlightHandler can show what
+// the process is busy with.
+func WithInFlight() Option {
+	return func(o *options) {
+		o.inFlight = true
+	}
+}
+
+// WithMaxNodeBytes caps the params of every node to about n bytes as
+// rendered, so a huge struct or byte slice cannot blow up the log. Values
+// past the cap are cut and end in "…(+N bytes)", N being the bytes dropped.
+func WithMaxNodeBytes(n int) Option {
+	return func(o *options) {
+		o.maxNodeBytes = n
+	}
+}
+
+// WithMaxTraceBytes caps the params of the whole trace to about n bytes, cut
+// like with WithMaxNodeBytes. Spans are filled in tree order, each with its
+// errors before its infos, so the nodes past the cap lose their params.
+func WithMaxTraceBytes(n int) Option {
+	return func(o *options) {
+		o.maxTraceBytes = n
+	}
+}
+
+// WithResource stamps every trace with r, see ProcessResource. It is
+// rendered in the header of the tree and included in structured outputs.
+func WithResource(r Resource) Option {
+	return func(o *options) {
+		o.resource = &r
+	}
+}
+
+// WithRecordHook runs h on every info and error recorded. Hooks run in the
+// order they were added.
+func WithRecordHook(h RecordHook) Option {
+	return func(o *options) {
+		o.recordHooks = append(o.recordHooks, h)
+	}
+}
+
+// WithLogHook runs h every time the trace is logged, before it is
+// formatted. Hooks run in the order they were added.
+func WithLogHook(h LogHook) Option {
+	return func(o *options) {
+		o.logHooks = append(o.logHooks, h)
+	}
+}
+
+// WithFallbackLogger writes the trace to w as well when writing it to the
+// logger failed, e.g. to stderr when the log file is on a full disk.
+func WithFallbackLogger(w io.Writer) Option {
+	return func(o *options) {
+		o.fallbackLogger = w
+	}
+}
+
+// WithWriteErrorHandler calls h with the error when writing a trace failed,
+// after the fallback logger was written to. See LogE to get the error from
+// Log instead.
+func WithWriteErrorHandler(h func(error)) Option {
+	return func(o *options) {
+		o.writeErrorHandler = h
+	}
+}
+
+// WithWriteTimeout gives up on a sink that did not take the trace within d,
+// so that Log cannot block forever on a network writer; Log then fails with
+// ErrWriteTimeout. The write that timed out goes on in the background, so a
+// sink that hangs keeps a goroutine per trace: wrap it in an AsyncWriter to
+// bound them, whose writes never block and are not timed.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.writeTimeout = d
+	}
+}
+
+// WithAlignedColumns lines up the tree: the markers following the span names,
+// such as the status and budget, start at the same column, and so does the
+// data of the nodes of a span. Widths are measured in terminal cells, so
+// CJK text and emoji count twice.
+func WithAlignedColumns() Option {
+	return func(o *options) {
+		o.alignColumns = true
+	}
+}
+
+// WithAmbiguousWide measures the East Asian Ambiguous characters, such as
+// the box drawing of the tree and "…", as two cells like terminals set up
+// for CJK locales draw them.
+func WithAmbiguousWide() Option {
+	return func(o *options) {
+		o.ambiguousWide = true
+	}
+}
+
+// WithClosedTree draws the tree with closed box drawing: the last item below
+// a span, be it a node or a child span, ends its branch with "└" and the
+// branches of the spans still open are continued by "│" down to it.
+//
+//	├─ svc.Load
+//	│  ├> svc.go:12:["key"]
+//	│  └─ db.Query
+//	│     └E db.go:40:["timeout"]
+func WithClosedTree() Option {
+	return func(o *options) {
+		o.closedTree = true
+	}
+}
+
+// WithTreeIndent sets the number of cells every level of the tree is indented
+// by, 3 by default. Values below 2 are raised to 2.
+func WithTreeIndent(n int) Option {
+	return func(o *options) {
+		o.treeIndent = n
+	}
+}
+
+// WithFuncNames trims the function names of spans and nodes in every format
+// the trace is written in, e.g. to ShortFuncNames. WithIncludeFuncs and
+// WithExcludeFuncs still match the full names.
+func WithFuncNames(f FuncNames) Option {
+	return func(o *options) {
+		o.trimFunc = f.trim()
+	}
+}
+
+// WithFuncNameTrim trims the function names of spans and nodes with trim in
+// every format the trace is written in, like WithFuncNames.
+func WithFuncNameTrim(trim func(name string) string) Option {
+	return func(o *options) {
+		o.trimFunc = trim
+	}
+}
+
+// WithAttachmentLimit keeps at most n bytes of every payload attached with
+// Attach, 64 KiB by default.
+func WithAttachmentLimit(n int) Option {
+	return func(o *options) {
+		o.attachmentLimit = n
+	}
+}
+
+// WithRateLimit keeps at most n infos and errors per call site, the line
+// recording them, within every period, so that a tight loop cannot flood
+// the trace and the sinks. The nodes over the limit are dropped and counted
+// on the last node kept, rendered as "(42 more suppressed)". Errors dropped
+// still mark the trace as failed and count in the metrics.
+func WithRateLimit(n int, period time.Duration) Option {
+	return func(o *options) {
+		o.rateLimit = n
+		o.ratePeriod = period
+	}
+}
+
+// WithRecent keeps the trace, once logged, in a process wide buffer of the
+// last traces, see SetRecentLimits, so that operators can dump them with
+// DumpRecent even when WithErrorOnly left them out of the logs.
+func WithRecent() Option {
+	return func(o *options) {
+		o.recent = true
+	}
+}
+
+// WithAggregate adds the spans of the trace, once logged, to the process
+// wide latency histograms and error rates of their functions, see
+// AggregateReport, whether or not WithErrorOnly left the trace out of the
+// logs. Spans still running are left out.
+func WithAggregate() Option {
+	return func(o *options) {
+		o.aggregate = true
+	}
+}
+
+// WithSummary ends the tree with a footer giving the verdict on the trace:
+// the number of spans and errors, the path to the deepest error and the
+// slowest span.
+func WithSummary() Option {
+	return func(o *options) {
+		o.summary = true
+	}
+}
+
+// WithCollapse renders every subtree without errors that finished within
+// threshold as a single summary line, e.g. "✓ svc.LoadProfile 3 spans 12ms",
+// so the reader's attention goes to the subtrees that failed or were slow.
+func WithCollapse(threshold time.Duration) Option {
+	return func(o *options) {
+		o.collapse = true
+		o.collapseThreshold = threshold
+	}
+}
+
+// WithStackTrace records up to depth stack frames on every error node,
+// printed indented beneath the error line.
+func WithStackTrace(depth int) Option {
+	return func(o *options) {
+		o.stackDepth = depth
+	}
+}
+
+// WithCallerSkip attributes every record and span name of the trace n
+// frames further up the stack than the caller of Info, Error, Trace and the
+// like, for traces only ever used through a layer of wrappers. Use
+// ErrorSkip for a single helper.
+func WithCallerSkip(n int) Option {
+	return func(o *options) {
+		o.callerSkip = n
+	}
+}
+
+// WithTraceID continues an existing trace: the new root takes over traceID,
+// as returned by TraceID, instead of generating its own. An invalid id is
+// ignored.
+func WithTraceID(traceID string) Option {
+	return func(o *options) {
+		if id, err := ParseTraceID(traceID); err == nil {
+			o.traceId = id
+		}
+	}
+}
+
+// WithParentSpanID makes the new root the child of a span of another
+// process, identified by spanID as returned by SpanID. An invalid id is
+// ignored.
+func WithParentSpanID(spanID string) Option {
+	return func(o *options) {
+		if id, err := ParseTraceID(spanID); err == nil {
+			o.parentSpanId = id
+		}
+	}
+}
+
+// WithPalette overrides the colors and glyphs of node levels. Levels missing
+// from p keep their DefaultPalette style.
+func WithPalette(p Palette) Option {
+	return func(o *options) {
+		o.palette = p
+	}
+}
+
+// WithCategories replaces DefaultCategory to classify the codes given to
+// ErrorCode.
+func WithCategories(fn func(code int) Category) Option {
+	return func(o *options) {
+		o.categories = fn
+	}
+}
+
+// WithMetrics reports counters to m.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithMetricLabels gives the tags of ErrorTagged with the given keys as
+// labels to the trace_errors_total counter of WithMetrics. Other tags are
+// left out, so that callers cannot make the label set unbounded.
+func WithMetricLabels(keys ...string) Option {
+	return func(o *options) {
+		o.metricLabels = append([]string(nil), keys...)
+		sort.Strings(o.metricLabels)
+	}
+}
+
+// WithPruning selects which child spans the tree leaves out, PruneEmpty by
+// default.
+func WithPruning(mode PruneMode) Option {
+	return func(o *options) {
+		o.prune = mode
+	}
+}
+
+// WithChainCollapse prints chains of spans that only lead to a single child
+// on one line, "a › b › c".
+func WithChainCollapse() Option {
+	return func(o *options) {
+		o.chainCollapse = true
+	}
+}
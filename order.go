@@ -0,0 +1,72 @@
+package trace
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// recordKind tells apart the records of a span merged by timeline.
+type recordKind int
+
+const (
+	infoRecord recordKind = iota
+	errorRecord
+	eventRecord
+)
+
+// spanRecord is an info, error or event of a span.
+type spanRecord struct {
+	kind recordKind
+	*node
+}
+
+// nextSeq returns the position of a record about to be added to the trace.
+// Records of the same span are rendered in this order, which unlike their
+// time is never tied.
+func (s *traceState) nextSeq() uint64 {
+	return atomic.AddUint64(&s.seq, 1)
+}
+
+// before reports whether n was recorded before o. Nodes without a
+// sequence, such as those of TraceData encoded by older versions, are
+// ordered by time.
+func (n *node) before(o *node) bool {
+	if n.seq != 0 && o.seq != 0 {
+		return n.seq < o.seq
+	}
+	return n.Time.Before(o.Time)
+}
+
+// timeline returns the infos, errors and events of the span in the order
+// they were recorded.
+func (s *spanSnapshot) timeline() []spanRecord {
+	res := make([]spanRecord, 0, len(s.infos)+len(s.errors)+len(s.events))
+	for _, v := range s.infos {
+		res = append(res, spanRecord{infoRecord, v})
+	}
+	for _, v := range s.errors {
+		res = append(res, spanRecord{errorRecord, v})
+	}
+	for _, v := range s.events {
+		res = append(res, spanRecord{eventRecord, v})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].before(res[j].node)
+	})
+	return res
+}
+
+// sequence numbers the records of tc restored by Resume in the order of
+// their time, so that the ones recorded afterwards follow them.
+func (tc *TraceContext) sequence() {
+	nodes := make([]*node, 0, len(tc.infos)+len(tc.errors)+len(tc.events))
+	nodes = append(nodes, tc.infos...)
+	nodes = append(nodes, tc.errors...)
+	nodes = append(nodes, tc.events...)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Time.Before(nodes[j].Time)
+	})
+	for _, v := range nodes {
+		v.seq = tc.state.nextSeq()
+	}
+}
@@ -0,0 +1,64 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTraceContext_recordOrder(t *testing.T) {
+	// a clock that never moves: only the order of the calls tells them apart
+	clock := &stepClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithClock(clock))
+	tc.Info("connecting")
+	_ = tc.Error(errors.New("refused"))
+	tc.Info("retrying")
+	tc.Event("connected")
+	tc.Log()
+
+	out := ansi.ReplaceAllString(buf.String(), "")
+	if !regexp.MustCompile(`├> \S+:\d+:\["connecting"\]\n├E \S+:\d+:\["refused"\]\n├> \S+:\d+:\["retrying"\]\n├\* 12:00:00\.000 connected\n`).MatchString(out) {
+		t.Fatalf("records not rendered in the order they were made: %s", out)
+	}
+	var got []string
+	for _, e := range tc.Entries() {
+		got = append(got, e.Level.String()+":"+e.Name)
+	}
+	if want := "[info: error: info: info:connected]"; fmt.Sprint(got) != want {
+		t.Fatalf("entries out of order: %s, want %s", fmt.Sprint(got), want)
+	}
+}
+
+func TestResume_recordOrder(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	tc := NewTraceContext(context.Background(), nil, WithClock(clock))
+	_ = tc.Error(errors.New("refused"))
+	clock.now = clock.now.Add(time.Second)
+	tc.Info("retrying")
+	b, err := tc.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := Resume(context.Background(), nil, b, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// recorded at the same time as the last restored record, yet after it
+	resumed.Info("resumed")
+	var got []string
+	for _, e := range resumed.Entries() {
+		got = append(got, e.Level.String())
+	}
+	if want := "[error info info]"; fmt.Sprint(got) != want {
+		t.Fatalf("entries out of order: %s, want %s", fmt.Sprint(got), want)
+	}
+	if entries := resumed.Entries(); entries[2].Data[0] != "resumed" {
+		t.Fatalf("new record not last: %+v", entries)
+	}
+}
@@ -0,0 +1,326 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLP status codes and span kinds.
+const (
+	otlpStatusUnset  = 0
+	otlpStatusOK     = 1
+	otlpStatusError  = 2
+	otlpKindInternal = 1
+	otlpKindClient   = 3
+	otlpKindProducer = 4
+)
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Links             []otlpLink     `json:"links,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+// otlpLink has no span id, Link refers to a whole trace.
+type otlpLink struct {
+	TraceId    string         `json:"traceId"`
+	SpanId     string         `json:"spanId,omitempty"`
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    string   `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func otlpValue(v interface{}) otlpAnyValue {
+	switch val := v.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &val}
+	case bool:
+		return otlpAnyValue{BoolValue: &val}
+	case int:
+		return otlpAnyValue{IntValue: strconv.Itoa(val)}
+	case int64:
+		return otlpAnyValue{IntValue: strconv.FormatInt(val, 10)}
+	case float64:
+		return otlpAnyValue{DoubleValue: &val}
+	}
+	s := toString(v)
+	return otlpAnyValue{StringValue: &s}
+}
+
+func otlpAttr(key string, v interface{}) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpValue(v)}
+}
+
+// otlpStatusOf maps the status of the span, OTLP has no cancelled status.
+func otlpStatusOf(s *spanSnapshot) otlpStatus {
+	switch s.status {
+	case StatusOK:
+		return otlpStatus{Code: otlpStatusOK}
+	case StatusError:
+		return otlpStatus{Code: otlpStatusError}
+	case StatusCancelled:
+		msg := "cancelled"
+		if s.cause != "" {
+			msg += ": " + s.cause
+		}
+		return otlpStatus{Code: otlpStatusError, Message: msg}
+	}
+	return otlpStatus{Code: otlpStatusUnset}
+}
+
+func otlpTime(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// otlpSpans appends the spans of the subtree in pre-order.
+func (s *spanSnapshot) otlpSpans(res []otlpSpan) []otlpSpan {
+	res = append(res, s.otlpSpan())
+	for _, v := range s.children {
+		res = v.otlpSpans(res)
+	}
+	return res
+}
+
+// otlpSpan converts the span without its children.
+func (s *spanSnapshot) otlpSpan() otlpSpan {
+	span := otlpSpan{
+		TraceId:           strings.Repeat("0", 16) + hexID(s.traceId),
+		SpanId:            hexSpanID(s.spanId),
+		ParentSpanId:      hexSpanID(s.parentId),
+		Name:              s.funcName,
+		StartTimeUnixNano: otlpTime(s.start),
+		EndTimeUnixNano:   otlpTime(s.end),
+		Status:            otlpStatusOf(s),
+	}
+	span.Attributes = otlpAttrs(s.tags)
+	for _, v := range s.args {
+		span.Attributes = append(span.Attributes, otlpAttr("code.args."+v.Name, v.Value))
+	}
+	if s.label != "" {
+		span.Attributes = append(span.Attributes, otlpAttr("thread.name", s.label))
+	}
+	if s.peer.Host != "" {
+		span.Attributes = append(span.Attributes, otlpAttr("net.peer.name", s.peer.Host))
+	}
+	if s.peer.Port > 0 {
+		span.Attributes = append(span.Attributes, otlpAttr("net.peer.port", s.peer.Port))
+	}
+	if s.peer.Service != "" {
+		span.Attributes = append(span.Attributes, otlpAttr("peer.service", s.peer.Service))
+	}
+	span.Kind = s.otlpKind()
+	if d := s.dependency; d != nil {
+		attrs := d.otlpAttrs()
+		if s.peer.Service != "" {
+			attrs = attrs[1:] // peer.service is already set
+		}
+		span.Attributes = append(span.Attributes, attrs...)
+	}
+	if s.budget != 0 {
+		span.Attributes = append(span.Attributes, otlpAttr("trace.deadline_budget_ms", s.budget.Milliseconds()))
+	}
+	if s.slow {
+		span.Attributes = append(span.Attributes, otlpAttr("trace.slow", true))
+	}
+	if s.cause != "" {
+		span.Attributes = append(span.Attributes, otlpAttr("trace.cancel_cause", s.cause))
+	}
+	if s.suppressed > 0 {
+		span.Attributes = append(span.Attributes, otlpAttr("trace.suppressed_children", s.suppressed))
+	}
+	for _, v := range s.timeline() {
+		switch v.kind {
+		case infoRecord:
+			span.Events = append(span.Events, otlpEvent{
+				TimeUnixNano: otlpTime(v.Time),
+				Name:         v.Level.String(),
+				Attributes:   otlpNodeAttrs(v.node, "data"),
+			})
+		case errorRecord:
+			attrs := otlpNodeAttrs(v.node, "exception.message")
+			if len(v.Stack) > 0 {
+				attrs = append(attrs, otlpAttr("exception.stacktrace", strings.Join(v.Stack, "\n")))
+			}
+			if v.Code != 0 {
+				attrs = append(attrs, otlpAttr("error.code", v.Code))
+			}
+			if v.Category != "" {
+				attrs = append(attrs, otlpAttr("error.category", string(v.Category)))
+			}
+			for _, k := range sortedTags(v.Tags) {
+				attrs = append(attrs, otlpAttr("error.tag."+k, v.Tags[k]))
+			}
+			span.Events = append(span.Events, otlpEvent{
+				TimeUnixNano: otlpTime(v.Time),
+				Name:         "exception",
+				Attributes:   attrs,
+			})
+			if s.status == StatusError && span.Status.Message == "" {
+				span.Status.Message = otlpData(v.Data)
+			}
+		case eventRecord:
+			span.Events = append(span.Events, otlpEvent{
+				TimeUnixNano: otlpTime(v.Time),
+				Name:         v.Name,
+				Attributes:   otlpAttrs(v.Data[0].(map[string]interface{})),
+			})
+		}
+	}
+	for _, v := range s.attachments {
+		span.Events = append(span.Events, v.otlpEvent())
+	}
+	for _, v := range s.links {
+		id, _ := ParseTraceID(v.Name)
+		span.Links = append(span.Links, otlpLink{
+			TraceId:    strings.Repeat("0", 16) + hexID(id),
+			Attributes: otlpAttrs(v.Data[0].(map[string]interface{})),
+		})
+	}
+	return span
+}
+
+// otlpAttrs converts m sorted by key, so exports are stable.
+func otlpAttrs(m map[string]interface{}) []otlpKeyValue {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, otlpAttr(k, m[k]))
+	}
+	return res
+}
+
+func otlpNodeAttrs(v *node, dataKey string) []otlpKeyValue {
+	return []otlpKeyValue{
+		otlpAttr("code.function", v.Func),
+		otlpAttr("code.lineno", v.Line),
+		otlpAttr(dataKey, otlpData(v.Data)),
+	}
+}
+
+func otlpData(data []interface{}) string {
+	res, _ := json.Marshal(data)
+	return string(res)
+}
+
+// writeOTLP writes the trace as a single OTLP/JSON export request.
+func writeOTLP(w io.Writer, s *spanSnapshot) (int, error) {
+	req := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: s.resource.otlpAttrs()},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/mucolud/trace"},
+			Spans: s.otlpSpans(nil),
+		}},
+	}}}
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(req); err != nil {
+		return 0, err
+	}
+	return w.Write(buf.Bytes())
+}
+
+// httpExporter posts every write to the endpoint of a collector.
+type httpExporter struct {
+	protocol string // for errors
+	url      string
+	client   *http.Client
+}
+
+func newHTTPExporter(protocol, url string) *httpExporter {
+	return &httpExporter{protocol: protocol, url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NewOTLPExporter returns a sink posting every trace to the OTLP/HTTP
+// traces endpoint url of a collector, such as
+// http://localhost:4318/v1/traces.
+func NewOTLPExporter(url string) io.Writer {
+	return Formatted(newHTTPExporter("OTLP", url), FormatOTLP)
+}
+
+func (e *httpExporter) Write(p []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(p))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if Gzipped(p) {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return 0, fmt.Errorf("trace: %s export to %s: %s", e.protocol, e.url, resp.Status)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,55 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	tc := NewTraceContext(context.Background(), NewOTLPExporter(srv.URL))
+	tc.SetTag("user", 42)
+	child := tc.Trace(WithPeerService("billing"))
+	child.Event("charged", "amount", 1.5)
+	_ = child.Error(errors.New("declined"))
+	tc.Log()
+
+	var req otlpRequest
+	if err := json.Unmarshal(<-bodies, &req); err != nil {
+		t.Fatal(err)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	root, sub := spans[0], spans[1]
+	if len(root.TraceId) != 32 || root.TraceId[16:] != tc.TraceID() || sub.TraceId != root.TraceId {
+		t.Fatalf("unexpected trace ids %q %q", root.TraceId, sub.TraceId)
+	}
+	if root.ParentSpanId != "" || sub.ParentSpanId != root.SpanId || sub.SpanId == root.SpanId {
+		t.Fatalf("child not linked to its parent: %+v", sub)
+	}
+	if kv := root.Attributes[0]; kv.Key != "user" || kv.Value.IntValue != "42" {
+		t.Fatalf("tag not exported: %+v", root.Attributes)
+	}
+	if sub.Status.Code != otlpStatusError || sub.Status.Message != `["declined"]` {
+		t.Fatalf("failed span should have an error status: %+v", sub.Status)
+	}
+	if len(sub.Events) != 2 || sub.Events[0].Name != "charged" || sub.Events[1].Name != "exception" {
+		t.Fatalf("unexpected events %+v", sub.Events)
+	}
+}
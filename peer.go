@@ -0,0 +1,74 @@
+package trace
+
+import (
+	"net"
+	"strconv"
+)
+
+// Peer is the remote side of a client span (HTTP, gRPC, database...).
+type Peer struct {
+	Host    string `json:"host,omitempty"`
+	Port    int    `json:"port,omitempty"`
+	Service string `json:"service,omitempty"`
+}
+
+func (p Peer) IsZero() bool {
+	return p == Peer{}
+}
+
+// String renders the peer as service@host:port, leaving out unknown parts.
+func (p Peer) String() string {
+	addr := p.Host
+	if p.Port > 0 {
+		addr = net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	}
+	switch {
+	case p.Service == "":
+		return addr
+	case addr == "":
+		return p.Service
+	}
+	return p.Service + "@" + addr
+}
+
+// WithPeer marks the span as a call to host:port.
+func WithPeer(host string, port int) SpanOption {
+	return func(tc *TraceContext) {
+		tc.peer.Host = host
+		tc.peer.Port = port
+	}
+}
+
+// WithPeerAddr marks the span as a call to addr ("host:port" or "host").
+func WithPeerAddr(addr string) SpanOption {
+	return func(tc *TraceContext) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			tc.peer.Host = addr
+			return
+		}
+		tc.peer.Host = host
+		tc.peer.Port, _ = strconv.Atoi(port)
+	}
+}
+
+// WithPeerService names the service the span calls.
+func WithPeerService(service string) SpanOption {
+	return func(tc *TraceContext) {
+		tc.peer.Service = service
+	}
+}
+
+// Peer returns the remote peer of the span, if any.
+func (tc *TraceContext) Peer() Peer {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	return tc.peer
+}
+
+func (s *spanSnapshot) formatPeer() string {
+	if s.peer.IsZero() {
+		return ""
+	}
+	return " -> " + s.peer.String()
+}
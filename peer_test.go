@@ -0,0 +1,29 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWithPeer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	db := tc.Trace(WithPeerAddr("10.0.0.7:5432"), WithPeerService("postgres"))
+	db.Info("select")
+	tc.Trace(WithPeer("cache", 0)).Info("get")
+	tc.Log()
+
+	if p := db.Peer(); p != (Peer{Host: "10.0.0.7", Port: 5432, Service: "postgres"}) {
+		t.Fatalf("unexpected peer %+v", p)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "TestWithPeer -> postgres@10.0.0.7:5432\n") ||
+		!strings.Contains(out, "TestWithPeer -> cache\n") {
+		t.Fatalf("peer not rendered: %s", out)
+	}
+	if e := tc.Entries()[0]; e.Peer == nil || e.Peer.Service != "postgres" {
+		t.Fatalf("peer missing from entries: %+v", e)
+	}
+}
@@ -0,0 +1,61 @@
+package trace
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	nodePool = sync.Pool{New: func() interface{} { return new(node) }}
+	spanPool = sync.Pool{New: func() interface{} { return new(TraceContext) }}
+)
+
+func newNode(v node) *node {
+	atomic.AddUint64(&stats.Nodes, 1)
+	n := nodePool.Get().(*node)
+	*n = v
+	return n
+}
+
+// Release hands tc, its descendants and their records back to internal
+// pools, so the next traces can reuse them instead of allocating. Call it on
+// the root once the trace was logged and nothing refers to it any more; it
+// waits for goroutines started with Go. Neither tc nor any of its spans may
+// be used afterwards.
+func (tc *TraceContext) Release() {
+	tc.state.running.Wait()
+	if tc.opts.inFlight {
+		tc.unregisterInFlight()
+	}
+	tc.release()
+}
+
+func (tc *TraceContext) release() {
+	for _, v := range tc.children {
+		v.release()
+	}
+	*tc = TraceContext{
+		children: releaseSpans(tc.children),
+		errors:   releaseNodes(tc.errors),
+		infos:    releaseNodes(tc.infos),
+		events:   releaseNodes(tc.events),
+		links:    releaseNodes(tc.links),
+	}
+	spanPool.Put(tc)
+}
+
+func releaseNodes(nodes []*node) []*node {
+	for i, v := range nodes {
+		*v = node{}
+		nodePool.Put(v)
+		nodes[i] = nil
+	}
+	return nodes[:0]
+}
+
+func releaseSpans(spans []*TraceContext) []*TraceContext {
+	for i := range spans {
+		spans[i] = nil
+	}
+	return spans[:0]
+}
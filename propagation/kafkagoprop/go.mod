@@ -0,0 +1,15 @@
+module github.com/mucolud/trace/propagation/kafkagoprop
+
+go 1.20
+
+require (
+	github.com/mucolud/trace v0.0.0
+	github.com/segmentio/kafka-go v0.4.39
+)
+
+require (
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+)
+
+replace github.com/mucolud/trace => ../../
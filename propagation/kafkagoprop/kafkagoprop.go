@@ -0,0 +1,42 @@
+// Package kafkagoprop propagates traces through kafka-go messages.
+package kafkagoprop
+
+import (
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/propagation"
+	"github.com/segmentio/kafka-go"
+)
+
+// Carrier is a propagation.Carrier over the headers of a kafka-go message.
+type Carrier struct {
+	Msg *kafka.Message
+}
+
+func (c Carrier) Get(key string) string {
+	for _, h := range c.Msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c Carrier) Set(key, value string) {
+	for i, h := range c.Msg.Headers {
+		if h.Key == key {
+			c.Msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.Msg.Headers = append(c.Msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Inject writes the trace of tc to the headers of msg.
+func Inject(tc *trace.TraceContext, msg *kafka.Message) {
+	propagation.Inject(tc, Carrier{Msg: msg})
+}
+
+// Extract returns the options continuing the trace carried by msg.
+func Extract(msg *kafka.Message) []trace.Option {
+	return propagation.Extract(Carrier{Msg: msg})
+}
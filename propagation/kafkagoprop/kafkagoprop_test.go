@@ -0,0 +1,27 @@
+package kafkagoprop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mucolud/trace"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestInjectExtract(t *testing.T) {
+	producer := trace.NewTraceContext(context.Background(), nil, trace.WithDebug())
+	msg := &kafka.Message{Topic: "orders"}
+	Inject(trace.NewTraceContext(context.Background(), nil), msg)
+	Inject(producer, msg)
+	if len(msg.Headers) != 3 {
+		t.Fatalf("injecting again should replace the headers: %v", msg.Headers)
+	}
+
+	consumer := trace.NewTraceContext(context.Background(), nil, Extract(msg)...)
+	if consumer.TraceID() != producer.TraceID() || consumer.ParentSpanID() != producer.SpanID() || !consumer.IsDebug() {
+		t.Fatalf("consumer should continue the producer's trace: %v", msg.Headers)
+	}
+	if opts := Extract(&kafka.Message{}); opts != nil {
+		t.Fatalf("a message without headers carries no trace")
+	}
+}
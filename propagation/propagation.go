@@ -0,0 +1,107 @@
+// Package propagation passes traces across process boundaries, so that the
+// consumer of a message or the server of a request continues the caller's
+// trace instead of starting a new one.
+package propagation
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mucolud/trace"
+)
+
+// Carrier is where the trace is written to and read from, such as message
+// or request headers.
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// Inject writes the trace of tc to c.
+func Inject(tc *trace.TraceContext, c Carrier) {
+	c.Set(trace.TraceIDHeader, tc.TraceID())
+	c.Set(trace.SpanIDHeader, tc.SpanID())
+	if tc.IsDebug() {
+		c.Set(trace.DebugHeader, "1")
+	}
+}
+
+// Extract reads a trace from c and returns the options continuing it, to be
+// passed to trace.NewTraceContext. A caller's trace.DebugHeader carries
+// over as trace.WithDebug. It returns nil if c carries neither.
+func Extract(c Carrier) []trace.Option {
+	var opts []trace.Option
+	if trace.ParseDebug(c.Get(trace.DebugHeader)) {
+		opts = append(opts, trace.WithDebug())
+	}
+	id := c.Get(trace.TraceIDHeader)
+	if id == "" {
+		return opts
+	}
+	if _, err := trace.ParseTraceID(id); err != nil {
+		return opts
+	}
+	opts = append(opts, trace.WithTraceID(id))
+	if span := c.Get(trace.SpanIDHeader); span != "" {
+		opts = append(opts, trace.WithParentSpanID(span))
+	}
+	return opts
+}
+
+// MapCarrier is a Carrier over a map. Keys are looked up case-insensitively
+// when there is no exact match.
+type MapCarrier map[string]string
+
+func (m MapCarrier) Get(key string) string {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+func (m MapCarrier) Set(key, value string) {
+	m[key] = value
+}
+
+// HeaderCarrier is a Carrier over HTTP headers.
+type HeaderCarrier http.Header
+
+func (h HeaderCarrier) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+func (h HeaderCarrier) Set(key, value string) {
+	http.Header(h).Set(key, value)
+}
+
+// TableCarrier is a Carrier over AMQP headers. amqp.Table converts to it
+// directly: propagation.TableCarrier(msg.Headers).
+type TableCarrier map[string]interface{}
+
+func (t TableCarrier) Get(key string) string {
+	v, ok := t[key]
+	if !ok {
+		for k, val := range t {
+			if strings.EqualFold(k, key) {
+				v, ok = val, true
+				break
+			}
+		}
+	}
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return ""
+}
+
+func (t TableCarrier) Set(key, value string) {
+	t[key] = value
+}
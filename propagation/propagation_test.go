@@ -0,0 +1,47 @@
+package propagation
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mucolud/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	producer := trace.NewTraceContext(context.Background(), nil)
+	carriers := map[string]Carrier{
+		"map":    MapCarrier{},
+		"header": HeaderCarrier(http.Header{}),
+		"table":  TableCarrier{},
+	}
+	for name, c := range carriers {
+		Inject(producer, c)
+		consumer := trace.NewTraceContext(context.Background(), nil, Extract(c)...)
+		if consumer.TraceID() != producer.TraceID() || consumer.ParentSpanID() != producer.SpanID() {
+			t.Errorf("%s: consumer should continue the producer's trace", name)
+		}
+	}
+
+	if opts := Extract(MapCarrier{"x-trace-id": "0000000000000abc"}); len(opts) != 1 {
+		t.Fatalf("map keys should match case-insensitively")
+	}
+	if opts := Extract(TableCarrier{trace.TraceIDHeader: []byte("0000000000000abc")}); len(opts) != 1 {
+		t.Fatalf("byte values in AMQP tables should be accepted")
+	}
+	if opts := Extract(MapCarrier{trace.TraceIDHeader: "not hex"}); opts != nil {
+		t.Fatalf("invalid ids must be ignored")
+	}
+}
+
+func TestInjectExtractDebug(t *testing.T) {
+	producer := trace.NewTraceContext(context.Background(), nil, trace.WithDebug())
+	c := MapCarrier{}
+	Inject(producer, c)
+	if !trace.NewTraceContext(context.Background(), nil, Extract(c)...).IsDebug() {
+		t.Fatalf("debug should carry over: %v", c)
+	}
+	if opts := Extract(MapCarrier{trace.DebugHeader: "true"}); len(opts) != 1 {
+		t.Fatalf("the debug header alone should be extracted")
+	}
+}
@@ -0,0 +1,68 @@
+// Package saramaprop propagates traces through sarama Kafka messages.
+package saramaprop
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/propagation"
+)
+
+// ProducerCarrier is a propagation.Carrier over the headers of a message
+// being produced.
+type ProducerCarrier struct {
+	Msg *sarama.ProducerMessage
+}
+
+func (c ProducerCarrier) Get(key string) string {
+	for _, h := range c.Msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c ProducerCarrier) Set(key, value string) {
+	for i, h := range c.Msg.Headers {
+		if string(h.Key) == key {
+			c.Msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.Msg.Headers = append(c.Msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+// ConsumerCarrier is a propagation.Carrier over the headers of a consumed
+// message.
+type ConsumerCarrier struct {
+	Msg *sarama.ConsumerMessage
+}
+
+func (c ConsumerCarrier) Get(key string) string {
+	for _, h := range c.Msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c ConsumerCarrier) Set(key, value string) {
+	for _, h := range c.Msg.Headers {
+		if h != nil && string(h.Key) == key {
+			h.Value = []byte(value)
+			return
+		}
+	}
+	c.Msg.Headers = append(c.Msg.Headers, &sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+// Inject writes the trace of tc to the headers of msg.
+func Inject(tc *trace.TraceContext, msg *sarama.ProducerMessage) {
+	propagation.Inject(tc, ProducerCarrier{Msg: msg})
+}
+
+// Extract returns the options continuing the trace carried by msg.
+func Extract(msg *sarama.ConsumerMessage) []trace.Option {
+	return propagation.Extract(ConsumerCarrier{Msg: msg})
+}
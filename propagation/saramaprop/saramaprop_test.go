@@ -0,0 +1,39 @@
+package saramaprop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/mucolud/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	producer := trace.NewTraceContext(context.Background(), nil, trace.WithDebug())
+	msg := &sarama.ProducerMessage{Topic: "orders"}
+	Inject(trace.NewTraceContext(context.Background(), nil), msg)
+	Inject(producer, msg)
+	if len(msg.Headers) != 3 {
+		t.Fatalf("injecting again should replace the headers: %v", msg.Headers)
+	}
+
+	// the broker hands the record headers over to the consumer
+	consumed := &sarama.ConsumerMessage{Topic: "orders"}
+	for i := range msg.Headers {
+		consumed.Headers = append(consumed.Headers, &msg.Headers[i])
+	}
+	consumer := trace.NewTraceContext(context.Background(), nil, Extract(consumed)...)
+	if consumer.TraceID() != producer.TraceID() || consumer.ParentSpanID() != producer.SpanID() || !consumer.IsDebug() {
+		t.Fatalf("consumer should continue the producer's trace: %v", msg.Headers)
+	}
+
+	c := ConsumerCarrier{Msg: &sarama.ConsumerMessage{Headers: []*sarama.RecordHeader{nil}}}
+	c.Set("x-retry", "1")
+	c.Set("x-retry", "2")
+	if len(c.Msg.Headers) != 2 || c.Get("x-retry") != "2" {
+		t.Fatalf("unexpected headers %v", c.Msg.Headers)
+	}
+	if opts := Extract(&sarama.ConsumerMessage{}); opts != nil {
+		t.Fatalf("a message without headers carries no trace")
+	}
+}
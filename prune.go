@@ -0,0 +1,63 @@
+package trace
+
+import (
+	"strings"
+	"time"
+)
+
+// PruneMode selects the child spans left out of the tree.
+type PruneMode int
+
+const (
+	// PruneEmpty leaves out spans with nothing recorded in their subtree.
+	PruneEmpty PruneMode = iota
+	// PruneNone shows every span, empty ones with their duration.
+	PruneNone
+	// PruneHealthy shows only the spans leading to an error or a slow span.
+	PruneHealthy
+)
+
+// pruned reports whether the child span s is left out of the tree.
+func (tc *TraceContext) pruned(s *spanSnapshot) bool {
+	switch tc.opts.prune {
+	case PruneNone:
+		return false
+	case PruneHealthy:
+		return !s.hasError() && !s.hasSlow()
+	}
+	return s.isEmpty()
+}
+
+// hasRecords reports whether the span itself, leaving out its children, has
+// anything to print.
+func (s *spanSnapshot) hasRecords() bool {
+	return len(s.errors) > 0 || len(s.infos) > 0 || len(s.events) > 0 || len(s.links) > 0 || len(s.attachments) > 0 || len(s.tags) > 0 ||
+		!s.peer.IsZero() || s.dependency != nil || len(s.args) > 0 || s.label != "" || s.running || s.slow || len(s.violations) > 0 || s.cause != "" || s.suppressed > 0
+}
+
+// chain follows s down through spans that only lead to a single child, with
+// WithChainCollapse. It returns the names of the spans passed through and the
+// span the chain ends in.
+func (tc *TraceContext) chain(s *spanSnapshot) (string, *spanSnapshot) {
+	if !tc.opts.chainCollapse {
+		return "", s
+	}
+	var names []string
+	for len(s.children) == 1 && !s.hasRecords() && s.formatStatus() == "" {
+		names = append(names, s.funcName)
+		s = s.children[0]
+	}
+	if len(names) == 0 {
+		return "", s
+	}
+	return strings.Join(names, " › ") + " › ", s
+}
+
+// formatDuration renders the duration of an empty span shown with
+// PruneNone, so it is still worth a line.
+func (tc *TraceContext) formatDuration(s *spanSnapshot) string {
+	if tc.opts.prune != PruneNone || !s.isEmpty() {
+		return ""
+	}
+	return " " + s.duration().Round(time.Microsecond).String()
+}
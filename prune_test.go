@@ -0,0 +1,53 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildTree records a failed span, a healthy one and an empty one that took
+// 5ms.
+func buildTree(tc *TraceContext, clock *stepClock) {
+	_ = tc.Trace().Error("failed")
+	tc.Trace().Info("healthy")
+	empty := tc.Trace()
+	clock.now = clock.now.Add(5 * time.Millisecond)
+	empty.End()
+}
+
+func TestWithPruning(t *testing.T) {
+	cases := []struct {
+		mode  PruneMode
+		spans int
+		want  string
+	}{
+		{PruneEmpty, 2, `["healthy"]`},
+		{PruneNone, 3, "buildTree 5ms\n"},
+		{PruneHealthy, 1, `["failed"]`},
+	}
+	for _, c := range cases {
+		buf := &bytes.Buffer{}
+		clock := &stepClock{now: time.Unix(0, 0)}
+		tc := NewTraceContext(context.Background(), buf, WithClock(clock), WithPruning(c.mode))
+		buildTree(tc, clock)
+		tc.Log()
+		out := buf.String()
+		if n := strings.Count(out, "\n├github.com"); n != c.spans || !strings.Contains(out, c.want) {
+			t.Errorf("mode %d: %d spans, want %d containing %q: %s", c.mode, n, c.spans, c.want, out)
+		}
+	}
+}
+
+func TestWithChainCollapse(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithChainCollapse())
+	tc.Trace().Trace().Trace().Info("deep")
+	tc.Log()
+	name := "github.com/mucolud/trace.TestWithChainCollapse"
+	if !strings.Contains(buf.String(), "\n├"+name+" › "+name+" › "+name+"\n   ├> ") {
+		t.Fatalf("single child chain should be printed on one line: %s", buf.String())
+	}
+}
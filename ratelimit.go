@@ -0,0 +1,69 @@
+package trace
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// callSite identifies the line recording a node.
+type callSite struct {
+	fn   string
+	line int
+}
+
+// siteWindow counts the nodes a call site recorded in the current window
+// of WithRateLimit.
+type siteWindow struct {
+	start      time.Time
+	count      int
+	suppressed int32 // nodes dropped since the window filled up
+}
+
+// admit reports whether n, about to be recorded, is within the rate limit
+// of its call site. When the limit is reached, n is the last node kept in
+// the window and counts the nodes suppressed after it.
+func (tc *TraceContext) admit(n *node) bool {
+	limit := tc.opts.rateLimit
+	if limit <= 0 {
+		return true
+	}
+	site := callSite{fn: n.Func, line: n.Line}
+	s := tc.state
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	w := s.windows[site]
+	if w == nil || n.Time.Sub(w.start) >= tc.opts.ratePeriod {
+		if s.windows == nil {
+			s.windows = map[callSite]*siteWindow{}
+		}
+		w = &siteWindow{start: n.Time}
+		s.windows[site] = w
+	}
+	if w.count < limit {
+		w.count++
+		if w.count == limit {
+			n.window = w
+		}
+		return true
+	}
+	atomic.AddInt32(&w.suppressed, 1)
+	return false
+}
+
+// suppressed returns the number of nodes of the same call site dropped by
+// WithRateLimit after n.
+func (n *node) suppressed() int {
+	if n.window == nil {
+		return n.Suppressed
+	}
+	return int(atomic.LoadInt32(&n.window.suppressed))
+}
+
+// formatSuppressed renders the number of nodes suppressed after n.
+func (n *node) formatSuppressed() string {
+	if n.Suppressed == 0 {
+		return ""
+	}
+	return " (" + strconv.Itoa(n.Suppressed) + " more suppressed)"
+}
@@ -0,0 +1,51 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func poll(tc *TraceContext, clock *stepClock, n int) {
+	for i := 0; i < n; i++ {
+		tc.Info("poll", i)
+		clock.now = clock.now.Add(10 * time.Millisecond)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	clock := &stepClock{now: time.Unix(1700000000, 0)}
+	tc := NewTraceContext(context.Background(), buf, WithClock(clock), WithRateLimit(3, time.Second))
+	poll(tc, clock, 150)
+	_ = tc.Error("failed")
+	tc.Log()
+
+	data := tc.Snapshot()
+	if len(data.Infos) != 6 {
+		t.Fatalf("want 3 infos in each of the 2 windows, got %d", len(data.Infos))
+	}
+	if got := data.Infos[2].Suppressed; got != 97 {
+		t.Fatalf("the last info of the first window should count 97 suppressed, got %d", got)
+	}
+	if got := data.Infos[5].Suppressed; got != 47 {
+		t.Fatalf("the last info of the second window should count 47 suppressed, got %d", got)
+	}
+	if len(data.Errors) != 1 {
+		t.Fatalf("other call sites should not be limited: %+v", data.Errors)
+	}
+	if !strings.Contains(buf.String(), `["poll",2] (97 more suppressed)`) {
+		t.Fatalf("the suppressed count should be rendered: %s", buf.String())
+	}
+
+	b, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back TraceData
+	if err := back.Unmarshal(b); err != nil || back.Infos[2].Suppressed != 97 {
+		t.Fatalf("the suppressed count should round trip: %v %+v", err, back.Infos)
+	}
+}
@@ -0,0 +1,119 @@
+package trace
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// recentTrace is a trace kept for DumpRecent with the options it is
+// formatted with.
+type recentTrace struct {
+	at   time.Time
+	snap *spanSnapshot
+	opts *options
+}
+
+// recent is the ring of the last traces logged with WithRecent.
+var recent = struct {
+	mux    sync.Mutex
+	ring   []recentTrace
+	next   int // where the next trace goes
+	maxAge time.Duration
+}{ring: make([]recentTrace, 100)}
+
+// SetRecentLimits resizes the buffer of WithRecent to the last size traces,
+// 100 by default, and with maxAge above 0 leaves out of dumps the traces
+// logged longer ago. It drops the traces kept so far.
+func SetRecentLimits(size int, maxAge time.Duration) {
+	if size < 1 {
+		size = 1
+	}
+	recent.mux.Lock()
+	defer recent.mux.Unlock()
+	recent.ring = make([]recentTrace, size)
+	recent.next = 0
+	recent.maxAge = maxAge
+}
+
+func (tc *TraceContext) keepRecent(snap *spanSnapshot) {
+	recent.mux.Lock()
+	recent.ring[recent.next] = recentTrace{at: time.Now(), snap: snap, opts: tc.opts}
+	recent.next = (recent.next + 1) % len(recent.ring)
+	recent.mux.Unlock()
+}
+
+// recentTraces returns the traces kept, oldest first.
+func recentTraces() []recentTrace {
+	recent.mux.Lock()
+	defer recent.mux.Unlock()
+	now := time.Now()
+	res := make([]recentTrace, 0, len(recent.ring))
+	for i := range recent.ring {
+		v := recent.ring[(recent.next+i)%len(recent.ring)]
+		if v.snap == nil || recent.maxAge > 0 && now.Sub(v.at) > recent.maxAge {
+			continue
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// Recent returns the last traces logged with WithRecent, oldest first.
+func Recent() []TraceData {
+	kept := recentTraces()
+	res := make([]TraceData, 0, len(kept))
+	for _, v := range kept {
+		res = append(res, v.snap.data())
+	}
+	return res
+}
+
+// DumpRecent writes the last traces logged with WithRecent to w as trees,
+// oldest first, whether or not Log wrote them.
+func DumpRecent(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, v := range recentTraces() {
+		tc := &TraceContext{opts: v.opts}
+		tc.writeTree(bw, v.snap)
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+// DumpRecentOnSignal calls DumpRecent on w every time the process receives
+// one of sigs, such as syscall.SIGUSR1, until stop is called.
+func DumpRecentOnSignal(w io.Writer, sigs ...os.Signal) (stop func()) {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				_ = DumpRecent(w)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// RecentHandler returns a debug endpoint answering with DumpRecent.
+func RecentHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_ = DumpRecent(w)
+	})
+}
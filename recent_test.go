@@ -0,0 +1,48 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRecent(t *testing.T) {
+	SetRecentLimits(2, 0)
+	defer SetRecentLimits(100, 0)
+	for _, name := range []string{"first", "second", "third"} {
+		tc := NewTraceContext(context.Background(), nil, WithRecent(), WithErrorOnly())
+		tc.Info(name)
+		tc.Log()
+		tc.Release()
+	}
+	NewTraceContext(context.Background(), nil).Log()
+
+	traces := Recent()
+	if len(traces) != 2 || traces[0].Infos[0].Data[0] != "second" || traces[1].Infos[0].Data[0] != "third" {
+		t.Fatalf("the last two traces should be kept: %+v", traces)
+	}
+	buf := &bytes.Buffer{}
+	if err := DumpRecent(buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, `"first"`) || strings.Index(out, `"second"`) > strings.Index(out, `"third"`) {
+		t.Fatalf("dump should hold the kept traces oldest first: %s", out)
+	}
+
+	rec := httptest.NewRecorder()
+	RecentHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/traces", nil))
+	if rec.Body.String() != out {
+		t.Fatalf("endpoint should serve the dump: %s", rec.Body.String())
+	}
+
+	SetRecentLimits(10, time.Nanosecond)
+	NewTraceContext(context.Background(), nil, WithRecent()).Log()
+	time.Sleep(time.Millisecond)
+	if len(Recent()) != 0 {
+		t.Fatalf("expired traces should be left out")
+	}
+}
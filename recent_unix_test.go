@@ -0,0 +1,45 @@
+//go:build unix
+
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	bytes.Buffer
+	done chan struct{}
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	defer close(b.done)
+	return b.Buffer.Write(p)
+}
+
+func TestDumpRecentOnSignal(t *testing.T) {
+	SetRecentLimits(10, 0)
+	defer SetRecentLimits(100, 0)
+	tc := NewTraceContext(context.Background(), nil, WithRecent())
+	tc.Info("dumped")
+	tc.Log()
+
+	buf := &syncBuffer{done: make(chan struct{})}
+	stop := DumpRecentOnSignal(buf, syscall.SIGUSR1)
+	defer stop()
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-buf.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal should trigger a dump")
+	}
+	if !strings.Contains(buf.String(), `"dumped"`) {
+		t.Fatalf("unexpected dump: %s", buf.String())
+	}
+}
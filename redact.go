@@ -0,0 +1,135 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redactor rewrites a recorded parameter before it is serialized, so
+// secrets never reach the log writer.
+type Redactor interface {
+	Redact(v interface{}) interface{}
+}
+
+// RedactorFunc adapts a function to the Redactor interface.
+type RedactorFunc func(v interface{}) interface{}
+
+func (f RedactorFunc) Redact(v interface{}) interface{} {
+	return f(v)
+}
+
+// KeyRedactor is a Redactor that is also given the key a value is recorded
+// under, for the key/value pairs of events, span arguments and tags.
+type KeyRedactor interface {
+	Redactor
+	RedactKey(key string, v interface{}) interface{}
+}
+
+// FieldRedactor masks map and struct fields whose name matches one of Keys
+// (case-insensitive) and any part of a string matching one of Patterns.
+type FieldRedactor struct {
+	Keys     []string
+	Patterns []*regexp.Regexp
+	Mask     string
+}
+
+// DefaultRedactor masks common credential fields and card numbers.
+var DefaultRedactor = NewRedactor(
+	[]string{"password", "passwd", "secret", "token", "access_token", "refresh_token", "authorization", "api_key", "apikey"},
+	regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+)
+
+// NewRedactor returns a FieldRedactor masking keys and patterns with "***".
+func NewRedactor(keys []string, patterns ...*regexp.Regexp) *FieldRedactor {
+	return &FieldRedactor{Keys: keys, Patterns: patterns, Mask: "***"}
+}
+
+func (r *FieldRedactor) Redact(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.redactString(val)
+	}
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return v
+	}
+	// go through json so struct tags decide the key names, exactly as they
+	// would be printed
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return v
+	}
+	return r.walk(generic)
+}
+
+// RedactKey masks v entirely if key is one of Keys, and redacts it like
+// Redact otherwise.
+func (r *FieldRedactor) RedactKey(key string, v interface{}) interface{} {
+	if r.isSecretKey(key) {
+		return r.Mask
+	}
+	return r.Redact(v)
+}
+
+// redactKey redacts the value v recorded under key with the redactor of
+// the trace, if any.
+func (tc *TraceContext) redactKey(key string, v interface{}) interface{} {
+	switch r := tc.opts.redactor.(type) {
+	case nil:
+		return v
+	case KeyRedactor:
+		return r.RedactKey(key, v)
+	default:
+		return r.Redact(v)
+	}
+}
+
+func (r *FieldRedactor) walk(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if r.isSecretKey(k) {
+				val[k] = r.Mask
+			} else {
+				val[k] = r.walk(item)
+			}
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = r.walk(item)
+		}
+		return val
+	case string:
+		return r.redactString(val)
+	}
+	return v
+}
+
+func (r *FieldRedactor) isSecretKey(key string) bool {
+	for _, k := range r.Keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *FieldRedactor) redactString(s string) string {
+	for _, p := range r.Patterns {
+		s = p.ReplaceAllString(s, r.Mask)
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type login struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+func TestWithRedactor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithRedactor(DefaultRedactor))
+	tc.Info(login{User: "bob", Password: "hunter2"})
+	tc.Info(map[string]interface{}{"Token": "xyz", "n": 1})
+	_ = tc.Error("card 4111 1111 1111 1111 declined")
+	tc.Log()
+
+	out := buf.String()
+	for _, secret := range []string{"hunter2", "xyz", "4111"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("secret %q leaked: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, `"user":"bob"`) || !strings.Contains(out, `"n":1`) {
+		t.Fatalf("non secret fields must be kept: %s", out)
+	}
+}
+
+func TestWithRedactor_tags(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithRedactor(DefaultRedactor))
+	tc.SetTag("authorization", "Bearer abc").SetTag("region", "eu")
+	tc.Log()
+
+	tags := tc.Snapshot().Tags
+	if tags["authorization"] != "***" || tags["region"] != "eu" {
+		t.Fatalf("the value of a secret tag should be masked: %v", tags)
+	}
+	if strings.Contains(buf.String(), "Bearer") {
+		t.Fatalf("secret tag printed:\n%s", buf.String())
+	}
+}
+
+func TestWithRedactor_errorTags(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithRedactor(DefaultRedactor))
+	_ = tc.ErrorTagged(errors.New("denied"), map[string]string{"token": "xyz", "owner": "payments"})
+	tc.Log()
+
+	tags := tc.Snapshot().Errors[0].Tags
+	if tags["token"] != "***" || tags["owner"] != "payments" {
+		t.Fatalf("the value of a secret error tag should be masked: %v", tags)
+	}
+	if strings.Contains(buf.String(), "xyz") {
+		t.Fatalf("secret error tag printed:\n%s", buf.String())
+	}
+}
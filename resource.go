@@ -0,0 +1,79 @@
+package trace
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Resource describes the process traces come from, so traces of many
+// services can share a sink. See WithResource.
+type Resource struct {
+	Service     string                 `json:"service,omitempty"`
+	Version     string                 `json:"version,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Host        string                 `json:"host,omitempty"`
+	PID         int                    `json:"pid,omitempty"`
+	Attrs       map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// ProcessResource returns the resource of the current process: service,
+// version and environment as given, with the host name and pid filled in.
+func ProcessResource(service, version, environment string) Resource {
+	host, _ := os.Hostname()
+	return Resource{
+		Service:     service,
+		Version:     version,
+		Environment: environment,
+		Host:        host,
+		PID:         os.Getpid(),
+	}
+}
+
+// String renders the resource as key=value pairs, leaving out unknown parts,
+// e.g. "service=api version=1.4.2 env=prod host=web-1 pid=42".
+func (r *Resource) String() string {
+	var parts []string
+	add := func(k, v string) {
+		if v != "" {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	add("service", r.Service)
+	add("version", r.Version)
+	add("env", r.Environment)
+	add("host", r.Host)
+	if r.PID > 0 {
+		add("pid", strconv.Itoa(r.PID))
+	}
+	keys := make([]string, 0, len(r.Attrs))
+	for k := range r.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(k, toString(r.Attrs[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// otlpAttrs returns the resource attributes following the OpenTelemetry
+// semantic conventions.
+func (r *Resource) otlpAttrs() []otlpKeyValue {
+	res := []otlpKeyValue{}
+	if r == nil {
+		return res
+	}
+	add := func(k string, v interface{}, ok bool) {
+		if ok {
+			res = append(res, otlpAttr(k, v))
+		}
+	}
+	add("service.name", r.Service, r.Service != "")
+	add("service.version", r.Version, r.Version != "")
+	add("deployment.environment", r.Environment, r.Environment != "")
+	add("host.name", r.Host, r.Host != "")
+	add("process.pid", r.PID, r.PID > 0)
+	return append(res, otlpAttrs(r.Attrs)...)
+}
@@ -0,0 +1,49 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWithResource(t *testing.T) {
+	res := ProcessResource("checkout", "1.4.2", "prod")
+	res.Attrs = map[string]interface{}{"region": "eu-west-1"}
+	if host, _ := os.Hostname(); res.Host != host || res.PID != os.Getpid() {
+		t.Fatalf("process not filled in: %+v", res)
+	}
+	tree, otlp := &bytes.Buffer{}, &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), MultiLogger(tree, Formatted(otlp, FormatOTLP)), WithResource(res))
+	tc.Trace().Info("child")
+	tc.Log()
+
+	header := "┌ traceId:" + tc.TraceID() + " service=checkout version=1.4.2 env=prod host=" + res.Host +
+		" pid=" + strconv.Itoa(res.PID) + " region=eu-west-1\n"
+	if !strings.Contains(tree.String(), header) {
+		t.Fatalf("resource missing from the header: %s", tree.String())
+	}
+	if e := tc.Entries(); e[0].Resource == nil || e[0].Resource.Service != "checkout" {
+		t.Fatalf("entries should carry the resource: %+v", e[0])
+	}
+	data := tc.Snapshot()
+	if data.Resource == nil || data.Children[0].Resource != nil {
+		t.Fatalf("only the root should carry the resource: %+v", data)
+	}
+	b, _ := data.Marshal()
+	var decoded TraceData
+	if err := decoded.Unmarshal(b); err != nil || decoded.Resource.String() != res.String() {
+		t.Fatalf("resource lost on the wire: %+v %v", decoded.Resource, err)
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal(otlp.Bytes(), &req); err != nil {
+		t.Fatal(err)
+	}
+	if attrs := req.ResourceSpans[0].Resource.Attributes; len(attrs) != 6 || attrs[0].Key != "service.name" {
+		t.Fatalf("unexpected OTLP resource %+v", attrs)
+	}
+}
@@ -0,0 +1,100 @@
+package trace
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures Retry. The zero value makes 3 attempts with a
+// backoff starting at 100ms and doubling after every attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts, the first included.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts, 0 for no cap.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after every attempt, 2 if 0.
+	Multiplier float64
+	// Jitter takes up to this fraction off every wait at random, so that
+	// clients failing together do not retry together.
+	Jitter float64
+	// Retryable reports whether an error is worth another attempt, nil
+	// retries every error.
+	Retryable func(err error) bool
+}
+
+// backoff returns the wait after the n-th attempt.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff)
+	if d == 0 {
+		d = float64(100 * time.Millisecond)
+	}
+	mult := p.Multiplier
+	if mult == 0 {
+		mult = 2
+	}
+	for i := 1; i < n; i++ {
+		d *= mult
+		if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d -= d * p.Jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// Retry calls fn until it succeeds, the attempts of policy are used up, fn
+// returns an error policy does not retry, or the context of tc is done.
+// Every attempt runs in its own span created with Attempt, named after the
+// caller, where the error of fn is recorded, and the backoff between them
+// grows exponentially. A "retry" event on tc records the number of attempts
+// and the outcome: ok, failed, permanent or cancelled.
+//
+// Retry returns nil on success, the last error of fn otherwise, or the
+// context's error if it was done before the first attempt.
+func Retry(tc *TraceContext, policy RetryPolicy, fn func(attempt *TraceContext) error) error {
+	max := policy.MaxAttempts
+	if max <= 0 {
+		max = 3
+	}
+	var err error
+	n := 0
+	outcome := "failed"
+	for n < max {
+		if tc.Err() != nil {
+			outcome = "cancelled"
+			break
+		}
+		n++
+		attempt := tc.attempt(2, n, nil)
+		err = attempt.endAttempt(2, fn(attempt))
+		if err == nil {
+			outcome = "ok"
+			break
+		}
+		if policy.Retryable != nil && !policy.Retryable(err) {
+			outcome = "permanent"
+			break
+		}
+		if n == max {
+			break
+		}
+		timer := time.NewTimer(policy.backoff(n))
+		select {
+		case <-timer.C:
+		case <-tc.Done():
+			timer.Stop()
+		}
+	}
+	tc.event(2, "retry", []interface{}{"attempts", n, "outcome", outcome})
+	if err == nil && outcome == "cancelled" {
+		return tc.Err()
+	}
+	return err
+}
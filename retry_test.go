@@ -0,0 +1,79 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	calls := 0
+	err := Retry(tc, RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}, func(attempt *TraceContext) error {
+		calls++
+		if calls < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("Retry should stop at the first success: %v %d", err, calls)
+	}
+	data := tc.Snapshot()
+	name := "github.com/mucolud/trace.TestRetry"
+	if len(data.Children) != 3 || data.Children[0].Func != name || len(data.Children[0].Errors) != 1 ||
+		data.Children[0].Errors[0].Func != name {
+		t.Fatalf("attempts should be spans named after the caller: %+v", data.Children)
+	}
+	if _, ok := data.Children[1].Tags[BackoffTag]; !ok {
+		t.Fatalf("backoff should be recorded: %+v", data.Children[1].Tags)
+	}
+	if ev := data.Events[0]; ev.Name != "retry" || ev.Attrs["attempts"] != 3 || ev.Attrs["outcome"] != "ok" {
+		t.Fatalf("unexpected retry event %+v", ev)
+	}
+
+	permanent := errors.New("bad request")
+	tc = NewTraceContext(context.Background(), nil)
+	err = Retry(tc, RetryPolicy{Retryable: func(err error) bool { return err != permanent }}, func(*TraceContext) error {
+		return permanent
+	})
+	if err != permanent || len(tc.Snapshot().Children) != 1 {
+		t.Fatalf("permanent errors should not be retried: %v", err)
+	}
+}
+
+func TestRetry_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tc := NewTraceContext(ctx, nil)
+	start := time.Now()
+	err := Retry(tc, RetryPolicy{InitialBackoff: time.Hour}, func(*TraceContext) error {
+		cancel()
+		return errors.New("unavailable")
+	})
+	if err == nil || time.Since(start) > time.Minute {
+		t.Fatalf("cancellation should stop the backoff: %v", err)
+	}
+	data := tc.Snapshot()
+	if len(data.Children) != 1 || data.Events[0].Attrs["outcome"] != "cancelled" {
+		t.Fatalf("unexpected trace %+v", data)
+	}
+
+	err = Retry(tc, RetryPolicy{}, func(*TraceContext) error { return nil })
+	if err != context.Canceled {
+		t.Fatalf("a done context should prevent the first attempt: %v", err)
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	for n, want := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 40 * time.Millisecond, 10: 50 * time.Millisecond} {
+		if got := p.backoff(n); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", n, got, want)
+		}
+	}
+	p.Jitter = 0.5
+	if d := p.backoff(1); d > 10*time.Millisecond || d < 5*time.Millisecond {
+		t.Fatalf("jitter out of range: %s", d)
+	}
+}
@@ -0,0 +1,79 @@
+package trace
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Validator checks the tags of a finished span.
+type Validator interface {
+	Validate(tags map[string]interface{}) error
+}
+
+// Schema is a Validator requiring tags to be present and of a given kind.
+type Schema struct {
+	Required []string
+	Types    map[string]reflect.Kind
+}
+
+func (s Schema) Validate(tags map[string]interface{}) error {
+	var problems []string
+	for _, k := range s.Required {
+		if _, ok := tags[k]; !ok {
+			problems = append(problems, fmt.Sprintf("missing tag %q", k))
+		}
+	}
+	keys := make([]string, 0, len(s.Types))
+	for k := range s.Types {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, ok := tags[k]
+		if !ok || v == nil {
+			continue
+		}
+		if kind := reflect.TypeOf(v).Kind(); kind != s.Types[k] {
+			problems = append(problems, fmt.Sprintf("tag %q is %s, want %s", k, kind, s.Types[k]))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+type schemaRule struct {
+	pattern   string
+	validator Validator
+}
+
+// schemaViolations returns the problems found in the tags of node.
+func (tc *TraceContext) schemaViolations(node *TraceContext) []string {
+	if !tc.opts.development || len(tc.opts.schemas) == 0 {
+		return nil
+	}
+	name := node.funcName
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	node.mux.Lock()
+	tags := make(map[string]interface{}, len(node.tags))
+	for k, v := range node.tags {
+		tags[k] = v
+	}
+	node.mux.Unlock()
+	var res []string
+	for _, rule := range tc.opts.schemas {
+		if ok, _ := path.Match(rule.pattern, name); !ok {
+			continue
+		}
+		if err := rule.validator.Validate(tags); err != nil {
+			res = append(res, err.Error())
+		}
+	}
+	return res
+}
@@ -0,0 +1,35 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithSchema(t *testing.T) {
+	schema := Schema{
+		Required: []string{"user_id"},
+		Types:    map[string]reflect.Kind{"attempt": reflect.Int},
+	}
+
+	run := func(opts ...Option) string {
+		buf := &bytes.Buffer{}
+		tc := NewTraceContext(context.Background(), buf, opts...)
+		tc.Trace().SetTag("attempt", "1")
+		tc.Log()
+		return buf.String()
+	}
+
+	out := run(WithSchema("trace.TestWithSchema*", schema), WithDevelopment())
+	if !strings.Contains(out, `schema: missing tag "user_id"; tag "attempt" is string, want int`) {
+		t.Fatalf("violation not flagged: %s", out)
+	}
+	if out := run(WithSchema("trace.TestWithSchema*", schema)); strings.Contains(out, "schema:") {
+		t.Fatalf("schemas must only be checked in development mode: %s", out)
+	}
+	if out := run(WithSchema("other.*", schema), WithDevelopment()); strings.Contains(out, "schema:") {
+		t.Fatalf("schema must only apply to matching spans: %s", out)
+	}
+}
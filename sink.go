@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"errors"
+	"io"
+)
+
+// Sink receives traces as TraceData rather than formatted text, for
+// destinations that need their structure, such as a database or a message
+// queue. Use SinkLogger to log to it.
+type Sink interface {
+	WriteTrace(t TraceData) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(t TraceData) error
+
+func (f SinkFunc) WriteTrace(t TraceData) error {
+	return f(t)
+}
+
+// errSinkWrite is returned by the Write of a SinkLogger, which only takes
+// traces from Log.
+var errSinkWrite = errors.New("trace: a Sink only takes traces from Log")
+
+// sinkLogger is the logger handing traces to a Sink.
+type sinkLogger struct {
+	sink Sink
+}
+
+// SinkLogger returns a logger handing every trace Log writes to s, to pass
+// to NewTraceContext or MultiLogger like any writer. The trace is not
+// formatted for s, nor subject to WithWriteTimeout. Writing to the logger
+// directly fails.
+func SinkLogger(s Sink) io.Writer {
+	return &sinkLogger{sink: s}
+}
+
+func (l *sinkLogger) Write(p []byte) (int, error) {
+	return 0, errSinkWrite
+}
+
+// WriterSink adapts w to the Sink interface: it writes every trace to w the
+// way Log would, in the format of each of its sinks, see WriteTrace. opts
+// configure the formatting.
+func WriterSink(w io.Writer, opts ...Option) Sink {
+	return SinkFunc(func(t TraceData) error {
+		return WriteTrace(w, &t, opts...)
+	})
+}
@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSinkLogger(t *testing.T) {
+	var got []TraceData
+	store := SinkFunc(func(t TraceData) error {
+		got = append(got, t)
+		return nil
+	})
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), MultiLogger(SinkLogger(store), buf))
+	load(tc)
+	tc.Log()
+
+	if len(got) != 1 || got[0].TraceId != tc.TraceID() || len(got[0].Children) != 1 || len(got[0].Children[0].Infos) != 1 {
+		t.Fatalf("the sink should get the trace as is: %+v", got)
+	}
+	if !strings.Contains(buf.String(), "trace.load") {
+		t.Fatalf("the other sinks should get the trace too: %s", buf.String())
+	}
+
+	var failure error
+	failed := errors.New("db down")
+	tc = NewTraceContext(context.Background(), SinkLogger(SinkFunc(func(TraceData) error { return failed })),
+		WithWriteErrorHandler(func(err error) { failure = err }))
+	tc.Log()
+	if failure != failed {
+		t.Fatalf("the error of the sink should be reported, got %v", failure)
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := WriterSink(Formatted(buf, FormatLogfmt))
+	tc := NewTraceContext(context.Background(), nil)
+	load(tc)
+	if err := s.WriteTrace(tc.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "span=github.com/mucolud/trace.load level=info") {
+		t.Fatalf("the trace should be formatted for the writer: %s", buf.String())
+	}
+}
@@ -0,0 +1,80 @@
+package trace
+
+import (
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+)
+
+// sizeLimits caps the rendered params of one snapshot, see WithMaxNodeBytes
+// and WithMaxTraceBytes. A nil *sizeLimits caps nothing.
+type sizeLimits struct {
+	node  int // per node, 0 for no limit
+	trace int // left for the rest of the trace, -1 for no limit
+}
+
+func (tc *TraceContext) newSizeLimits() *sizeLimits {
+	if tc.opts.maxNodeBytes <= 0 && tc.opts.maxTraceBytes <= 0 {
+		return nil
+	}
+	l := &sizeLimits{node: tc.opts.maxNodeBytes, trace: -1}
+	if tc.opts.maxTraceBytes > 0 {
+		l.trace = tc.opts.maxTraceBytes
+	}
+	return l
+}
+
+// apply truncates the rendered params of one node in place, every step-th
+// one starting at from, so that they fit both limits. Values that do not fit
+// are cut to what is left and marked with the number of bytes dropped.
+func (l *sizeLimits) apply(params []interface{}, from, step int) {
+	if l == nil {
+		return
+	}
+	node := -1
+	if l.node > 0 {
+		node = l.node
+	}
+	for i := from; i < len(params); i += step {
+		limit := node
+		if limit < 0 || (l.trace >= 0 && l.trace < limit) {
+			limit = l.trace
+		}
+		v, size := capValue(params[i], limit)
+		params[i] = v
+		if node >= 0 {
+			node -= size
+		}
+		if l.trace >= 0 {
+			l.trace -= size
+		}
+	}
+}
+
+// capValue returns v, or its text cut to limit bytes, and the size it takes
+// against the limits. A negative limit keeps v as is.
+func capValue(v interface{}, limit int) (interface{}, int) {
+	var text string
+	switch val := v.(type) {
+	case string:
+		text = val
+	case []byte:
+		text = string(val)
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		text = toString(val)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return v, 0
+		}
+		text = string(b)
+	}
+	if limit < 0 || len(text) <= limit {
+		return v, len(text)
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…(+" + strconv.Itoa(len(text)-cut) + " bytes)", cut
+}
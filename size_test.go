@@ -0,0 +1,45 @@
+package trace
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWithMaxNodeBytes(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil, WithMaxNodeBytes(10))
+	tc.Info("short", 42)
+	tc.Info(strings.Repeat("a", 25), "dropped")
+	tc.Info("héééé", struct{ Name string }{"ann"})
+	tc.Event("upload", "body", []byte("0123456789abc"))
+
+	data := tc.Snapshot()
+	if got := data.Infos[0].Data; got[0] != "short" || got[1] != 42 {
+		t.Fatalf("params within the cap must be kept: %v", got)
+	}
+	if got := data.Infos[1].Data; got[0] != "aaaaaaaaaa…(+15 bytes)" || got[1] != "…(+7 bytes)" {
+		t.Fatalf("params past the cap should be cut: %v", got)
+	}
+	// "héééé" takes 9 bytes, the cut must not split a rune
+	if got := data.Infos[2].Data; got[0] != "héééé" || got[1] != "{…(+13 bytes)" {
+		t.Fatalf("structs should be cut as JSON: %v", got)
+	}
+	if got := data.Events[0].Attrs["body"]; got != "0123456789…(+3 bytes)" {
+		t.Fatalf("event values should be capped: %v", got)
+	}
+}
+
+func TestWithMaxTraceBytes(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil, WithMaxTraceBytes(12))
+	tc.Info("first info")
+	_ = tc.Error("the error")
+	tc.Trace().Info("child")
+
+	data := tc.Snapshot()
+	if data.Errors[0].Data[0] != "the error" || data.Infos[0].Data[0] != "fir…(+7 bytes)" {
+		t.Fatalf("errors should be kept before infos: %v %v", data.Errors[0].Data, data.Infos[0].Data)
+	}
+	if got := data.Children[0].Infos[0].Data[0]; got != "…(+5 bytes)" {
+		t.Fatalf("nodes past the budget should lose their params: %v", got)
+	}
+}
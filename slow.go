@@ -0,0 +1,41 @@
+package trace
+
+import "time"
+
+// WithSpanSlowThreshold flags the span as slow when it takes longer than d,
+// overriding WithSlowThreshold for this span alone.
+func WithSpanSlowThreshold(d time.Duration) SpanOption {
+	return func(tc *TraceContext) {
+		tc.slowThreshold = d
+	}
+}
+
+// isSlow reports whether a span of duration d exceeds its threshold, the one
+// of the span if set or else the one of the trace.
+func (tc *TraceContext) isSlow(span *TraceContext, d time.Duration) bool {
+	threshold := span.slowThreshold
+	if threshold == 0 {
+		threshold = tc.opts.slowThreshold
+	}
+	return threshold > 0 && d > threshold
+}
+
+func (s *spanSnapshot) hasSlow() bool {
+	if s.slow {
+		return true
+	}
+	for _, v := range s.children {
+		if v.hasSlow() {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSlow renders the slow marker in the header of a span.
+func (s *spanSnapshot) formatSlow() string {
+	if !s.slow {
+		return ""
+	}
+	return " " + withColor(colorYellow, "[slow "+s.duration().Round(time.Millisecond).String()+"]")
+}
@@ -0,0 +1,44 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSlowThreshold(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithClock(clock), WithSlowThreshold(100*time.Millisecond),
+		WithErrorOnly(), WithLogSlow())
+	fast := tc.Trace()
+	clock.now = clock.now.Add(50 * time.Millisecond)
+	fast.End()
+	slow := tc.Trace()
+	clock.now = clock.now.Add(150 * time.Millisecond)
+	slow.End()
+	strict := tc.Trace(WithSpanSlowThreshold(10 * time.Millisecond))
+	clock.now = clock.now.Add(20 * time.Millisecond)
+	strict.End()
+	tc.End()
+	tc.Log()
+
+	out := buf.String()
+	if strings.Count(out, "[slow ") != 3 || !strings.Contains(out, "[slow 150ms]") || !strings.Contains(out, "[slow 20ms]") {
+		t.Fatalf("root, slow and strict spans should be flagged: %s", out)
+	}
+	data := tc.snapshot().data()
+	if !data.Slow || data.Children[0].Slow || !data.Children[1].Slow || !data.Children[2].Slow {
+		t.Fatalf("unexpected slow flags %+v", data)
+	}
+
+	buf.Reset()
+	quick := NewTraceContext(context.Background(), buf, WithClock(clock), WithSlowThreshold(time.Second), WithErrorOnly(), WithLogSlow())
+	quick.Trace().Info("fine")
+	quick.Log()
+	if buf.Len() != 0 {
+		t.Fatalf("fast clean trace must not be logged: %s", buf.String())
+	}
+}
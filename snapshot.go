@@ -0,0 +1,178 @@
+package trace
+
+import "time"
+
+// spanSnapshot is a copy of a span taken under its lock, with params
+// already rendered. Every output of Log is produced from the same snapshot,
+// so they never disagree because of records added while formatting.
+type spanSnapshot struct {
+	traceId    int64
+	spanId     int64
+	parentId   int64
+	funcName   string
+	peer       Peer
+	dependency *Dependency
+	kind       SpanKind
+	args       []Arg
+	label      string
+	tags       map[string]interface{}
+	start      time.Time
+	end        time.Time
+	running    bool
+	slow       bool // took longer than its slow threshold
+	budget     time.Duration
+	cause      string // why the span's context was cancelled
+	suppressed int
+	status     Status
+	infos      []*node
+	errors     []*node
+	events     []*node // Data holds a single attribute map
+	links      []*node // Name is the linked trace id, Data as for events
+	violations []string
+	children   []*spanSnapshot
+	resource   *Resource // root only
+
+	attachments []Attachment
+
+	warned bool // failed by WithWarnThreshold
+}
+
+func (tc *TraceContext) snapshot() *spanSnapshot {
+	snap := tc.snapshotSpan(tc, nil, tc.newSizeLimits())
+	snap.resource = tc.opts.resource
+	return snap
+}
+
+func (tc *TraceContext) snapshotSpan(span *TraceContext, parentCause error, limits *sizeLimits) *spanSnapshot {
+	span.mux.Lock()
+	snap := &spanSnapshot{
+		traceId:    span.traceId,
+		spanId:     span.spanId,
+		parentId:   span.parentId,
+		funcName:   tc.funcNameOf(span.funcName),
+		peer:       span.peer,
+		dependency: span.dependency,
+		kind:       span.kind,
+		label:      span.label,
+		suppressed: span.suppressed,
+		budget:     span.budget,
+		start:      span.start,
+		end:        span.end,
+	}
+	if len(span.tags) > 0 {
+		snap.tags = make(map[string]interface{}, len(span.tags))
+		for k, v := range span.tags {
+			snap.tags[k] = tc.redactKey(k, v)
+		}
+	}
+	infos, errors, events, links, children := span.infos, span.errors, span.events, span.links, span.children
+	args, attachments := span.args, span.attachments
+	explicit := span.status
+	snap.warned = span.warned()
+	span.mux.Unlock()
+
+	snap.running = span.isRunning()
+	cause := spanCause(span)
+	snap.status = inferStatus(explicit, len(errors) > 0 || snap.warned, cause)
+	// only the span where the cancellation shows up first renders it
+	if cause != nil && cause != parentCause {
+		snap.cause = cause.Error()
+	}
+	// errors first, they should be the last to be cut
+	snap.errors = tc.renderNodes(errors, limits)
+	snap.infos = tc.renderNodes(infos, limits)
+	snap.args = tc.renderArgs(args, limits)
+	snap.attachments = attachments
+	snap.events = make([]*node, 0, len(events))
+	for _, v := range events {
+		n := *v
+		n.Func = tc.funcNameOf(v.Func)
+		n.Data = []interface{}{tc.renderAttrs(v.Data, limits)}
+		snap.events = append(snap.events, &n)
+	}
+	for _, v := range links {
+		n := *v
+		n.Data = []interface{}{tc.renderAttrs(v.Data, limits)}
+		snap.links = append(snap.links, &n)
+	}
+	snap.violations = tc.schemaViolations(span)
+	snap.children = make([]*spanSnapshot, 0, len(children))
+	for _, v := range children {
+		snap.children = append(snap.children, tc.snapshotSpan(v, cause, limits))
+	}
+	if snap.end.IsZero() {
+		snap.end = snap.lastActivity()
+	}
+	snap.slow = tc.isSlow(span, snap.duration())
+	return snap
+}
+
+// lastActivity is the time of the latest record in the span or the end of
+// its latest child.
+func (s *spanSnapshot) lastActivity() time.Time {
+	last := s.start
+	for _, nodes := range [][]*node{s.infos, s.errors, s.events} {
+		for _, v := range nodes {
+			if v.Time.After(last) {
+				last = v.Time
+			}
+		}
+	}
+	for _, v := range s.children {
+		if v.end.After(last) {
+			last = v.end
+		}
+	}
+	return last
+}
+
+func (s *spanSnapshot) duration() time.Duration {
+	return s.end.Sub(s.start)
+}
+
+// spanCount returns the number of spans in the subtree.
+func (s *spanSnapshot) spanCount() int {
+	n := 1
+	for _, v := range s.children {
+		n += v.spanCount()
+	}
+	return n
+}
+
+func (tc *TraceContext) renderNodes(nodes []*node, limits *sizeLimits) []*node {
+	res := make([]*node, 0, len(nodes))
+	for _, v := range nodes {
+		n := *v
+		n.Suppressed, n.window = v.suppressed(), nil
+		n.Func = tc.funcNameOf(v.Func)
+		n.Data = tc.renderParams(v.Data)
+		limits.apply(n.Data, 0, 1)
+		n.Tags = tc.redactTags(v.Tags)
+		res = append(res, &n)
+	}
+	return res
+}
+
+// isEmpty reports whether the span has nothing worth printing.
+func (s *spanSnapshot) isEmpty() bool {
+	return len(s.children) == 0 && !s.hasRecords()
+}
+
+func (s *spanSnapshot) hasError() bool {
+	if len(s.errors) > 0 || s.warned {
+		return true
+	}
+	for _, v := range s.children {
+		if v.hasError() {
+			return true
+		}
+	}
+	return false
+}
+
+// collapsible reports whether the subtree is healthy enough to be rendered
+// as a single line.
+func (tc *TraceContext) collapsible(s *spanSnapshot) bool {
+	return tc.opts.collapse && !s.hasError() && !s.hasSlow() && s.duration() < tc.opts.collapseThreshold &&
+		!s.running && len(s.violations) == 0 && s.cause == ""
+}
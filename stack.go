@@ -0,0 +1,62 @@
+package trace
+
+import (
+	"runtime"
+	"strconv"
+)
+
+// captureStack returns at most depth frames, starting skip frames above the
+// caller of captureStack (counted like runtime.Caller).
+func captureStack(skip, depth int) []string {
+	// skip logical frames rather than pcs, inlined calls share a pc
+	skip++
+	pcs := make([]uintptr, skip+depth+1)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+	res := make([]runtime.Frame, 0, depth)
+	for len(res) < depth {
+		f, more := frames.Next()
+		if skip > 0 {
+			skip--
+		} else {
+			res = append(res, f)
+		}
+		if !more {
+			break
+		}
+	}
+	return formatFrames(res)
+}
+
+func formatFrames(frames []runtime.Frame) []string {
+	if len(frames) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(frames))
+	for _, f := range frames {
+		res = append(res, f.Function+" ("+f.File+":"+strconv.Itoa(f.Line)+")")
+	}
+	return res
+}
+
+// caller returns the function and line skip frames above the caller of
+// caller, counted like runtime.Caller, without allocating.
+func caller(skip int) (string, int) {
+	funcName, _, line := callerFile(skip + 1)
+	return funcName, line
+}
+
+// callerFile is caller also returning the file.
+func callerFile(skip int) (string, string, int) {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return "", "", 0
+	}
+	// pcs hold return addresses, look up the call instruction
+	pc := pcs[0] - 1
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "", "", 0
+	}
+	file, line := fn.FileLine(pc)
+	return fn.Name(), file, line
+}
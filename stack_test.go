@@ -0,0 +1,79 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func deepError(tc *TraceContext) error {
+	return tc.Error("deep")
+}
+
+func TestWithStackTrace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithStackTrace(2))
+	_ = deepError(tc)
+	tc.Log()
+
+	stack := tc.Entries()[0].Stack
+	if len(stack) != 2 || !strings.HasPrefix(stack[0], "github.com/mucolud/trace.deepError (") ||
+		!strings.HasPrefix(stack[1], "github.com/mucolud/trace.TestWithStackTrace (") {
+		t.Fatalf("unexpected stack %q", stack)
+	}
+	if !strings.Contains(buf.String(), `["deep"]`+"\x1b[0m\n│     at github.com/mucolud/trace.deepError (") {
+		t.Fatalf("stack not printed beneath the error: %s", buf.String())
+	}
+}
+
+func TestWithStackTracePanic(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil, WithStackTrace(3), WithWaitGoroutines(time.Second))
+	tc.Go(func(child *TraceContext) {
+		var p *profile
+		_ = p.Name
+	})
+	tc.state.wait(time.Second)
+	e := tc.Entries()[0]
+	if len(e.Stack) != 3 || !strings.HasPrefix(e.Stack[0], "github.com/mucolud/trace.TestWithStackTracePanic.func1 (") {
+		t.Fatalf("panic stack should start at the panicking function: %q", e.Stack)
+	}
+}
+
+func checkError(tc *TraceContext, err error) error {
+	return tc.ErrorSkip(1, err)
+}
+
+// wrapped stands for a logging layer every call of a trace goes through.
+type wrapped struct{ tc *TraceContext }
+
+func (w wrapped) info(params ...interface{}) { w.tc.Info(params...) }
+
+func (w wrapped) trace() wrapped { return wrapped{w.tc.Trace()} }
+
+func TestTraceContext_ErrorSkip(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil, WithStackTrace(1))
+	_ = checkError(tc, errors.New("boom"))
+	e := tc.Entries()[0]
+	if e.Func != "github.com/mucolud/trace.TestTraceContext_ErrorSkip" ||
+		!strings.HasPrefix(e.Stack[0], "github.com/mucolud/trace.TestTraceContext_ErrorSkip (") {
+		t.Fatalf("error should be attributed to the caller of the helper: %+v", e)
+	}
+	var terr *TracedError
+	if err := checkError(tc, errors.New("boom")); !errors.As(err, &terr) || terr.Func != e.Func {
+		t.Fatalf("unexpected error location %+v", terr)
+	}
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	w := wrapped{NewTraceContext(context.Background(), nil, WithCallerSkip(1))}
+	w.info("outer")
+	w.trace().info("inner")
+	data := w.tc.Snapshot()
+	name := "github.com/mucolud/trace.TestWithCallerSkip"
+	if data.Infos[0].Func != name || data.Children[0].Func != name || data.Children[0].Infos[0].Func != name {
+		t.Fatalf("records should be attributed above the wrapper: %+v", data)
+	}
+}
@@ -0,0 +1,31 @@
+package trace
+
+import "sync/atomic"
+
+// Stats are process wide performance counters of the package.
+type Stats struct {
+	Traces      uint64 // root traces created
+	Spans       uint64 // child spans created
+	Nodes       uint64 // info, error and event nodes recorded
+	Logged      uint64 // traces written by Log
+	BytesLogged uint64 // bytes handed to the writers by Log
+	FormatNanos uint64 // time spent snapshotting and formatting in Log
+	Dropped     uint64 // traces Log dropped because the queue of an AsyncWriter was full
+	LateSpans   uint64 // spans created from a trace after it was logged, see WithLateSpans
+}
+
+var stats Stats
+
+// ReadStats returns the current value of the performance counters.
+func ReadStats() Stats {
+	return Stats{
+		Traces:      atomic.LoadUint64(&stats.Traces),
+		Spans:       atomic.LoadUint64(&stats.Spans),
+		Nodes:       atomic.LoadUint64(&stats.Nodes),
+		Logged:      atomic.LoadUint64(&stats.Logged),
+		BytesLogged: atomic.LoadUint64(&stats.BytesLogged),
+		FormatNanos: atomic.LoadUint64(&stats.FormatNanos),
+		Dropped:     atomic.LoadUint64(&stats.Dropped),
+		LateSpans:   atomic.LoadUint64(&stats.LateSpans),
+	}
+}
@@ -0,0 +1,22 @@
+package trace
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+)
+
+func TestReadStats(t *testing.T) {
+	before := ReadStats()
+	tc := NewTraceContext(context.Background(), ioutil.Discard)
+	tc.Info("a")
+	tc.Trace().Event("b")
+	tc.Log()
+	after := ReadStats()
+
+	if after.Traces-before.Traces < 1 || after.Spans-before.Spans < 1 ||
+		after.Nodes-before.Nodes < 2 || after.Logged-before.Logged < 1 ||
+		after.BytesLogged <= before.BytesLogged {
+		t.Fatalf("counters not updated: %+v -> %+v", before, after)
+	}
+}
@@ -0,0 +1,91 @@
+package trace
+
+import "fmt"
+
+// Status is the outcome of a span.
+type Status int
+
+const (
+	// StatusUnset leaves the status to be inferred.
+	StatusUnset Status = iota
+	StatusOK
+	StatusError
+	StatusCancelled
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusUnset:
+		return "unset"
+	case StatusOK:
+		return "ok"
+	case StatusError:
+		return "error"
+	case StatusCancelled:
+		return "cancelled"
+	}
+	return "unknown"
+}
+
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Status) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "unset":
+		*s = StatusUnset
+	case "ok":
+		*s = StatusOK
+	case "error":
+		*s = StatusError
+	case "cancelled":
+		*s = StatusCancelled
+	default:
+		return fmt.Errorf("trace: unknown status %q", text)
+	}
+	return nil
+}
+
+// SetStatus sets the status of the span explicitly, overriding the inferred
+// one. StatusUnset goes back to inferring it.
+func (tc *TraceContext) SetStatus(s Status) {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.status = s
+}
+
+// Status returns the status set with SetStatus or else the inferred one:
+// StatusError if the span recorded an error, StatusCancelled if its context
+// was done when it ended, or is done if it did not end, StatusOK otherwise.
+func (tc *TraceContext) Status() Status {
+	tc.mux.Lock()
+	explicit, failed := tc.status, len(tc.errors) > 0 || tc.warned()
+	tc.mux.Unlock()
+	return inferStatus(explicit, failed, spanCause(tc))
+}
+
+func inferStatus(explicit Status, failed bool, ctxErr error) Status {
+	switch {
+	case explicit != StatusUnset:
+		return explicit
+	case failed:
+		return StatusError
+	case ctxErr != nil:
+		return StatusCancelled
+	}
+	return StatusOK
+}
+
+// formatStatus renders the status in the header of a span, if it is worth
+// pointing out.
+func (s *spanSnapshot) formatStatus() string {
+	switch {
+	case s.status == StatusError:
+		return " [error]"
+	case s.status == StatusCancelled && s.cause == "":
+		// the cause says it already
+		return " [cancelled]"
+	}
+	return ""
+}
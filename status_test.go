@@ -0,0 +1,45 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(ctx, buf)
+	failed := tc.Trace()
+	_ = failed.Error("boom")
+	overridden := tc.Trace()
+	_ = overridden.Error("expected miss")
+	overridden.SetStatus(StatusOK)
+	ok := tc.Trace()
+	ok.Info("fine")
+
+	if tc.Status() != StatusOK || failed.Status() != StatusError || overridden.Status() != StatusOK {
+		t.Fatalf("unexpected statuses %s %s %s", tc.Status(), failed.Status(), overridden.Status())
+	}
+	cancel()
+	if ok.Status() != StatusCancelled {
+		t.Fatalf("cancelled context should give a cancelled status, got %s", ok.Status())
+	}
+	tc.Log()
+	out := buf.String()
+	if strings.Count(out, " [error]\n") != 1 || !strings.Contains(out, " (cancelled: context canceled)\n") ||
+		strings.Count(out, " [cancelled]\n") != 1 {
+		t.Fatalf("unexpected headers: %s", out)
+	}
+
+	b, _ := json.Marshal(tc.snapshot().data().Children[0])
+	if !strings.Contains(string(b), `"status":"error"`) {
+		t.Fatalf("status not exported: %s", b)
+	}
+	var s Status
+	if err := s.UnmarshalText([]byte("cancelled")); err != nil || s != StatusCancelled {
+		t.Fatalf("unexpected %v %v", s, err)
+	}
+}
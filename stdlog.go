@@ -0,0 +1,43 @@
+package trace
+
+import (
+	"log"
+	"runtime"
+	"strings"
+)
+
+// StdLogger returns a *log.Logger recording every line written to it as an
+// info node of tc, attributed to the function calling the logger rather
+// than to the log package. Hand it to libraries that accept a *log.Logger,
+// such as http.Server.ErrorLog, to get their output in the trace. It has no
+// flags: nodes are timed already.
+func (tc *TraceContext) StdLogger() *log.Logger {
+	return log.New(stdWriter{tc}, "", 0)
+}
+
+// stdWriter records the lines of a *log.Logger as info nodes.
+type stdWriter struct {
+	tc *TraceContext
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	w.tc.info(2+logDepth(), []interface{}{msg})
+	return len(p), nil
+}
+
+// logDepth returns the number of frames of the log package calling the
+// caller of logDepth.
+func logDepth() int {
+	var pcs [8]uintptr
+	n := runtime.Callers(3, pcs[:])
+	depth := 0
+	for _, pc := range pcs[:n] {
+		fn := runtime.FuncForPC(pc - 1)
+		if fn == nil || !strings.HasPrefix(fn.Name(), "log.") {
+			break
+		}
+		depth++
+	}
+	return depth
+}
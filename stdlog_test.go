@@ -0,0 +1,31 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+)
+
+func logFromLibrary(logger *log.Logger) {
+	logger.Printf("retrying %s", "/users")
+}
+
+func TestTraceContext_StdLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	logFromLibrary(tc.StdLogger())
+	tc.StdLogger().Println("done")
+	tc.Log()
+
+	data := tc.Snapshot()
+	if len(data.Infos) != 2 {
+		t.Fatalf("want 2 infos, got %+v", data.Infos)
+	}
+	if n := data.Infos[0]; n.Func != "github.com/mucolud/trace.logFromLibrary" || n.Data[0] != "retrying /users" {
+		t.Fatalf("the line should be attributed to the caller of the logger: %+v", n)
+	}
+	if n := data.Infos[1]; n.Func != "github.com/mucolud/trace.TestTraceContext_StdLogger" || n.Data[0] != "done" {
+		t.Fatalf("unexpected info %+v", n)
+	}
+}
@@ -0,0 +1,109 @@
+package trace
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// InfoStruct records the fields of the struct v, or of the struct v points
+// to, as an info of name/value pairs in the order of the fields. Like
+// encoding/json it takes the exported fields and flattens embedded structs;
+// the tag `trace:"name"` renames a field and `trace:"-"` leaves it out, for
+// fields that must never reach a log:
+//
+//	type User struct {
+//		ID       int    `trace:"id"`
+//		Email    string `trace:"email"`
+//		Password string `trace:"-"`
+//	}
+//
+// Any other v is recorded like Info(v).
+func (tc *TraceContext) InfoStruct(v interface{}) {
+	tc.info(2, structParams(v))
+}
+
+// structField is a field InfoStruct records.
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFields caches the fields of the struct types given to InfoStruct.
+var structFields sync.Map // reflect.Type -> []structField
+
+// structParams returns the name/value pairs of the fields of v, or v alone
+// if it is not a struct.
+func structParams(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return []interface{}{v}
+	}
+	fields := fieldsOf(rv.Type())
+	res := make([]interface{}, 0, 2*len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		res = append(res, f.name, fv.Interface())
+	}
+	return res
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex reporting false instead of
+// panicking on a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func fieldsOf(t reflect.Type) []structField {
+	if res, ok := structFields.Load(t); ok {
+		return res.([]structField)
+	}
+	res := appendFields(nil, t, nil)
+	structFields.Store(t, res)
+	return res
+}
+
+// appendFields appends the recorded fields of the struct type t, found at
+// index below the value InfoStruct got.
+func appendFields(res []structField, t reflect.Type, index []int) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("trace")
+		if tag == "-" {
+			continue
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && !tagged && ft.Kind() == reflect.Struct {
+			res = appendFields(res, ft, fieldIndex)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag != "" {
+			name = strings.TrimSpace(tag)
+		}
+		res = append(res, structField{name: name, index: fieldIndex})
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package trace
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type auditInfo struct {
+	CreatedBy string `trace:"created_by"`
+}
+
+type account struct {
+	*auditInfo
+	ID       int    `trace:"id"`
+	Email    string `trace:"email"`
+	Password string `trace:"-"`
+	Plan     string
+	internal int
+}
+
+func TestTraceContext_InfoStruct(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	tc.InfoStruct(&account{auditInfo: &auditInfo{CreatedBy: "admin"}, ID: 7, Email: "bob@example.com", Password: "hunter2", Plan: "pro", internal: 1})
+	tc.InfoStruct(account{ID: 8})
+	tc.InfoStruct("not a struct")
+
+	infos := tc.Snapshot().Infos
+	want := []interface{}{"created_by", "admin", "id", 7, "email", "bob@example.com", "Plan", "pro"}
+	if got := infos[0].Data; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected data %#v, want %#v", got, want)
+	}
+	// a nil embedded pointer leaves its fields out
+	want = []interface{}{"id", 8, "email", "", "Plan", ""}
+	if got := infos[1].Data; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected data %#v, want %#v", got, want)
+	}
+	if got := infos[2].Data; len(got) != 1 || got[0] != "not a struct" {
+		t.Fatalf("unexpected data %#v", got)
+	}
+}
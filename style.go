@@ -0,0 +1,41 @@
+package trace
+
+const colorMagenta = 35
+
+// Style is how the nodes of one level are drawn: the ANSI color of the line,
+// 0 for none, and the glyph following the tree branch.
+type Style struct {
+	Color int
+	Glyph string
+}
+
+// Palette maps levels to the style of their nodes.
+type Palette map[Level]Style
+
+// DefaultPalette leaves info nodes uncolored and makes warnings, errors and
+// panics stand out.
+var DefaultPalette = Palette{
+	LevelInfo:  {Glyph: ">"},
+	LevelWarn:  {Color: colorYellow, Glyph: "W"},
+	LevelError: {Color: colorRed, Glyph: "E"},
+	LevelPanic: {Color: colorMagenta, Glyph: "P"},
+}
+
+// style returns the style of level, falling back to DefaultPalette for the
+// levels p does not set.
+func (p Palette) style(level Level) Style {
+	if s, ok := p[level]; ok {
+		return s
+	}
+	return DefaultPalette[level]
+}
+
+// paint draws line, which must not contain the trailing newline, after
+// branch in the style of level.
+func (p Palette) paint(level Level, branch, line string) string {
+	s := p.style(level)
+	if s.Color == 0 {
+		return branch + s.Glyph + " " + line
+	}
+	return withColor(s.Color, branch+s.Glyph+" "+line)
+}
@@ -0,0 +1,42 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPalette(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	tc.Info("fine")
+	tc.Log()
+	if out := buf.String(); !strings.HasPrefix(out, "\x1b[33m") || strings.Contains(out, "\x1b[31m") {
+		t.Fatalf("healthy trace should have a yellow header and no red: %q", out)
+	}
+
+	buf.Reset()
+	tc = NewTraceContext(context.Background(), buf, WithPalette(Palette{LevelError: {Glyph: "✗"}}))
+	tc.Info("fine")
+	tc.Error(errors.New("broken"))
+	tc.Go(func(child *TraceContext) {
+		panic("boom")
+	})
+	tc.state.wait(0)
+	tc.Log()
+	out := buf.String()
+	if !strings.HasPrefix(out, "\x1b[31m") || !strings.HasSuffix(out, "\x1b[31m└ traceId:"+tc.TraceID()+"\x1b[0m") {
+		t.Fatalf("failed trace should have a red header and footer: %q", out)
+	}
+	if !strings.Contains(out, "\n├> github.com/mucolud/trace.TestPalette:") {
+		t.Fatalf("info node should stay uncolored: %q", out)
+	}
+	if !strings.Contains(out, "\n├✗ github.com/mucolud/trace.TestPalette:") {
+		t.Fatalf("error node should use the configured glyph without color: %q", out)
+	}
+	if !strings.Contains(out, "\x1b[35m├P ") {
+		t.Fatalf("panic node should keep its default style: %q", out)
+	}
+}
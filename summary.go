@@ -0,0 +1,64 @@
+package trace
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// summary is the verdict on a trace printed in the footer with WithSummary.
+type summary struct {
+	spans  int
+	errors int
+
+	// deepest is the path to the deepest span that recorded an error and
+	// deepestErr its first error.
+	deepest    []string
+	deepestErr *node
+
+	// slowest is the slowest span below the root, the root when it has no
+	// children.
+	slowest *spanSnapshot
+}
+
+func summarize(snap *spanSnapshot) *summary {
+	sum := &summary{}
+	sum.walk(snap, nil, true)
+	return sum
+}
+
+func (sum *summary) walk(s *spanSnapshot, path []string, root bool) {
+	path = append(path, s.funcName)
+	sum.spans++
+	sum.errors += len(s.errors)
+	if len(s.errors) > 0 && len(path) > len(sum.deepest) {
+		sum.deepest = append([]string(nil), path...)
+		sum.deepestErr = s.errors[0]
+	}
+	if !root || len(s.children) == 0 {
+		if sum.slowest == nil || s.duration() > sum.slowest.duration() {
+			sum.slowest = s
+		}
+	}
+	for _, v := range s.children {
+		sum.walk(v, path, false)
+	}
+}
+
+// writeSummary writes the footer lines of WithSummary in color.
+func (tc *TraceContext) writeSummary(str io.StringWriter, snap *spanSnapshot, color int) {
+	sum := summarize(snap)
+	line := "├= " + strconv.Itoa(sum.spans) + " spans, " + strconv.Itoa(sum.errors) + " errors"
+	str.WriteString(withColor(color, line) + "\n")
+	if sum.deepestErr != nil {
+		res, _ := json.Marshal(sum.deepestErr.Data)
+		line = "├= deepest error: " + strings.Join(sum.deepest, " › ") + ": " + string(res)
+		str.WriteString(withColor(color, line) + "\n")
+	}
+	if sum.slowest != nil {
+		line = "├= slowest: " + sum.slowest.funcName + " " + sum.slowest.duration().Round(time.Microsecond).String()
+		str.WriteString(withColor(color, line) + "\n")
+	}
+}
@@ -0,0 +1,38 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSummary(t *testing.T) {
+	buf := &bytes.Buffer{}
+	clock := &stepClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	tc := NewTraceContext(context.Background(), buf, WithSummary(), WithClock(clock))
+	_ = tc.Error("top")
+	fast := tc.Trace()
+	fast.Info("fast")
+	fast.End()
+	slow := tc.Trace()
+	clock.now = clock.now.Add(time.Second)
+	_ = slow.Trace().Error("deep", 42)
+	slow.End()
+	tc.Log()
+
+	name := "github.com/mucolud/trace.TestWithSummary"
+	for _, want := range []string{
+		"├= 4 spans, 2 errors",
+		"├= deepest error: " + name + " › " + name + " › " + name + `: ["deep",42]`,
+		"├= slowest: " + name + " 1s",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("summary should contain %q: %s", want, buf.String())
+		}
+	}
+	if i, j := strings.Index(buf.String(), "├= "), strings.Index(buf.String(), "└ traceId"); i < 0 || i > j {
+		t.Fatalf("summary should come before the closing line: %s", buf.String())
+	}
+}
@@ -0,0 +1,121 @@
+package trace
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyslogConfig configures SyslogSink.
+type SyslogConfig struct {
+	// Facility is the syslog facility of the messages, 0 meaning 1, user
+	// level, as applications do not log as the kernel.
+	Facility int
+	// Hostname defaults to the host of the Resource of the trace, else to
+	// the one of the machine.
+	Hostname string
+	// AppName defaults to the service of the Resource of the trace, else
+	// to the name of the program.
+	AppName string
+	// Framing prefixes every message with its length as in RFC 6587, as
+	// syslog servers expect on TCP and TLS streams.
+	Framing bool
+	// Options configure the formatting of the tree, as for WriteTrace.
+	Options []Option
+}
+
+// SyslogSDID is the structured data element SyslogSink carries the ids of
+// the trace in, under the enterprise number IANA reserves for examples.
+const SyslogSDID = "trace@32473"
+
+// SyslogSink writes every trace to w as an RFC 5424 syslog message holding
+// the tree without colors, with severity err if the trace has errors and
+// info otherwise. The trace and root span ids travel as the structured
+// data [trace@32473 trace_id="..." span_id="..."], so the syslog server can
+// index them. w is typically a connection to the server:
+//
+//	conn, err := net.Dial("udp", "logs.internal:514")
+//	tc := trace.NewTraceContext(ctx, trace.SinkLogger(trace.SyslogSink(conn, trace.SyslogConfig{})))
+func SyslogSink(w io.Writer, cfg SyslogConfig) Sink {
+	return SinkFunc(func(t TraceData) error {
+		_, err := w.Write(cfg.message(&t))
+		return err
+	})
+}
+
+// message formats t as an RFC 5424 message.
+func (cfg *SyslogConfig) message(t *TraceData) []byte {
+	facility := cfg.Facility
+	if facility == 0 {
+		facility = 1
+	}
+	severity := 6
+	if t.HasError() {
+		severity = 3
+	}
+	host, app, pid := cfg.Hostname, cfg.AppName, os.Getpid()
+	if r := t.Resource; r != nil {
+		if host == "" {
+			host = r.Host
+		}
+		if app == "" {
+			app = r.Service
+		}
+		if r.PID != 0 {
+			pid = r.PID
+		}
+	}
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	if app == "" {
+		app = filepath.Base(os.Args[0])
+	}
+	at := t.End
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + strconv.Itoa(facility*8+severity) + ">1 ")
+	b.WriteString(at.UTC().Format("2006-01-02T15:04:05.000000Z07:00") + " ")
+	b.WriteString(syslogHeader(host, 255) + " " + syslogHeader(app, 48) + " " + strconv.Itoa(pid) + " trace ")
+	b.WriteString("[" + SyslogSDID + ` trace_id="` + syslogParam(t.TraceId) + `" span_id="` + syslogParam(t.SpanId) + `"]`)
+	// the tree is UTF-8, which RFC 5424 wants announced with a BOM
+	b.WriteString(" \xef\xbb\xbf" + plainTree(t, cfg.Options))
+	msg := b.String()
+	if cfg.Framing {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+	return []byte(msg)
+}
+
+// syslogHeader makes s a header field of at most max printable ASCII
+// characters, "-" if empty.
+func syslogHeader(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// syslogParam escapes a structured data parameter value.
+func syslogParam(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(s)
+}
+
+// plainTree formats t as the tree Log writes, without colors nor the
+// blank lines leading it.
+func plainTree(t *TraceData, opts []Option) string {
+	tc := &TraceContext{opts: newOptions(opts)}
+	return strings.TrimLeft(stripColor(string(tc.formatTree(t.snapshot()))), "\n")
+}
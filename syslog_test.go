@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSyslogSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := SinkLogger(SyslogSink(buf, SyslogConfig{Framing: true}))
+	tc := NewTraceContext(context.Background(), sink, WithResource(Resource{Service: "billing", Host: "web 1", PID: 42}))
+	_ = tc.Error("declined")
+	tc.Log()
+
+	out := buf.String()
+	n, msg, _ := strings.Cut(out, " ")
+	if size, err := strconv.Atoi(n); err != nil || size != len(msg) {
+		t.Fatalf("message not framed with its length: %q", out)
+	}
+	header := `^<11>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}Z web1 billing 42 trace ` +
+		`\[trace@32473 trace_id="` + tc.TraceID() + `" span_id="` + tc.SpanID() + `"\] ` + "\xef\xbb\xbf┌ traceId:"
+	if !regexp.MustCompile(header).MatchString(msg) || strings.Contains(msg, "\x1b") || !strings.Contains(msg, `["declined"]`) {
+		t.Fatalf("unexpected message %q", msg)
+	}
+}
+
+func TestJournaldSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), SinkLogger(JournaldSink(buf)))
+	tc.Info("fine")
+	tc.Log()
+
+	entry := buf.Bytes()
+	if !bytes.HasPrefix(entry, []byte("MESSAGE\n")) {
+		t.Fatalf("a multi-line message should be sent with its length: %q", entry)
+	}
+	size := int(entry[8]) | int(entry[9])<<8 | int(entry[10])<<16
+	msg, rest := string(entry[16:16+size]), string(entry[16+size:])
+	if !strings.HasPrefix(msg, "┌ traceId:"+tc.TraceID()) || strings.Contains(msg, "\x1b") {
+		t.Fatalf("unexpected message %q", msg)
+	}
+	want := "\nPRIORITY=6\nTRACE_ID=" + tc.TraceID() + "\nSPAN_ID=" + tc.SpanID() + "\nCODE_FUNC=github.com/mucolud/trace.TestJournaldSink\n"
+	if rest != want {
+		t.Fatalf("unexpected fields %q, want %q", rest, want)
+	}
+}
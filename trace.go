@@ -1,18 +1,19 @@
 package trace
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"reflect"
-	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/mucolud/lib/convert"
 )
 
 const (
@@ -20,52 +21,154 @@ const (
 	colorYellow = 33
 )
 
-var customError = errors.New("custom error: ")
+// traceState is the mutable state shared by all spans of one trace.
+type traceState struct {
+	seq      uint64 // of the last record, see nextSeq
+	hasError int32
+	logged   int32
+	running  sync.WaitGroup
+
+	mux       sync.Mutex
+	artifacts *artifactDir
+	windows   map[callSite]*siteWindow // of WithRateLimit
+
+	escalation     []escalated // ring of WithEscalationBuffer
+	escalationNext int
+}
+
+func (s *traceState) markError() {
+	if atomic.SwapInt32(&s.hasError, 1) == 0 {
+		s.flushEscalation()
+	}
+}
+
+func (s *traceState) failed() bool {
+	return atomic.LoadInt32(&s.hasError) == 1
+}
 
 type node struct {
-	File string        `json:"file"`
-	Func string        `json:"func"`
+	Level    Level     `json:"level"`
+	Code     int       `json:"code,omitempty"`
+	Category Category  `json:"category,omitempty"`
+	Line     int       `json:"line"`
+	Func     string    `json:"func"`
+	Name     string    `json:"name,omitempty"`
+	Time     time.Time `json:"time"`
+
+	// Data is kept as recorded, errors and lazy params are resolved when
+	// the trace is rendered
 	Data []interface{} `json:"data"`
+
+	Stack []string `json:"stack,omitempty"`
+
+	// Tags are set with ErrorTagged, errors only
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Suppressed is the number of nodes of the same call site dropped
+	// after this one by WithRateLimit, counted in window until rendered
+	Suppressed int `json:"suppressed,omitempty"`
+	window     *siteWindow
+
+	seq uint64 // order of the record in the trace
 }
 type TraceContext struct {
 	context.Context
 	traceId  int64
+	spanId   int64
+	parentId int64         // span id of the parent, 0 for a root
+	parent   *TraceContext // in the tree, nil for a root
 	mux      sync.Mutex
 	logger   io.Writer
+	opts     *options
+	state    *traceState
 	funcName string
+	tags     map[string]interface{}
+	peer     Peer
+	running  int32
+	start    time.Time
+	end      time.Time
 	errors   []*node
 	infos    []*node
+	events   []*node
+	links    []*node
 	children []*TraceContext
+
+	// suppressed counts children dropped by WithMaxChildren
+	suppressed    int
+	status        Status        // set with SetStatus
+	slowThreshold time.Duration // set with WithSpanSlowThreshold
+	budget        time.Duration // left before the deadline at creation, 0 for none
+	dependency    *Dependency   // set with WithDependency
+	kind          SpanKind
+	args          []interface{} // name/value pairs set with Args
+	label         string        // set with Label
+	attachments   []Attachment
+
+	warnings int  // recorded with Warn
+	orphan   bool // created after Log with LateOrphan, logged by End
+
+	endCause error // why the context was done when the span ended
 }
 
-func NewTraceContext(ctx context.Context, logger io.Writer) *TraceContext {
-	pc, _, _, _ := runtime.Caller(1)
-	funcName := ""
-	if pcFunc := runtime.FuncForPC(pc); pcFunc != nil {
-		funcName = pcFunc.Name()
+func NewTraceContext(ctx context.Context, logger io.Writer, opts ...Option) *TraceContext {
+	o := newOptions(opts)
+	funcName, _ := caller(1 + o.callerSkip)
+	if !o.debug && debugFromContext(ctx) {
+		o.enableDebug()
+	}
+	tc := newTraceContext(ctx, o.logger(logger), o, &traceState{})
+	tc.funcName = funcName
+	if tc.opts.traceId != 0 {
+		tc.traceId = tc.opts.traceId
+	}
+	tc.parentId = tc.opts.parentSpanId
+	tc.budget = remaining(ctx)
+	atomic.AddUint64(&stats.Traces, 1)
+	tc.opts.journal.span(tc.now(), tc)
+	if tc.opts.inFlight {
+		tc.registerInFlight()
 	}
-	return &TraceContext{
-		Context:  ctx,
-		logger:   logger,
-		traceId:  time.Now().UnixNano(),
-		funcName: funcName,
-		children: make([]*TraceContext, 0, 10),
-		errors:   make([]*node, 0, 10),
-		infos:    make([]*node, 0, 10),
+	if tc.opts.debug {
+		tc.SetTag("trace.debug", true)
+	}
+	return tc
+}
+
+func newTraceContext(ctx context.Context, logger io.Writer, opts *options, state *traceState) *TraceContext {
+	tc := spanPool.Get().(*TraceContext)
+	tc.init(ctx, logger, opts, state)
+	return tc
+}
+
+// init starts tc, a new or released span.
+func (tc *TraceContext) init(ctx context.Context, logger io.Writer, opts *options, state *traceState) {
+	tc.Context = ctx
+	tc.logger = logger
+	tc.opts = opts
+	tc.state = state
+	tc.start = opts.clock.Now()
+	tc.traceId = tc.start.UnixNano()
+	tc.spanId = newSpanID()
+	if tc.children == nil {
+		tc.children = make([]*TraceContext, 0, 10)
+		tc.errors = make([]*node, 0, 10)
+		tc.infos = make([]*node, 0, 10)
 	}
 }
 
 func withColor(color int, str interface{}) string {
-	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", color, convert.ToString(str))
+	return "\x1b[" + strconv.Itoa(color) + "m" + toString(str) + "\x1b[0m"
 }
 
-func (tc *TraceContext) convertParams(params []interface{}) []interface{} {
-	for i, v := range params {
-		if err, ok := v.(error); ok && err != nil {
-			params[i] = err.Error()
+// renderParams prepares recorded params for serialization.
+func (tc *TraceContext) renderParams(params []interface{}) []interface{} {
+	res := tc.applyZeroMode(tc.encodeParams(resolveParams(params)))
+	if r := tc.opts.redactor; r != nil {
+		for i, v := range res {
+			res[i] = r.Redact(v)
 		}
 	}
-	return params
+	return res
 }
 
 func (tc *TraceContext) convertToError(params []interface{}) error {
@@ -73,71 +176,257 @@ func (tc *TraceContext) convertToError(params []interface{}) error {
 		return nil
 	}
 
-	var res = make([]string, 0, len(params))
+	var buf [128]byte
+	b := buf[:0]
+	n := 0
 	for _, v := range params {
-		if err, ok := v.(error); ok && err != nil {
-			res = append(res, err.Error())
-		} else {
-			if v == nil {
-				res = append(res, "nil")
-			} else {
-				res = append(res, fmt.Sprintf("%+v",
-					reflect.Indirect(reflect.ValueOf(v)).Interface()))
+		v = tc.encode(resolveLazy(v))
+		if tc.opts.zeroMode != ZeroKeep && isZeroParam(v) {
+			switch tc.opts.zeroMode {
+			case ZeroSkip:
+				continue
+			case ZeroNull:
+				v = nil
+			case ZeroPlaceholder:
+				v = tc.zeroPlaceholder()
 			}
 		}
+		if n > 0 {
+			b = append(b, ',')
+		}
+		n++
+		b = tc.appendParam(b, v)
 	}
-	return errors.New(strings.Join(res, ","))
+	return errors.New(string(b))
 }
 
-func (tc *TraceContext) Trace() *TraceContext {
+func (tc *TraceContext) Trace(opts ...SpanOption) *TraceContext {
+	return tc.trace(2, opts)
+}
+
+// WithSpanName names the span instead of after the function calling
+// Trace, for helpers creating spans on behalf of their callers.
+func WithSpanName(name string) SpanOption {
+	return func(tc *TraceContext) {
+		tc.funcName = name
+	}
+}
+
+// trace creates a child span named after the caller skip frames up.
+func (tc *TraceContext) trace(skip int, opts []SpanOption) *TraceContext {
+	budget := remaining(tc)
 	tc.mux.Lock()
 	defer tc.mux.Unlock()
 	if tc.children == nil {
 		tc.children = make([]*TraceContext, 0, 10)
 	}
-	pc, _, _, _ := runtime.Caller(1)
-	funcName := ""
-	if pcFunc := runtime.FuncForPC(pc); pcFunc != nil {
-		funcName = pcFunc.Name()
-	}
-	ntc := NewTraceContext(tc.Context, tc.logger)
+	funcName, _ := caller(skip + tc.opts.callerSkip)
+	ntc := newTraceContext(tc, tc.logger, tc.opts, tc.state)
 	ntc.traceId = tc.traceId
+	ntc.parentId = tc.spanId
+	ntc.budget = budget
 	ntc.funcName = funcName
+	for _, opt := range opts {
+		opt(ntc)
+	}
+	if tc.state.isLogged() && tc.lateChild(ntc) {
+		// a trace of its own, not part of the tree
+		return ntc
+	}
+	if max := tc.opts.maxChildren; max > 0 && len(tc.children) >= max {
+		// still usable by the caller, but not part of the tree
+		tc.suppressed++
+		return ntc
+	}
+	ntc.parent = tc
 	tc.children = append(tc.children, ntc)
+	atomic.AddUint64(&stats.Spans, 1)
+	tc.opts.journal.span(tc.now(), ntc)
 	return ntc
 }
 
+// WithValue attaches key/val to this span. Children created afterwards
+// derive from tc, so they see the value as well.
+func (tc *TraceContext) WithValue(key, val interface{}) *TraceContext {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.Context = context.WithValue(tc.Context, key, val)
+	return tc
+}
+
+// Value looks key up in this span and then walks up through the parents.
+func (tc *TraceContext) Value(key interface{}) interface{} {
+	if key == (traceKey{}) {
+		return tc
+	}
+	return tc.context().Value(key)
+}
+
+func (tc *TraceContext) Deadline() (time.Time, bool) {
+	return tc.context().Deadline()
+}
+
+func (tc *TraceContext) Done() <-chan struct{} {
+	return tc.context().Done()
+}
+
+func (tc *TraceContext) Err() error {
+	return tc.context().Err()
+}
+
+// context returns the wrapped context, which WithValue may replace.
+func (tc *TraceContext) context() context.Context {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	return tc.Context
+}
+
+// Short returns a compact "trace=... span=... fn=pkg.Func" token identifying the
+// span, for prefixing messages sent to systems outside this package.
+func (tc *TraceContext) Short() string {
+	name := tc.funcName
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return "trace=" + tc.TraceID() + " span=" + tc.SpanID() + " fn=" + name
+}
+
+// End marks the span as finished. A span's duration runs from its creation
+// to the first call of End, or to its last recorded activity if End is never
+// called. If the context of the span is done, End records why, so that
+// spans cut short are told apart from those ending before a cancellation. A
+// span created after its trace was logged is logged by End with
+// LateOrphan, see WithLateSpans.
+func (tc *TraceContext) End() {
+	cause := cancelCause(tc)
+	tc.mux.Lock()
+	first := tc.end.IsZero()
+	if first {
+		tc.end = tc.now()
+		tc.endCause = cause
+	}
+	tc.mux.Unlock()
+	if first && tc.orphan {
+		tc.Log()
+	}
+}
+
+// SetTag sets a span level tag, rendered next to the span name.
+func (tc *TraceContext) SetTag(key string, value interface{}) *TraceContext {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	if tc.tags == nil {
+		tc.tags = make(map[string]interface{})
+	}
+	tc.tags[key] = value
+	return tc
+}
+
+func (s *spanSnapshot) formatTags() string {
+	if len(s.tags) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(s.tags))
+	for k := range s.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var str = &strings.Builder{}
+	for _, k := range keys {
+		str.WriteString(" " + k + "=" + fmt.Sprint(s.tags[k]))
+	}
+	return str.String()
+}
+
+// Error records params as an error node and returns them as a
+// *TracedError, nil if there are none.
 func (tc *TraceContext) Error(params ...interface{}) error {
-	pc, _, line, _ := runtime.Caller(1)
-	funcName := ""
-	if pcFunc := runtime.FuncForPC(pc); pcFunc != nil {
-		funcName = pcFunc.Name()
+	return tc.error(2, 0, params)
+}
+
+// ErrorSkip is Error attributing the record skip frames above its caller,
+// for helpers recording errors on behalf of the code calling them:
+//
+//	func check(tc *trace.TraceContext, err error) error {
+//		if err != nil {
+//			return tc.ErrorSkip(1, err) // the line calling check
+//		}
+//		return nil
+//	}
+func (tc *TraceContext) ErrorSkip(skip int, params ...interface{}) error {
+	return tc.error(2+skip, 0, params)
+}
+
+// error records an error node with code, 0 for none, attributed to the
+// caller skip frames up.
+func (tc *TraceContext) error(skip int, code int, params []interface{}) error {
+	return tc.errorTagged(skip+1, code, nil, params)
+}
+
+// errorTagged records an error node carrying tags, attributed to the caller
+// skip frames up.
+func (tc *TraceContext) errorTagged(skip int, code int, tags map[string]string, params []interface{}) error {
+	skip += tc.opts.callerSkip
+	funcName, file, line := callerFile(skip)
+	var stack []string
+	if depth := tc.opts.stackDepth; depth > 0 {
+		stack = captureStack(skip, depth)
 	}
-	tc.errors = append(tc.errors, &node{
-		File: fmt.Sprintf("%d", line),
-		Func: funcName,
-		Data: tc.convertParams(params),
-	})
+	err := tc.tracedError(funcName, file, line, code, params)
+	n := node{
+		Level:    LevelError,
+		Code:     code,
+		Category: tc.category(code),
+		Line:     line,
+		Func:     funcName,
+		Time:     tc.now(),
+		Data:     params,
+		Stack:    stack,
+		Tags:     tags,
+		seq:      tc.state.nextSeq(),
+	}
+	if !tc.onRecord(&n) {
+		return err
+	}
+	if tc.admit(&n) {
+		tc.mux.Lock()
+		tc.errors = append(tc.errors, newNode(n))
+		tc.mux.Unlock()
+	}
+	tc.countError(&n)
+	if err != nil && tc.opts.journal != nil {
+		var msg interface{} = err.Error()
+		if tc.opts.redactor != nil {
+			msg = tc.opts.redactor.Redact(msg)
+		}
+		tc.opts.journal.error(tc.now(), tc, funcName, fmt.Sprint(msg))
+	}
+	tc.state.markError()
 
-	return tc.convertToError(params)
+	return err
 }
 
+// ErrorCustom returns params as a *UserError, a message meant for the end
+// user. The first error among params becomes its cause. Nothing is recorded.
 func (tc *TraceContext) ErrorCustom(params ...interface{}) error {
 	ve := tc.convertToError(params)
 	if ve == nil {
 		return nil
-	} else {
-		return fmt.Errorf("%w%s", customError, ve.Error())
 	}
+	return &UserError{Message: ve.Error(), Cause: firstError(params)}
 }
 
+// WrapError prepares err to be shown to the end user: a *UserError anywhere
+// in its chain is returned as is, other errors are replaced by title if
+// given.
 func (tc *TraceContext) WrapError(err error, title ...string) error {
 	if err == nil {
 		return nil
 	}
 
-	if errors.Is(err, customError) {
-		return errors.New(strings.ReplaceAll(err.Error(), customError.Error(), ""))
+	var ue *UserError
+	if errors.As(err, &ue) {
+		return ue
 	} else {
 		if len(title) > 0 {
 			return errors.New(strings.Join(title, ","))
@@ -148,66 +437,314 @@ func (tc *TraceContext) WrapError(err error, title ...string) error {
 }
 
 func (tc *TraceContext) Info(params ...interface{}) {
-	pc, _, line, _ := runtime.Caller(1)
-	funcName := ""
-	if pcFunc := runtime.FuncForPC(pc); pcFunc != nil {
-		funcName = pcFunc.Name()
+	tc.info(2, params)
+}
+
+// info records an info node attributed to the caller skip frames up.
+func (tc *TraceContext) info(skip int, params []interface{}) {
+	if !tc.enabled(LevelInfo) {
+		return
 	}
-	tc.infos = append(tc.infos, &node{
-		File: fmt.Sprintf("%d", line),
+	buffer := tc.opts.escalate && !tc.state.failed()
+	if buffer && tc.opts.escalationBuffer <= 0 {
+		return
+	}
+	funcName, line := caller(skip + tc.opts.callerSkip)
+	if !tc.opts.funcsAllowed(funcName) {
+		return
+	}
+	n := node{
+		Line: line,
 		Func: funcName,
-		Data: tc.convertParams(params),
-	})
+		Time: tc.now(),
+		Data: params,
+		seq:  tc.state.nextSeq(),
+	}
+	if !tc.onRecord(&n) || !tc.admit(&n) {
+		return
+	}
+	if buffer && tc.state.buffer(tc, newNode(n), tc.opts.escalationBuffer) {
+		return
+	}
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.infos = append(tc.infos, newNode(n))
 }
 
-func (tc *TraceContext) formatLog(node *TraceContext, prefix string) string {
+// writeLog writes the span and its subtree to str as they are formatted.
+// The header of the span starts at the display width indent; with
+// WithAlignedColumns its markers start at column.
+func (tc *TraceContext) writeLog(str io.StringWriter, node *spanSnapshot, prefix string, indent, column int) {
 	//┌ ┬ ┐
 	//├ ┼ ┤
 	//└ ┴ ┘
 
-	var str = &strings.Builder{}
 	//var hasLog = len(node.infos) > 0 && len(node.errors) > 0
-	str.WriteString(node.funcName + "\n")
+	header := node.funcName + node.formatArgs() + node.formatLabel() + node.formatKind() + node.formatPeer() + node.formatDependency() + node.formatTags()
+	markers := node.formatBudget()
+	if node.running {
+		markers += " (running)"
+	}
+	if node.cause != "" {
+		markers += " (cancelled: " + node.cause + ")"
+	}
+	markers += tc.formatDuration(node) + node.formatSlow() + node.formatStatus()
+	if tc.opts.alignColumns && markers != "" {
+		header = tc.padRight(header, column-indent)
+	}
+	str.WriteString(header + markers + "\n")
 
-	for _, v := range node.infos {
-		infoStr := ""
-		if len(v.Data) > 0 {
-			res, _ := json.Marshal(v.Data)
-			infoStr = strings.ReplaceAll(string(res), "\\", "") + "\n"
+	palette := tc.opts.palette
+	locWidth := 0
+	if tc.opts.alignColumns {
+		locWidth = tc.locationWidth(node)
+	}
+	children := tc.visibleChildren(node)
+	// the root level is closed by the footer of the trace
+	b := &branches{closed: tc.opts.closedTree && prefix != ""}
+	b.left = len(node.infos) + len(node.errors) + len(node.events) + len(node.links) + len(node.attachments) + len(node.violations) + len(children)
+	if node.suppressed > 0 {
+		b.left++
+	}
+	for _, v := range node.timeline() {
+		switch v.kind {
+		case infoRecord:
+			infoStr := ""
+			if len(v.Data) > 0 {
+				res, _ := json.Marshal(v.Data)
+				infoStr = strings.ReplaceAll(string(res), "\\", "")
+			}
+			loc := v.Func + ":" + strconv.Itoa(v.Line) + ":"
+			if infoStr != "" {
+				loc = tc.padRight(loc, locWidth)
+			}
+			branch, _ := b.next()
+			str.WriteString(prefix + palette.paint(v.Level, branch, loc+infoStr+v.formatSuppressed()) + "\n")
+		case errorRecord:
+			infoStr := ""
+			if len(v.Data) > 0 {
+				res, _ := json.Marshal(v.Data)
+				infoStr = string(res)
+			}
+			loc := v.formatCode() + v.Func + ":" + strconv.Itoa(v.Line) + ":"
+			if infoStr != "" {
+				loc = tc.padRight(loc, locWidth)
+			}
+			branch, guide := b.next()
+			str.WriteString(prefix + palette.paint(v.Level, branch, loc+infoStr+v.formatTags()+v.formatSuppressed()) + "\n")
+			for _, frame := range v.Stack {
+				str.WriteString(prefix + guide + "     at " + frame + "\n")
+			}
+		case eventRecord:
+			branch, _ := b.next()
+			str.WriteString(prefix + branch + "* " + v.Time.Format("15:04:05.000") + " " + v.Name)
+			if attrs := v.Data[0].(map[string]interface{}); len(attrs) > 0 {
+				res, _ := json.Marshal(attrs)
+				str.WriteString(" " + string(res))
+			}
+			str.WriteString("\n")
 		}
-		str.WriteString(prefix + "├> " + v.Func + ":" + v.File + ":" + infoStr)
 	}
-	for _, v := range node.errors {
-		infoStr := ""
-		if len(v.Data) > 0 {
-			res, _ := json.Marshal(v.Data)
-			infoStr = string(res) + "\n"
+	for _, v := range node.links {
+		branch, _ := b.next()
+		str.WriteString(prefix + branch + "~ link " + v.Name)
+		if attrs := v.Data[0].(map[string]interface{}); len(attrs) > 0 {
+			res, _ := json.Marshal(attrs)
+			str.WriteString(" " + string(res))
 		}
-		str.WriteString(prefix + "├E " + v.Func + ":" + v.File + ":" + infoStr)
+		str.WriteString("\n")
+	}
+	for _, v := range node.attachments {
+		branch, _ := b.next()
+		str.WriteString(prefix + branch + "# attachment " + formatAttachment(v) + "\n")
+	}
+	for _, v := range node.violations {
+		branch, _ := b.next()
+		str.WriteString(prefix + branch + "! schema: " + v + "\n")
 	}
 
-	if len(node.children) > 0 {
-		for _, v := range node.children {
-			if len(v.errors) == 0 && len(v.infos) == 0 && len(v.children) == 0 {
-				continue
-			}
-			tag := "├"
-			outLog := tc.formatLog(v, prefix+"   ")
-			if outLog != "" {
-				str.WriteString(prefix + tag + outLog)
-			}
+	for _, v := range children {
+		branch, guide := b.next()
+		if tc.collapsible(v) {
+			str.WriteString(prefix + fmt.Sprintf("%s✓ %s %d spans %s\n",
+				branch, v.funcName, v.spanCount(), v.duration().Round(time.Millisecond)))
+			continue
 		}
+		chain, v := tc.chain(v)
+		lead, childPrefix := tc.childLead(prefix, branch, guide, chain)
+		str.WriteString(lead)
+		tc.writeLog(str, v, childPrefix, tc.width(lead), column)
 	}
-	return str.String()
+	if node.suppressed > 0 {
+		branch, _ := b.next()
+		str.WriteString(prefix + fmt.Sprintf("%s… %d more children suppressed\n", branch, node.suppressed))
+	}
+}
+
+// SetLogger replaces the writer this trace is logged to.
+func (tc *TraceContext) SetLogger(w io.Writer) *TraceContext {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.logger = w
+	return tc
+}
+
+// hasError reports whether tc or any of its descendants recorded an error.
+func (tc *TraceContext) hasError() bool {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	if len(tc.errors) > 0 || tc.warned() {
+		return true
+	}
+	for _, v := range tc.children {
+		if v.hasError() {
+			return true
+		}
+	}
+	return false
+}
+
+// writer returns where the trace should be logged to.
+func (tc *TraceContext) writer(snap *spanSnapshot) io.Writer {
+	if tc.opts.errorLogger != nil && snap != nil && snap.hasError() {
+		return tc.opts.errorLogger
+	}
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	return tc.logger
 }
 
 func (tc *TraceContext) Log() {
-	if tc.logger != nil {
-		split := fmt.Sprintf("traceId:%d", tc.traceId)
-		tc.logger.Write([]byte(
-			withColor(colorYellow, "\n\n┌ "+split+"\n") +
-				tc.formatLog(tc, "") +
-				withColor(colorYellow, "└ "+split),
-		))
+	_ = tc.log(tc.opts.errorOnly)
+}
+
+// LogE is Log returning the first error of the writers the trace went to,
+// so callers can alarm when traces get lost. The trace was still written to
+// the writers that did not fail, and to WithFallbackLogger if set.
+func (tc *TraceContext) LogE() error {
+	return tc.log(tc.opts.errorOnly)
+}
+
+// LogIfError logs the trace only if it contains at least one error.
+func (tc *TraceContext) LogIfError() {
+	_ = tc.log(true)
+}
+
+// formatTree renders the whole trace as written to tree sinks.
+func (tc *TraceContext) formatTree(snap *spanSnapshot) []byte {
+	buf := &bytes.Buffer{}
+	tc.writeTree(buf, snap)
+	if tc.opts.noColor {
+		return []byte(stripColor(buf.String()))
+	}
+	return buf.Bytes()
+}
+
+// writeTree writes the whole trace to str as it is formatted.
+func (tc *TraceContext) writeTree(str io.StringWriter, snap *spanSnapshot) {
+	split := "traceId:" + formatTraceID(snap.traceId)
+	color := colorYellow
+	if snap.hasError() {
+		color = colorRed
+	}
+	header := split
+	if snap.resource != nil {
+		header += " " + snap.resource.String()
+	}
+	str.WriteString(withColor(color, "\n\n┌ "+header+"\n"))
+	column := 0
+	if tc.opts.alignColumns {
+		column = tc.headerColumn(snap, "", 0)
+	}
+	tc.writeLog(str, snap, "", 0, column)
+	if tc.opts.summary {
+		tc.writeSummary(str, snap, color)
+	}
+	str.WriteString(withColor(color, "└ "+split))
+}
+
+// WriteTo writes the trace to w as a tree, like Log, but streams it as it
+// is formatted through a buffer of bounded size instead of building it whole
+// in memory first, so huge traces are written in several calls to w. It
+// ignores the logger and options choosing what Log writes.
+func (tc *TraceContext) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriterSize(cw, 4096)
+	tc.writeTree(bw, tc.snapshot())
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (tc *TraceContext) log(errorOnly bool) error {
+	errorOnly = errorOnly || CurrentConfig().ErrorOnly || !tc.sampled()
+	if tc.opts.waitGoroutines {
+		tc.state.wait(tc.opts.waitTimeout)
+	}
+	tc.opts.journal.done(tc.now(), tc)
+	if tc.opts.inFlight {
+		tc.unregisterInFlight()
+	}
+	start := time.Now()
+	tc.state.markLogged()
+	var snap *spanSnapshot
+	if tc.onLog() {
+		snap = tc.snapshot()
+	}
+	if snap != nil && tc.opts.recent {
+		tc.keepRecent(snap)
+	}
+	if snap != nil && tc.opts.aggregate {
+		aggregateSpans(snap)
+	}
+	if snap != nil && errorOnly && !tc.opts.debug && !snap.hasError() && !(tc.opts.logSlow && snap.hasSlow()) {
+		snap = nil
+	}
+	if snap != nil {
+		atomic.AddUint64(&stats.Logged, 1)
+	}
+	var err error
+	if snap != nil {
+		err = tc.writeSinks(tc.writer(snap), snap, start)
+	}
+	if flat := tc.opts.flatLogger; snap != nil && flat != nil {
+		n, flatErr := writeEntries(flat, snap.entries(nil, 0))
+		atomic.AddUint64(&stats.BytesLogged, uint64(n))
+		if err == nil {
+			err = flatErr
+		}
+	}
+	if err != nil {
+		tc.writeFailed(err, snap, start)
+	}
+	tc.state.mux.Lock()
+	artifacts := tc.state.artifacts
+	tc.state.mux.Unlock()
+	_ = artifacts.cleanup()
+	return err
+}
+
+// writeFailed reports that writing snap failed with err and saves the trace
+// to the fallback logger.
+func (tc *TraceContext) writeFailed(err error, snap *spanSnapshot, start time.Time) {
+	if m := tc.opts.metrics; m != nil {
+		m.Count("trace_write_errors_total", 1)
+	}
+	if fallback := tc.opts.fallbackLogger; fallback != nil {
+		_ = tc.writeSinks(fallback, snap, start)
+	}
+	if h := tc.opts.writeErrorHandler; h != nil {
+		h(err)
 	}
 }
@@ -1,9 +1,12 @@
 package trace
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"testing"
 	"time"
 )
@@ -44,7 +47,8 @@ func E(tc *TraceContext) error {
 }
 
 func TestNewTraceContext(t *testing.T) {
-	tc, _ := context.WithTimeout(context.Background(), time.Second*20)
+	tc, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	defer cancel()
 	tc2 := NewTraceContext(tc, &MLog{})
 	A(tc2, "hahah", 10)
 	tc2.Log()
@@ -57,3 +61,250 @@ func TestTraceContext_ErrorCustom(t *testing.T) {
 	err = tc.Error("你好")
 	t.Log(tc.WrapError(err, "哈哈"))
 }
+
+type ctxKey string
+
+func TestTraceContext_WithValue(t *testing.T) {
+	tc := NewTraceContext(context.Background(), &MLog{})
+	child := tc.Trace()
+	tc.WithValue(ctxKey("user"), "bob")
+	if v := child.Value(ctxKey("user")); v != "bob" {
+		t.Fatalf("child should see parent value added later, got %v", v)
+	}
+	child.WithValue(ctxKey("user"), "alice")
+	if v := child.Trace().Value(ctxKey("user")); v != "alice" {
+		t.Fatalf("grandchild should see child value, got %v", v)
+	}
+	if v := tc.Value(ctxKey("user")); v != "bob" {
+		t.Fatalf("parent value must not be overwritten by child, got %v", v)
+	}
+}
+
+func TestWithEscalation(t *testing.T) {
+	tc := NewTraceContext(context.Background(), &MLog{}, WithEscalation())
+	child := tc.Trace()
+	tc.Info("dropped")
+	child.Info("dropped")
+	_ = child.Error("failed")
+	tc.Info("kept")
+	if len(tc.infos) != 1 || tc.infos[0].Data[0] != "kept" {
+		t.Fatalf("only infos after the first error should be kept, got %d", len(tc.infos))
+	}
+	if len(child.infos) != 0 || len(child.errors) != 1 {
+		t.Fatalf("unexpected child records %d/%d", len(child.infos), len(child.errors))
+	}
+}
+
+func TestWithEscalationBuffer(t *testing.T) {
+	tc := NewTraceContext(context.Background(), &MLog{}, WithEscalationBuffer(2))
+	child := tc.Trace()
+	tc.Info("dropped")
+	child.Info("buffered 1")
+	tc.Info("buffered 2")
+	if len(tc.infos) != 0 || len(child.infos) != 0 {
+		t.Fatalf("infos should be buffered until the first error")
+	}
+	_ = child.Error("failed")
+	tc.Info("kept")
+	if len(tc.infos) != 2 || tc.infos[0].Data[0] != "buffered 2" || tc.infos[1].Data[0] != "kept" {
+		t.Fatalf("the buffer should be flushed before later infos, got %d", len(tc.infos))
+	}
+	if len(child.infos) != 1 || child.infos[0].Data[0] != "buffered 1" {
+		t.Fatalf("buffered infos should go to their span, got %d", len(child.infos))
+	}
+
+	clean := NewTraceContext(context.Background(), &MLog{}, WithEscalationBuffer(2))
+	clean.Info("buffered")
+	if data := clean.Snapshot(); len(data.Infos) != 0 {
+		t.Fatalf("a healthy trace should not render buffered infos: %+v", data.Infos)
+	}
+}
+
+func TestTraceContext_SetLogger(t *testing.T) {
+	clean, failed, other := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+
+	tc := NewTraceContext(context.Background(), clean, WithErrorLogger(failed))
+	tc.Info("ok")
+	tc.Log()
+	if clean.Len() == 0 || failed.Len() != 0 {
+		t.Fatalf("clean trace should go to the default writer")
+	}
+
+	clean.Reset()
+	tc = NewTraceContext(context.Background(), clean, WithErrorLogger(failed))
+	_ = tc.Trace().Error("boom")
+	tc.Log()
+	if clean.Len() != 0 || failed.Len() == 0 {
+		t.Fatalf("trace with errors should go to the error writer")
+	}
+
+	tc = NewTraceContext(context.Background(), clean)
+	tc.SetLogger(other).Info("ok")
+	tc.Log()
+	if clean.Len() != 0 || other.Len() == 0 {
+		t.Fatalf("SetLogger should replace the writer")
+	}
+}
+
+func TestWithConverter(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil, WithConverter(func(v interface{}) string {
+		if p, ok := v.(*profile); ok {
+			return "profile(" + p.Name + ")"
+		}
+		return toString(v)
+	}))
+	err := tc.Error(&profile{Name: "bob"}, 3, 1.5)
+	if err.Error() != "profile(bob),3,1.5" {
+		t.Fatalf("converter not applied: %s", err)
+	}
+}
+
+func TestWithMaxChildren(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithMaxChildren(2))
+	for i := 0; i < 5; i++ {
+		tc.Trace().Info("row", i)
+	}
+	tc.Log()
+	if len(tc.children) != 2 {
+		t.Fatalf("want 2 children, got %d", len(tc.children))
+	}
+	if !strings.Contains(buf.String(), "├… 3 more children suppressed\n") {
+		t.Fatalf("suppressed children not reported: %s", buf.String())
+	}
+}
+
+func TestTraceContext_Short(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	span := tc.Trace()
+	want := "trace=" + tc.TraceID() + " span=" + span.SpanID() + " fn=trace.TestTraceContext_Short"
+	if got := span.Short(); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestTraceContext_TraceID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf)
+	id := tc.TraceID()
+	if len(id) != 16 || tc.Trace().TraceID() != id {
+		t.Fatalf("unexpected trace id %q", id)
+	}
+	if parsed, err := ParseTraceID(id); err != nil || parsed != tc.traceId {
+		t.Fatalf("round trip failed: %d %v", parsed, err)
+	}
+	tc.Log()
+	if !strings.Contains(buf.String(), "traceId:"+id) {
+		t.Fatalf("logged id must match TraceID: %s", buf.String())
+	}
+}
+
+func TestTraceContext_SpanID(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	child := tc.Trace()
+	if len(tc.SpanID()) != 16 || child.SpanID() == tc.SpanID() {
+		t.Fatalf("spans need distinct ids: %q %q", tc.SpanID(), child.SpanID())
+	}
+	if tc.ParentSpanID() != "" || child.ParentSpanID() != tc.SpanID() {
+		t.Fatalf("child should point to its parent: %q %q", tc.ParentSpanID(), child.ParentSpanID())
+	}
+	child.Info("loaded")
+	entries := tc.Entries()
+	if len(entries) != 1 || entries[0].SpanId != child.SpanID() || entries[0].ParentSpanId != tc.SpanID() {
+		t.Fatalf("entries should carry the span ids: %+v", entries)
+	}
+
+	remote := NewTraceContext(context.Background(), nil, WithParentSpanID(child.SpanID()))
+	if remote.ParentSpanID() != child.SpanID() {
+		t.Fatalf("root should continue the remote span, got %q", remote.ParentSpanID())
+	}
+}
+
+func TestTraceContext_Release(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		buf := &bytes.Buffer{}
+		tc := NewTraceContext(context.Background(), buf)
+		tc.Info("round", i)
+		tc.Trace().Info("child", i)
+		tc.Log()
+		tc.Release()
+		if strings.Count(buf.String(), "├>") != 2 || !strings.Contains(buf.String(), fmt.Sprintf(`["child",%d]`, i)) {
+			t.Fatalf("reused spans must start empty: %s", buf.String())
+		}
+	}
+}
+
+func TestWithErrorOnly(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithErrorOnly())
+	tc.Info("fine")
+	tc.Log()
+	if buf.Len() != 0 {
+		t.Fatalf("clean trace must not be logged: %s", buf.String())
+	}
+	_ = tc.Trace().Error("failed")
+	tc.Log()
+	if !strings.Contains(buf.String(), `"fine"`) || !strings.Contains(buf.String(), `"failed"`) {
+		t.Fatalf("failed trace should be logged with full context: %s", buf.String())
+	}
+
+	buf.Reset()
+	tc = NewTraceContext(context.Background(), buf)
+	tc.Info("fine")
+	tc.LogIfError()
+	if buf.Len() != 0 {
+		t.Fatalf("LogIfError must skip clean traces")
+	}
+}
+
+func TestWithInFlight(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil, WithInFlight())
+	NewTraceContext(context.Background(), nil).Info("not registered")
+	if _, ok := InFlightTrace(tc.TraceID()); !ok || len(InFlight()) != 1 {
+		t.Fatalf("trace should be in flight: %+v", InFlight())
+	}
+	tc.Log()
+	if _, ok := InFlightTrace(tc.TraceID()); ok || len(InFlight()) != 0 {
+		t.Fatalf("logged trace should be gone: %+v", InFlight())
+	}
+}
+
+type countWrites struct {
+	bytes.Buffer
+	writes, largest int
+}
+
+func (c *countWrites) Write(p []byte) (int, error) {
+	c.writes++
+	if len(p) > c.largest {
+		c.largest = len(p)
+	}
+	return c.Buffer.Write(p)
+}
+
+func TestTraceContext_WriteTo(t *testing.T) {
+	logged := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), logged)
+	for i := 0; i < 200; i++ {
+		child := tc.Trace()
+		child.Info("row", i, strings.Repeat("x", 64))
+		_ = child.Error("failed", i)
+	}
+	tc.Log()
+
+	w := &countWrites{}
+	n, err := tc.WriteTo(w)
+	if err != nil || n != int64(w.Len()) {
+		t.Fatalf("WriteTo = %d, %v, wrote %d bytes", n, err, w.Len())
+	}
+	if w.String() != logged.String() {
+		t.Fatalf("WriteTo should write the tree Log writes:\n%s\n%s", w.String(), logged.String())
+	}
+	if w.writes < 2 || w.largest > 4096 {
+		t.Fatalf("a large trace should be streamed in bounded writes: %d writes, largest %d", w.writes, w.largest)
+	}
+
+	if _, err := tc.WriteTo(failWriter{}); err == nil {
+		t.Fatal("WriteTo should return the write error")
+	}
+}
@@ -0,0 +1,67 @@
+package trace
+
+import (
+	"errors"
+	"strconv"
+)
+
+// TracedError is the error returned by Error and ErrorCode. Besides the
+// message, it tells where the error was recorded, so that whoever handles
+// it, such as a middleware answering the request, can point to the trace.
+// It wraps the first error among the params.
+type TracedError struct {
+	TraceID string // of the trace the error was recorded in
+	SpanID  string // of the span the error was recorded on
+	Span    string // name of that span
+	Func    string
+	File    string
+	Line    int
+	Code    int // as passed to ErrorCode, 0 for none
+	Params  []interface{}
+
+	msg string
+}
+
+func (e *TracedError) Error() string {
+	return e.msg
+}
+
+func (e *TracedError) Unwrap() error {
+	return firstError(e.Params)
+}
+
+// Location returns the file and line the error was recorded at, as
+// "file:line".
+func (e *TracedError) Location() string {
+	return e.File + ":" + strconv.Itoa(e.Line)
+}
+
+// TraceIDOf returns the id of the trace err, or any error it wraps, was
+// recorded in by Error or ErrorCode, and false if it was not.
+func TraceIDOf(err error) (string, bool) {
+	var te *TracedError
+	if !errors.As(err, &te) {
+		return "", false
+	}
+	return te.TraceID, true
+}
+
+// tracedError converts params to a *TracedError recorded on tc at
+// funcName, file and line, nil if there are no params.
+func (tc *TraceContext) tracedError(funcName, file string, line, code int, params []interface{}) error {
+	err := tc.convertToError(params)
+	if err == nil {
+		return nil
+	}
+	return &TracedError{
+		TraceID: formatTraceID(tc.traceId),
+		SpanID:  formatSpanID(tc.spanId),
+		Span:    tc.funcName,
+		Func:    funcName,
+		File:    file,
+		Line:    line,
+		Code:    code,
+		Params:  params,
+		msg:     err.Error(),
+	}
+}
@@ -0,0 +1,46 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func charge(tc *TraceContext) error {
+	tc = tc.Trace()
+	return tc.ErrorCode(402, "card declined", io.ErrUnexpectedEOF)
+}
+
+func TestTracedError(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	err := fmt.Errorf("checkout: %w", charge(tc))
+
+	var te *TracedError
+	if !errors.As(err, &te) {
+		t.Fatalf("Error should return a *TracedError, got %T", errors.Unwrap(err))
+	}
+	if te.Error() != `card declined,unexpected EOF` {
+		t.Fatalf("unexpected message %q", te.Error())
+	}
+	if te.TraceID != tc.TraceID() || te.SpanID == "" || te.Span != "github.com/mucolud/trace.charge" || te.Code != 402 {
+		t.Fatalf("unexpected error %+v", te)
+	}
+	if te.Func != "github.com/mucolud/trace.charge" || !strings.HasSuffix(te.Location(), "tracederror_test.go:14") {
+		t.Fatalf("unexpected location %s %s", te.Func, te.Location())
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("the error among the params should be wrapped")
+	}
+	if id, ok := TraceIDOf(err); !ok || id != tc.TraceID() {
+		t.Fatalf("TraceIDOf = %q, %v", id, ok)
+	}
+	if _, ok := TraceIDOf(io.EOF); ok {
+		t.Fatalf("TraceIDOf should not find a trace in other errors")
+	}
+	if tc.Error() != nil {
+		t.Fatalf("Error without params should return nil")
+	}
+}
@@ -0,0 +1,116 @@
+// Package tracegroup mirrors golang.org/x/sync/errgroup for traces: every
+// goroutine of a group runs in its own child span, their errors are recorded
+// on the trace, and Wait records a summary of the group.
+//
+//	g, tc := tracegroup.WithContext(tc)
+//	for _, url := range urls {
+//		url := url
+//		g.Go(func(tc *trace.TraceContext) error {
+//			return fetch(tc, url)
+//		})
+//	}
+//	err := g.Wait()
+package tracegroup
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mucolud/trace"
+)
+
+// Group is a collection of goroutines working on subtasks of the same task,
+// like errgroup.Group.
+type Group struct {
+	span   *trace.TraceContext
+	cancel context.CancelCauseFunc
+
+	wg      sync.WaitGroup
+	sem     chan struct{}
+	errOnce sync.Once
+	err     error
+
+	started int64
+	failed  int64
+}
+
+// WithContext returns a new Group and the span its goroutines are children
+// of, named after the caller. The context of the span is cancelled with the
+// first error a goroutine returns, or released once Wait returns.
+func WithContext(tc *trace.TraceContext) (*Group, *trace.TraceContext) {
+	span, cancel := tc.WithCancelCause(trace.WithSpanName(callerName()))
+	return &Group{span: span, cancel: cancel}, span
+}
+
+// SetLimit limits the number of goroutines running at once to n, a negative
+// n meaning no limit. It must not be called while goroutines are running.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go calls fn in a new goroutine, blocking while the limit of SetLimit is
+// reached. fn gets a child span named after the caller of Go and labelled
+// "worker-n" for the n-th goroutine of the group, on which the error fn
+// returns is recorded. The first error cancels the group and is
+// returned by Wait.
+func (g *Group) Go(fn func(tc *trace.TraceContext) error) {
+	name := callerName()
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	n := atomic.AddInt64(&g.started, 1)
+	child := g.span.Trace(trace.WithSpanName(name)).Label("worker-" + strconv.FormatInt(n, 10))
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+		defer child.End()
+		if err := fn(child); err != nil {
+			_ = child.Error(err)
+			atomic.AddInt64(&g.failed, 1)
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel(err)
+			})
+		}
+	}()
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// Wait blocks until all goroutines returned, records a "tracegroup" event
+// with the number of goroutines and failures on the group span, ends it and
+// returns the first error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	attrs := []interface{}{"goroutines", atomic.LoadInt64(&g.started), "failed", atomic.LoadInt64(&g.failed)}
+	if g.err != nil {
+		attrs = append(attrs, "error", g.err)
+	}
+	g.span.Event("tracegroup", attrs...)
+	g.cancel(nil)
+	return g.err
+}
+
+// callerName returns the name of the function calling the caller.
+func callerName() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return ""
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
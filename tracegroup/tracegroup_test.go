@@ -0,0 +1,77 @@
+package tracegroup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mucolud/trace"
+)
+
+func TestGroup(t *testing.T) {
+	buf := &bytes.Buffer{}
+	root := trace.NewTraceContext(context.Background(), buf)
+	g, tc := WithContext(root)
+	failure := errors.New("shard 2 unavailable")
+	for i := 0; i < 3; i++ {
+		i := i
+		g.Go(func(tc *trace.TraceContext) error {
+			if i == 2 {
+				return failure
+			}
+			<-tc.Done()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != failure {
+		t.Fatalf("Wait should return the first error, got %v", err)
+	}
+	if context.Cause(tc) != failure {
+		t.Fatalf("the group should be cancelled with the error")
+	}
+	root.Log()
+
+	data := root.Snapshot()
+	name := "github.com/mucolud/trace/tracegroup.TestGroup"
+	if len(data.Children) != 1 || data.Children[0].Func != name {
+		t.Fatalf("the group span should be named after the caller: %+v", data.Children)
+	}
+	group := data.Children[0]
+	if len(group.Children) != 3 || group.Children[2].Func != name || len(group.Children[2].Errors) != 1 {
+		t.Fatalf("every goroutine should have its span: %+v", group.Children)
+	}
+	if group.Children[2].Label != "worker-3" {
+		t.Fatalf("the span of the n-th goroutine should be labelled worker-n: %q", group.Children[2].Label)
+	}
+	if len(group.Events) != 1 || group.Events[0].Attrs["goroutines"] != int64(3) || group.Events[0].Attrs["failed"] != int64(1) {
+		t.Fatalf("Wait should record a summary: %+v", group.Events)
+	}
+	if !strings.Contains(buf.String(), "shard 2 unavailable") {
+		t.Fatalf("the error should be in the trace: %s", buf.String())
+	}
+}
+
+func TestGroup_SetLimit(t *testing.T) {
+	g, _ := WithContext(trace.NewTraceContext(context.Background(), nil))
+	g.SetLimit(2)
+	var running, max int64
+	for i := 0; i < 10; i++ {
+		g.Go(func(tc *trace.TraceContext) error {
+			n := atomic.AddInt64(&running, 1)
+			for {
+				m := atomic.LoadInt64(&max)
+				if n <= m || atomic.CompareAndSwapInt64(&max, m, n) {
+					break
+				}
+			}
+			atomic.AddInt64(&running, -1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil || max > 2 {
+		t.Fatalf("at most 2 goroutines should run at once: %v %d", err, max)
+	}
+}
@@ -0,0 +1,197 @@
+package trace
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// TraceIDHeader is the HTTP header carrying the trace id.
+const TraceIDHeader = "X-Trace-Id"
+
+// SpanIDHeader is the HTTP header carrying the span id of the caller.
+const SpanIDHeader = "X-Span-Id"
+
+// spanIDs generates span ids: a random start makes them unlikely to collide
+// with those of other processes, the increment unique within the process.
+var spanIDs = rand.Int63()
+
+func newSpanID() int64 {
+	id := atomic.AddInt64(&spanIDs, 1)
+	if id == 0 {
+		id = atomic.AddInt64(&spanIDs, 1)
+	}
+	return id
+}
+
+// IDEncoding is how trace and span ids are written as text, see
+// SetIDEncoding.
+type IDEncoding int32
+
+const (
+	// HexIDs writes 16 lowercase hex digits, the default.
+	HexIDs IDEncoding = iota
+	// Base62IDs writes 11 digits and letters, shorter in URLs and support
+	// tickets.
+	Base62IDs
+	// UUIDIDs writes the id as the low half of a UUID, such as
+	// 00000000-0000-0000-17cb-5b99f8638000, for systems expecting one.
+	UUIDIDs
+)
+
+// The length of the ids written in each IDEncoding, which tells them apart.
+const (
+	hexIDLen    = 16
+	base62IDLen = 11
+	uuidIDLen   = 36
+)
+
+// idEncoding is the IDEncoding set with SetIDEncoding.
+var idEncoding int32
+
+// SetIDEncoding sets how every trace of the process writes trace and span
+// ids: in its output, in the headers of the propagation package and in the
+// errors it returns. OTLP and Zipkin exporters keep the hex ids their
+// protocols require. ParseTraceID reads all encodings, so processes using
+// different ones still continue each other's traces.
+func SetIDEncoding(e IDEncoding) {
+	atomic.StoreInt32(&idEncoding, int32(e))
+}
+
+// Format writes id in the encoding.
+func (e IDEncoding) Format(id int64) string {
+	switch e {
+	case Base62IDs:
+		return formatBase62(uint64(id))
+	case UUIDIDs:
+		hex := hexID(id)
+		return "00000000-0000-0000-" + hex[:4] + "-" + hex[4:]
+	}
+	return hexID(id)
+}
+
+// Parse reads an id written in the encoding, which must have the length
+// Format gives it.
+func (e IDEncoding) Parse(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	switch e {
+	case Base62IDs:
+		return parseBase62(s)
+	case UUIDIDs:
+		if len(s) != uuidIDLen || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return 0, fmt.Errorf("trace: invalid uuid id %q", s)
+		}
+		hex := strings.ReplaceAll(s, "-", "")
+		if _, err := strconv.ParseUint(hex[:16], 16, 64); err != nil {
+			return 0, err
+		}
+		return HexIDs.Parse(hex[16:])
+	}
+	if len(s) != hexIDLen {
+		return 0, fmt.Errorf("trace: invalid hex id %q", s)
+	}
+	id, err := strconv.ParseUint(s, 16, 64)
+	return int64(id), err
+}
+
+// TraceID returns the trace id in the encoding set with SetIDEncoding, 16
+// lowercase hex digits by default. The same string is printed in every
+// output, so it can be handed to end users and looked up in the logs.
+func (tc *TraceContext) TraceID() string {
+	return formatTraceID(tc.traceId)
+}
+
+// SpanID returns the id of the span, unique among the spans of the process
+// and encoded like TraceID.
+func (tc *TraceContext) SpanID() string {
+	return formatSpanID(tc.spanId)
+}
+
+// ParentSpanID returns the span id of the parent of the span, or "" for a
+// root not continuing a span of another process.
+func (tc *TraceContext) ParentSpanID() string {
+	return formatSpanID(tc.parentId)
+}
+
+// TraceID returns the trace id of the record, see TraceContext.TraceID.
+func (r JournalRecord) TraceID() string {
+	return formatTraceID(r.TraceId)
+}
+
+func formatTraceID(id int64) string {
+	return IDEncoding(atomic.LoadInt32(&idEncoding)).Format(id)
+}
+
+// hexID formats id as 16 hex digits whatever the IDEncoding, for protocols
+// requiring them.
+func hexID(id int64) string {
+	s := strconv.FormatUint(uint64(id), 16)
+	if len(s) < 16 {
+		s = strings.Repeat("0", 16-len(s)) + s
+	}
+	return s
+}
+
+// formatSpanID formats span ids like trace ids, 0 means no span.
+func formatSpanID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return formatTraceID(id)
+}
+
+// hexSpanID formats span ids like hexID, 0 means no span.
+func hexSpanID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return hexID(id)
+}
+
+// ParseTraceID parses a trace id returned by TraceID, or a span id, in any
+// IDEncoding, told apart by its length whatever the one set with
+// SetIDEncoding.
+func ParseTraceID(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	switch len(s) {
+	case base62IDLen:
+		return Base62IDs.Parse(s)
+	case uuidIDLen:
+		return UUIDIDs.Parse(s)
+	}
+	return HexIDs.Parse(s)
+}
+
+const base62Digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// formatBase62 writes id as 11 base62 digits, enough for any uint64.
+func formatBase62(id uint64) string {
+	var buf [base62IDLen]byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = base62Digits[id%62]
+		id /= 62
+	}
+	return string(buf[:])
+}
+
+func parseBase62(s string) (int64, error) {
+	if len(s) != base62IDLen {
+		return 0, fmt.Errorf("trace: invalid base62 id %q", s)
+	}
+	var id uint64
+	for i := 0; i < len(s); i++ {
+		d := strings.IndexByte(base62Digits, s[i])
+		if d < 0 {
+			return 0, fmt.Errorf("trace: invalid base62 id %q", s)
+		}
+		next := id*62 + uint64(d)
+		if id > math.MaxUint64/62 || next < id*62 {
+			return 0, fmt.Errorf("trace: base62 id %q out of range", s)
+		}
+		id = next
+	}
+	return int64(id), nil
+}
@@ -0,0 +1,66 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIDEncoding(t *testing.T) {
+	for _, id := range []int64{1, 0x17cb5b99f8638000, -1, math.MaxInt64} {
+		for _, e := range []IDEncoding{HexIDs, Base62IDs, UUIDIDs} {
+			s := e.Format(id)
+			if got, err := e.Parse(s); err != nil || got != id {
+				t.Fatalf("%d in encoding %d: %q parsed as %d, %v", id, e, s, got, err)
+			}
+			if got, err := ParseTraceID(s); err != nil || got != id {
+				t.Fatalf("ParseTraceID(%q) = %d, %v, want %d", s, got, err, id)
+			}
+		}
+	}
+	if s := Base62IDs.Format(-1); s != "LygHa16AHYF" {
+		t.Fatalf("unexpected base62 id %q", s)
+	}
+	if s := UUIDIDs.Format(0x17cb5b99f8638000); s != "00000000-0000-0000-17cb-5b99f8638000" {
+		t.Fatalf("unexpected uuid id %q", s)
+	}
+	for _, s := range []string{"", "LygHa16AHYG", "zzzzzzzzzzzz", "not-a-uuid"} {
+		if _, err := ParseTraceID(s); err == nil {
+			t.Fatalf("%q should not parse", s)
+		}
+	}
+}
+
+func TestSetIDEncoding(t *testing.T) {
+	defer SetIDEncoding(HexIDs)
+	SetIDEncoding(Base62IDs)
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), Formatted(buf, FormatOTLP))
+	tc.Info("x")
+	if id := tc.TraceID(); len(id) != 11 || !strings.Contains(tc.Snapshot().TraceId, id) {
+		t.Fatalf("unexpected trace id %q", id)
+	}
+	tc.Log()
+	if hex := hexID(tc.traceId); !strings.Contains(buf.String(), `"traceId":"0000000000000000`+hex+`"`) {
+		t.Fatalf("OTLP needs hex ids: %s", buf.String())
+	}
+}
+
+func TestParseTraceID_otherEncoding(t *testing.T) {
+	defer SetIDEncoding(HexIDs)
+	for _, active := range []IDEncoding{HexIDs, Base62IDs, UUIDIDs} {
+		SetIDEncoding(active)
+		for _, id := range []int64{26, 98, 0x17cb5b99f8638000, -1} {
+			for _, e := range []IDEncoding{HexIDs, Base62IDs, UUIDIDs} {
+				if got, err := ParseTraceID(e.Format(id)); err != nil || got != id {
+					t.Fatalf("%q written in encoding %d read as %d with %d active, %v", e.Format(id), e, got, active, err)
+				}
+			}
+		}
+	}
+	if _, err := HexIDs.Parse("1a"); err == nil {
+		t.Fatal("a hex id must have 16 digits")
+	}
+}
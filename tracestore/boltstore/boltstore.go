@@ -0,0 +1,129 @@
+// Package boltstore is a tracestore.Backend persisting traces to a BoltDB
+// file.
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/tracestore"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	tracesBucket = []byte("traces") // trace id -> JSON encoded trace
+	startBucket  = []byte("start")  // start time + trace id -> trace id
+)
+
+// Backend stores traces in a BoltDB database.
+type Backend struct {
+	db *bolt.DB
+}
+
+// Open opens (or creates) the database file at path.
+func Open(path string) (*Backend, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	b, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// New stores traces in db, which stays owned by the caller.
+func New(db *bolt.DB) (*Backend, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(tracesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(startBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Backend{db: db}, nil
+}
+
+// Close closes the database.
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
+
+// startKey sorts traces by start time.
+func startKey(start time.Time, traceID string) []byte {
+	key := make([]byte, 8, 8+len(traceID))
+	binary.BigEndian.PutUint64(key, uint64(start.UnixNano()))
+	return append(key, traceID...)
+}
+
+func (b *Backend) Put(t trace.TraceData) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		traces, start := tx.Bucket(tracesBucket), tx.Bucket(startBucket)
+		if old := traces.Get([]byte(t.TraceId)); old != nil {
+			var prev trace.TraceData
+			if err := json.Unmarshal(old, &prev); err == nil {
+				if err := start.Delete(startKey(prev.Start, prev.TraceId)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := traces.Put([]byte(t.TraceId), data); err != nil {
+			return err
+		}
+		return start.Put(startKey(t.Start, t.TraceId), []byte(t.TraceId))
+	})
+}
+
+func (b *Backend) Get(traceID string) (trace.TraceData, error) {
+	var t trace.TraceData
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(tracesBucket).Get([]byte(traceID))
+		if data == nil {
+			return tracestore.ErrNotFound
+		}
+		return json.Unmarshal(data, &t)
+	})
+	return t, err
+}
+
+// Range walks the start time index backwards. fn is called inside a read
+// transaction.
+func (b *Backend) Range(from, to time.Time, fn func(t trace.TraceData) bool) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		traces := tx.Bucket(tracesBucket)
+		c := tx.Bucket(startBucket).Cursor()
+		var k, id []byte
+		if to.IsZero() {
+			k, id = c.Last()
+		} else if k, _ = c.Seek(startKey(to, "")); k == nil {
+			k, id = c.Last()
+		} else {
+			k, id = c.Prev()
+		}
+		for ; k != nil; k, id = c.Prev() {
+			started := time.Unix(0, int64(binary.BigEndian.Uint64(k[:8])))
+			if !from.IsZero() && started.Before(from) {
+				return nil
+			}
+			var t trace.TraceData
+			if err := json.Unmarshal(traces.Get(id), &t); err != nil {
+				return err
+			}
+			if !fn(t) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
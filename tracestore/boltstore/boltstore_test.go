@@ -0,0 +1,69 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/tracestore"
+)
+
+func TestBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.db")
+	backend, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := tracestore.New(backend)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		tc := trace.NewTraceContext(context.Background(), store.Logger())
+		tc.Trace().Info("loaded")
+		if i == 1 {
+			_ = tc.Error(errors.New("timeout"))
+		}
+		tc.Log()
+		ids = append(ids, tc.TraceID())
+	}
+	if err := backend.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the traces outlive the process
+	backend, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	store = tracestore.New(backend)
+
+	got, err := store.Get(ids[1])
+	if err != nil || got.TraceId != ids[1] || !got.HasError() || len(got.Children) != 1 {
+		t.Fatalf("unexpected trace %+v, %v", got, err)
+	}
+	if _, err := store.Get("nowhere"); !errors.Is(err, tracestore.ErrNotFound) {
+		t.Fatalf("unknown id should not be found, got %v", err)
+	}
+
+	all, err := store.Query(tracestore.Query{})
+	if err != nil || len(all) != 3 || all[0].TraceId != ids[2] || all[2].TraceId != ids[0] {
+		t.Fatalf("query should return the stored traces newest first: %+v, %v", all, err)
+	}
+	if res, _ := store.Query(tracestore.Query{ErrorsOnly: true}); len(res) != 1 || res[0].TraceId != ids[1] {
+		t.Fatalf("unexpected failed traces %+v", res)
+	}
+	if res, _ := store.Query(tracestore.Query{From: got.Start, To: all[0].Start}); len(res) != 1 || res[0].TraceId != ids[1] {
+		t.Fatalf("unexpected traces in the time range %+v", res)
+	}
+
+	// storing a trace again replaces it
+	got.Label = "replaced"
+	if err := backend.Put(got); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := store.Query(tracestore.Query{}); len(all) != 3 || all[1].Label != "replaced" {
+		t.Fatalf("the trace should be replaced in place: %+v", all)
+	}
+}
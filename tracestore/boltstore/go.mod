@@ -0,0 +1,12 @@
+module github.com/mucolud/trace/tracestore/boltstore
+
+go 1.20
+
+require (
+	github.com/mucolud/trace v0.0.0
+	go.etcd.io/bbolt v1.3.7
+)
+
+require golang.org/x/sys v0.4.0 // indirect
+
+replace github.com/mucolud/trace => ../../
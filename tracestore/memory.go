@@ -0,0 +1,80 @@
+package tracestore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mucolud/trace"
+)
+
+// Memory is a Backend keeping the last traces in a ring buffer. Once full,
+// every new trace evicts the oldest one.
+type Memory struct {
+	mux   sync.RWMutex
+	ring  []trace.TraceData
+	next  int // slot of the next trace
+	count int
+	index map[string]int // trace id -> slot
+}
+
+// NewMemory returns a ring buffer holding up to size traces.
+func NewMemory(size int) *Memory {
+	if size <= 0 {
+		size = 1
+	}
+	return &Memory{
+		ring:  make([]trace.TraceData, size),
+		index: make(map[string]int, size),
+	}
+}
+
+func (m *Memory) Put(t trace.TraceData) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if i, ok := m.index[t.TraceId]; ok {
+		m.ring[i] = t
+		return nil
+	}
+	if m.count == len(m.ring) {
+		delete(m.index, m.ring[m.next].TraceId)
+	} else {
+		m.count++
+	}
+	m.ring[m.next] = t
+	m.index[t.TraceId] = m.next
+	m.next = (m.next + 1) % len(m.ring)
+	return nil
+}
+
+func (m *Memory) Get(traceID string) (trace.TraceData, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	i, ok := m.index[traceID]
+	if !ok {
+		return trace.TraceData{}, ErrNotFound
+	}
+	return m.ring[i], nil
+}
+
+// Range visits the traces newest first in the order they were stored.
+func (m *Memory) Range(from, to time.Time, fn func(t trace.TraceData) bool) error {
+	m.mux.RLock()
+	traces := make([]trace.TraceData, 0, m.count)
+	for i := 1; i <= m.count; i++ {
+		t := m.ring[(m.next-i+len(m.ring))%len(m.ring)]
+		if inRange(t.Start, from, to) {
+			traces = append(traces, t)
+		}
+	}
+	m.mux.RUnlock()
+	for _, t := range traces {
+		if !fn(t) {
+			break
+		}
+	}
+	return nil
+}
+
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
@@ -0,0 +1,102 @@
+// Package redisstore is a tracestore.Backend keeping traces in Redis, so
+// several instances of a service share one store.
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/tracestore"
+	"github.com/redis/go-redis/v9"
+)
+
+// Backend stores every trace as a JSON string with an expiry, and indexes
+// them by start time in a sorted set.
+type Backend struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// New stores traces under keys starting with prefix. Traces expire after
+// ttl, 0 keeps them forever.
+func New(client redis.UniversalClient, prefix string, ttl time.Duration) *Backend {
+	return &Backend{client: client, prefix: prefix, ttl: ttl}
+}
+
+func (b *Backend) key(traceID string) string {
+	return b.prefix + "trace:" + traceID
+}
+
+func (b *Backend) index() string {
+	return b.prefix + "traces"
+}
+
+func (b *Backend) Put(t trace.TraceData) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	pipe := b.client.TxPipeline()
+	pipe.Set(ctx, b.key(t.TraceId), data, b.ttl)
+	pipe.ZAdd(ctx, b.index(), redis.Z{Score: float64(t.Start.UnixNano()), Member: t.TraceId})
+	if b.ttl > 0 {
+		// forget index entries of expired traces
+		expired := strconv.FormatInt(time.Now().Add(-b.ttl).UnixNano(), 10)
+		pipe.ZRemRangeByScore(ctx, b.index(), "-inf", "("+expired)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (b *Backend) Get(traceID string) (trace.TraceData, error) {
+	var t trace.TraceData
+	data, err := b.client.Get(context.Background(), b.key(traceID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return t, tracestore.ErrNotFound
+	}
+	if err != nil {
+		return t, err
+	}
+	err = json.Unmarshal(data, &t)
+	return t, err
+}
+
+// Range pages through the start time index, 100 traces at a time.
+func (b *Backend) Range(from, to time.Time, fn func(t trace.TraceData) bool) error {
+	by := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Count: 100}
+	if !from.IsZero() {
+		by.Min = strconv.FormatInt(from.UnixNano(), 10)
+	}
+	if !to.IsZero() {
+		by.Max = "(" + strconv.FormatInt(to.UnixNano(), 10)
+	}
+	ctx := context.Background()
+	for {
+		ids, err := b.client.ZRevRangeByScore(ctx, b.index(), by).Result()
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			t, err := b.Get(id)
+			if errors.Is(err, tracestore.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !fn(t) {
+				return nil
+			}
+		}
+		if len(ids) < int(by.Count) {
+			return nil
+		}
+		by.Offset += by.Count
+	}
+}
@@ -0,0 +1,59 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/tracestore"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBackend(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	backend := New(client, "app:", time.Hour)
+	store := tracestore.New(backend)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		tc := trace.NewTraceContext(context.Background(), store.Logger())
+		tc.Trace().Info("loaded")
+		if i == 1 {
+			_ = tc.Error(errors.New("timeout"))
+		}
+		tc.Log()
+		ids = append(ids, tc.TraceID())
+	}
+
+	got, err := store.Get(ids[1])
+	if err != nil || got.TraceId != ids[1] || !got.HasError() || len(got.Children) != 1 {
+		t.Fatalf("unexpected trace %+v, %v", got, err)
+	}
+	if _, err := store.Get("nowhere"); !errors.Is(err, tracestore.ErrNotFound) {
+		t.Fatalf("unknown id should not be found, got %v", err)
+	}
+	if !server.Exists("app:trace:"+ids[0]) || server.TTL("app:trace:"+ids[0]) != time.Hour {
+		t.Fatalf("traces should be stored under the prefix with the ttl: %v", server.Keys())
+	}
+
+	all, err := store.Query(tracestore.Query{})
+	if err != nil || len(all) != 3 || all[0].TraceId != ids[2] || all[2].TraceId != ids[0] {
+		t.Fatalf("query should return the stored traces newest first: %+v, %v", all, err)
+	}
+	if res, _ := store.Query(tracestore.Query{ErrorsOnly: true}); len(res) != 1 || res[0].TraceId != ids[1] {
+		t.Fatalf("unexpected failed traces %+v", res)
+	}
+	if res, _ := store.Query(tracestore.Query{From: got.Start, To: all[0].Start}); len(res) != 1 || res[0].TraceId != ids[1] {
+		t.Fatalf("unexpected traces in the time range %+v", res)
+	}
+
+	// an expired trace is skipped even while its index entry remains
+	server.Del("app:trace:" + ids[2])
+	if all, _ := store.Query(tracestore.Query{}); len(all) != 2 || all[0].TraceId != ids[1] {
+		t.Fatalf("expired traces should be skipped: %+v", all)
+	}
+}
@@ -0,0 +1,105 @@
+// Package tracestore keeps finished traces so they can be looked up by id or
+// queried later, after they scrolled out of the logs.
+//
+//	store := tracestore.New(tracestore.NewMemory(10000))
+//	tc := trace.NewTraceContext(ctx, trace.MultiLogger(os.Stderr, store.Logger()))
+//	...
+//	failed, _ := store.Query(tracestore.Query{ErrorsOnly: true, Limit: 20})
+package tracestore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/mucolud/trace"
+)
+
+// ErrNotFound is returned by Get when no trace has the requested id.
+var ErrNotFound = errors.New("tracestore: trace not found")
+
+// Backend persists traces.
+type Backend interface {
+	// Put stores t under t.TraceId, replacing an earlier trace with the same
+	// id.
+	Put(t trace.TraceData) error
+	// Get returns the trace with the id, or ErrNotFound.
+	Get(traceID string) (trace.TraceData, error)
+	// Range calls fn for the traces started in [from, to), newest first,
+	// until fn returns false. A zero from or to leaves that end open.
+	Range(from, to time.Time, fn func(t trace.TraceData) bool) error
+}
+
+// Query selects stored traces. Zero fields do not filter.
+type Query struct {
+	From, To   time.Time // the trace started in [From, To)
+	ErrorsOnly bool      // only traces with at least one error
+	Func       string    // only traces with a span whose function contains Func
+	Limit      int       // at most Limit traces, the newest ones
+}
+
+func (q *Query) match(t *trace.TraceData) bool {
+	if q.ErrorsOnly && !t.HasError() {
+		return false
+	}
+	if q.Func != "" && !t.HasFunc(q.Func) {
+		return false
+	}
+	return true
+}
+
+// Store receives finished traces and answers queries over a Backend.
+type Store struct {
+	backend Backend
+}
+
+// New returns a store persisting to backend.
+func New(backend Backend) *Store {
+	return &Store{backend: backend}
+}
+
+// Logger returns the writer to log traces to for them to be stored, on its
+// own or as one of the sinks of trace.MultiLogger.
+func (s *Store) Logger() io.Writer {
+	return trace.Formatted(s, trace.FormatTraceData)
+}
+
+// Write stores the JSON encoded traces in p, as written by Log to a
+// trace.FormatTraceData sink.
+func (s *Store) Write(p []byte) (int, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	for dec.More() {
+		var t trace.TraceData
+		if err := dec.Decode(&t); err != nil {
+			return 0, err
+		}
+		if err := s.Put(t); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Put stores t.
+func (s *Store) Put(t trace.TraceData) error {
+	return s.backend.Put(t)
+}
+
+// Get returns the trace with the id, or ErrNotFound.
+func (s *Store) Get(traceID string) (trace.TraceData, error) {
+	return s.backend.Get(traceID)
+}
+
+// Query returns the traces matching q, newest first.
+func (s *Store) Query(q Query) ([]trace.TraceData, error) {
+	var res []trace.TraceData
+	err := s.backend.Range(q.From, q.To, func(t trace.TraceData) bool {
+		if q.match(&t) {
+			res = append(res, t)
+		}
+		return q.Limit <= 0 || len(res) < q.Limit
+	})
+	return res, err
+}
@@ -0,0 +1,58 @@
+package tracestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mucolud/trace"
+)
+
+func handle(tc *trace.TraceContext, fail bool) {
+	child := tc.Trace()
+	child.Info("loaded")
+	if fail {
+		_ = child.Error(errors.New("timeout"))
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := New(NewMemory(3))
+	var ids []string
+	for i := 0; i < 4; i++ {
+		tc := trace.NewTraceContext(context.Background(), store.Logger())
+		handle(tc, i%2 == 1)
+		tc.Log()
+		ids = append(ids, tc.TraceID())
+	}
+
+	if _, err := store.Get(ids[0]); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("oldest trace should have been evicted, got %v", err)
+	}
+	got, err := store.Get(ids[3])
+	if err != nil || got.TraceId != ids[3] || !got.HasError() || len(got.Children) != 1 {
+		t.Fatalf("unexpected trace %+v, %v", got, err)
+	}
+
+	all, _ := store.Query(Query{})
+	if len(all) != 3 || all[0].TraceId != ids[3] || all[2].TraceId != ids[1] {
+		t.Fatalf("query should return the stored traces newest first: %+v", all)
+	}
+	failed, _ := store.Query(Query{ErrorsOnly: true})
+	if len(failed) != 2 || failed[0].TraceId != ids[3] || failed[1].TraceId != ids[1] {
+		t.Fatalf("unexpected failed traces %+v", failed)
+	}
+	if res, _ := store.Query(Query{Func: "tracestore.TestStore", Limit: 1}); len(res) != 1 || res[0].TraceId != ids[3] {
+		t.Fatalf("unexpected traces by function %+v", res)
+	}
+	if res, _ := store.Query(Query{Func: "nowhere"}); len(res) != 0 {
+		t.Fatalf("no trace should match %+v", res)
+	}
+	if res, _ := store.Query(Query{To: got.Start}); len(res) != 2 {
+		t.Fatalf("the time range should exclude the newest trace: %+v", res)
+	}
+	if res, _ := store.Query(Query{From: time.Now()}); len(res) != 0 {
+		t.Fatalf("no trace started in the future: %+v", res)
+	}
+}
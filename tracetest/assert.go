@@ -0,0 +1,96 @@
+package tracetest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mucolud/trace"
+)
+
+var update = flag.Bool("tracetest.update", false, "rewrite the golden files of AssertGolden")
+
+// AssertErrorCount fails the test unless the trace of tc, its descendants
+// included, recorded exactly n errors.
+func AssertErrorCount(t testing.TB, tc *trace.TraceContext, n int) {
+	t.Helper()
+	data := tc.Snapshot()
+	if got := countErrors(&data); got != n {
+		t.Errorf("trace %s recorded %d errors, want %d", data.TraceId, got, n)
+	}
+}
+
+func countErrors(t *trace.TraceData) int {
+	n := len(t.Errors)
+	for i := range t.Children {
+		n += countErrors(&t.Children[i])
+	}
+	return n
+}
+
+// AssertSpan fails the test unless tc or one of its descendants is a span
+// of the function name, given in full or from its package on, e.g.
+// "orders.(*Service).Place". It returns the first such span.
+func AssertSpan(t testing.TB, tc *trace.TraceContext, name string) trace.TraceData {
+	t.Helper()
+	data := tc.Snapshot()
+	if span, ok := findSpan(&data, name); ok {
+		return *span
+	}
+	t.Errorf("trace %s has no span %s", data.TraceId, name)
+	return trace.TraceData{}
+}
+
+func findSpan(t *trace.TraceData, name string) (*trace.TraceData, bool) {
+	if t.Func == name || strings.HasSuffix(t.Func, "/"+name) {
+		return t, true
+	}
+	for i := range t.Children {
+		if span, ok := findSpan(&t.Children[i], name); ok {
+			return span, true
+		}
+	}
+	return nil, false
+}
+
+// ansi matches the color codes of the tree.
+var ansi = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// Format renders the trace of tc as the tree Log writes, without colors and
+// with the trace id replaced by "<trace>", so it can be compared across
+// runs. Use a Clock for the times to be stable as well.
+func Format(tc *trace.TraceContext, opts ...trace.Option) string {
+	data := tc.Snapshot()
+	buf := &bytes.Buffer{}
+	_ = trace.WriteTrace(buf, &data, opts...)
+	out := ansi.ReplaceAllString(buf.String(), "")
+	return strings.ReplaceAll(strings.TrimLeft(out, "\n"), data.TraceId, "<trace>") + "\n"
+}
+
+// AssertGolden fails the test unless Format(tc, opts...) matches the
+// content of the golden file at path. Run the tests with -tracetest.update
+// to write the current output to the file instead.
+func AssertGolden(t testing.TB, tc *trace.TraceContext, path string, opts ...trace.Option) {
+	t.Helper()
+	got := Format(tc, opts...)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run with -tracetest.update to create it", err)
+	}
+	if got != string(want) {
+		t.Errorf("trace does not match %s, run with -tracetest.update to accept it\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
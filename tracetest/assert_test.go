@@ -0,0 +1,57 @@
+package tracetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mucolud/trace"
+)
+
+func checkout(tc *trace.TraceContext) {
+	tc.Info("cart", 3)
+	pay(tc)
+}
+
+func pay(tc *trace.TraceContext) {
+	tc = tc.Trace()
+	tc.Event("charge", "amount", 12.5)
+	_ = tc.Error(errors.New("card declined"))
+}
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder()
+	for i := 0; i < 2; i++ {
+		tc := trace.NewTraceContext(context.Background(), rec.Logger())
+		checkout(tc)
+		tc.Log()
+	}
+	last := rec.Last()
+	if traces := rec.Traces(); len(traces) != 2 || !last.HasError() || !last.HasFunc("tracetest.pay") {
+		t.Fatalf("unexpected traces %+v", traces)
+	}
+	rec.Reset()
+	if len(rec.Traces()) != 0 {
+		t.Fatalf("reset should forget the traces")
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	clock := NewClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	tc := trace.NewTraceContext(context.Background(), nil, trace.WithClock(clock))
+	checkout(tc)
+
+	AssertErrorCount(t, tc, 1)
+	if span := AssertSpan(t, tc, "tracetest.pay"); len(span.Events) != 1 {
+		t.Fatalf("unexpected span %+v", span)
+	}
+	AssertGolden(t, tc, "testdata/checkout.golden")
+
+	rec := &failRecorder{TB: t}
+	AssertErrorCount(rec, tc, 0)
+	AssertSpan(rec, tc, "tracetest.refund")
+	if rec.failures != 2 {
+		t.Fatalf("expected 2 failures, got %d", rec.failures)
+	}
+}
@@ -0,0 +1,40 @@
+// Package tracetest provides utilities for testing code instrumented with
+// the trace package.
+package tracetest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a manual clock implementing trace.Clock. Time only moves when
+// Advance or Set is called.
+type Clock struct {
+	mux sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a clock stopped at start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t.
+func (c *Clock) Set(t time.Time) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.now = t
+}
@@ -0,0 +1,25 @@
+package tracetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mucolud/trace"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := NewClock(start)
+	tc := trace.NewTraceContext(context.Background(), nil, trace.WithClock(clock))
+	clock.Advance(1500 * time.Millisecond)
+	tc.Info("later")
+
+	if want, _ := trace.ParseTraceID(tc.TraceID()); want != start.UnixNano() {
+		t.Fatalf("trace id should come from the clock")
+	}
+	entries := tc.Entries()
+	if got := entries[0].Time.Sub(start); got != 1500*time.Millisecond {
+		t.Fatalf("want node 1.5s after start, got %v", got)
+	}
+}
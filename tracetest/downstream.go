@@ -0,0 +1,105 @@
+package tracetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mucolud/trace"
+)
+
+// PropagationHeaders are the headers a Downstream echoes back to the caller.
+var PropagationHeaders = []string{trace.TraceIDHeader, trace.SpanIDHeader}
+
+// Request is a request received by a Downstream.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+}
+
+// Downstream is a fake HTTP dependency. It records every request, echoes the
+// propagation headers back in the response headers and answers with the
+// received headers as a JSON body, so tests can check end-to-end that the
+// trace is passed on to the services an application calls.
+type Downstream struct {
+	*httptest.Server
+
+	mux      sync.Mutex
+	status   int
+	requests []Request
+}
+
+// NewDownstream starts a fake downstream answering 200 OK. Close it when done.
+func NewDownstream() *Downstream {
+	d := &Downstream{status: http.StatusOK}
+	d.Server = httptest.NewServer(http.HandlerFunc(d.serve))
+	return d
+}
+
+func (d *Downstream) serve(w http.ResponseWriter, r *http.Request) {
+	d.mux.Lock()
+	d.requests = append(d.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+	})
+	status := d.status
+	d.mux.Unlock()
+
+	for _, h := range PropagationHeaders {
+		if v := r.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(r.Header)
+}
+
+// SetStatus makes the downstream answer with status, e.g. to exercise the
+// error paths of the caller.
+func (d *Downstream) SetStatus(status int) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.status = status
+}
+
+// Requests returns the requests received so far.
+func (d *Downstream) Requests() []Request {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return append([]Request(nil), d.requests...)
+}
+
+// Reset forgets the recorded requests.
+func (d *Downstream) Reset() {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.requests = nil
+}
+
+// AssertPropagated fails the test unless the downstream received at least
+// one request and every request carried the trace id of tc.
+func (d *Downstream) AssertPropagated(t testing.TB, tc *trace.TraceContext) {
+	t.Helper()
+	d.AssertTraceID(t, tc.TraceID())
+}
+
+// AssertTraceID fails the test unless the downstream received at least one
+// request and every request carried traceID.
+func (d *Downstream) AssertTraceID(t testing.TB, traceID string) {
+	t.Helper()
+	requests := d.Requests()
+	if len(requests) == 0 {
+		t.Errorf("downstream received no request")
+		return
+	}
+	for _, r := range requests {
+		if got := r.Header.Get(trace.TraceIDHeader); got != traceID {
+			t.Errorf("%s %s: %s header is %q, want %q", r.Method, r.Path, trace.TraceIDHeader, got, traceID)
+		}
+	}
+}
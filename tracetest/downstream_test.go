@@ -0,0 +1,56 @@
+package tracetest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mucolud/trace"
+)
+
+func TestDownstream(t *testing.T) {
+	d := NewDownstream()
+	defer d.Close()
+
+	tc := trace.NewTraceContext(context.Background(), nil)
+	req, _ := http.NewRequest(http.MethodGet, d.URL+"/users/1", nil)
+	req.Header.Set(trace.TraceIDHeader, tc.TraceID())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.Header.Get(trace.TraceIDHeader) != tc.TraceID() {
+		t.Fatalf("trace id not echoed")
+	}
+	d.AssertPropagated(t, tc)
+	if r := d.Requests(); len(r) != 1 || r[0].Path != "/users/1" {
+		t.Fatalf("unexpected requests %+v", r)
+	}
+
+	// a request without the header must be reported
+	d.Reset()
+	resp, err = http.Get(d.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	rec := &failRecorder{TB: t}
+	d.AssertPropagated(rec, tc)
+	if rec.failures == 0 {
+		t.Fatalf("missing header not detected")
+	}
+}
+
+// failRecorder records failures instead of failing the test.
+type failRecorder struct {
+	testing.TB
+	failures int
+}
+
+func (r *failRecorder) Helper() {}
+
+func (r *failRecorder) Errorf(format string, args ...interface{}) {
+	r.failures++
+}
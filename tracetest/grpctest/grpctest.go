@@ -0,0 +1,94 @@
+// Package grpctest provides a fake gRPC downstream recording the metadata it
+// receives, to check trace propagation in integration tests.
+package grpctest
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mucolud/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Call is a call received by a Downstream.
+type Call struct {
+	Method   string
+	Metadata metadata.MD
+}
+
+// Downstream is a fake gRPC server accepting any method. Every call is
+// recorded, the propagation metadata is echoed back in the response header
+// and an empty message is returned, which decodes as the zero value of any
+// response type.
+type Downstream struct {
+	Addr string
+
+	server *grpc.Server
+	mux    sync.Mutex
+	calls  []Call
+}
+
+// NewDownstream starts a fake downstream on a random local port.
+func NewDownstream() (*Downstream, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	d := &Downstream{Addr: lis.Addr().String()}
+	d.server = grpc.NewServer(grpc.UnknownServiceHandler(d.handle))
+	go func() {
+		_ = d.server.Serve(lis)
+	}()
+	return d, nil
+}
+
+func (d *Downstream) handle(srv interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	md, _ := metadata.FromIncomingContext(stream.Context())
+
+	d.mux.Lock()
+	d.calls = append(d.calls, Call{Method: method, Metadata: md.Copy()})
+	d.mux.Unlock()
+
+	key := strings.ToLower(trace.TraceIDHeader)
+	if v := md.Get(key); len(v) > 0 {
+		_ = stream.SetHeader(metadata.Pairs(key, v[0]))
+	}
+	if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+		return err
+	}
+	return stream.SendMsg(&emptypb.Empty{})
+}
+
+// Close stops the server.
+func (d *Downstream) Close() {
+	d.server.Stop()
+}
+
+// Calls returns the calls received so far.
+func (d *Downstream) Calls() []Call {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return append([]Call(nil), d.calls...)
+}
+
+// AssertPropagated fails the test unless the downstream received at least
+// one call and every call carried the trace id of tc in its metadata.
+func (d *Downstream) AssertPropagated(t testing.TB, tc *trace.TraceContext) {
+	t.Helper()
+	calls := d.Calls()
+	if len(calls) == 0 {
+		t.Errorf("downstream received no call")
+		return
+	}
+	key := strings.ToLower(trace.TraceIDHeader)
+	for _, c := range calls {
+		if v := c.Metadata.Get(key); len(v) == 0 || v[0] != tc.TraceID() {
+			t.Errorf("%s: %s metadata is %q, want %q", c.Method, key, v, tc.TraceID())
+		}
+	}
+}
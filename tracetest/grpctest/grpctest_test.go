@@ -0,0 +1,67 @@
+package grpctest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mucolud/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// sayHello calls a downstream greeter, propagating the trace of tc.
+func sayHello(tc *trace.TraceContext, conn *grpc.ClientConn, header *metadata.MD) error {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), trace.TraceIDHeader, tc.TraceID())
+	return conn.Invoke(ctx, "/greeter.Greeter/SayHello", &emptypb.Empty{}, &emptypb.Empty{}, grpc.Header(header))
+}
+
+func TestDownstream(t *testing.T) {
+	d, err := NewDownstream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	conn, err := grpc.Dial(d.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	tc := trace.NewTraceContext(context.Background(), nil)
+	var header metadata.MD
+	if err := sayHello(tc, conn, &header); err != nil {
+		t.Fatal(err)
+	}
+	if v := header.Get(strings.ToLower(trace.TraceIDHeader)); len(v) != 1 || v[0] != tc.TraceID() {
+		t.Fatalf("trace id not echoed: %v", header)
+	}
+	d.AssertPropagated(t, tc)
+	if c := d.Calls(); len(c) != 1 || c[0].Method != "/greeter.Greeter/SayHello" {
+		t.Fatalf("unexpected calls %+v", c)
+	}
+
+	// a call without the metadata must be reported
+	if err := conn.Invoke(context.Background(), "/greeter.Greeter/SayHello", &emptypb.Empty{}, &emptypb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	rec := &failRecorder{TB: t}
+	d.AssertPropagated(rec, tc)
+	if rec.failures == 0 {
+		t.Fatalf("missing metadata not detected")
+	}
+}
+
+// failRecorder records failures instead of failing the test.
+type failRecorder struct {
+	testing.TB
+	failures int
+}
+
+func (r *failRecorder) Helper() {}
+
+func (r *failRecorder) Errorf(format string, args ...interface{}) {
+	r.failures++
+}
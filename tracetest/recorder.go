@@ -0,0 +1,71 @@
+package tracetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/mucolud/trace"
+)
+
+// Recorder is an in-memory sink keeping every trace logged to it.
+//
+//	rec := tracetest.NewRecorder()
+//	tc := trace.NewTraceContext(ctx, rec.Logger())
+//	handle(tc)
+//	tc.Log()
+//	if got := rec.Last(); !got.HasError() { ... }
+type Recorder struct {
+	mux    sync.Mutex
+	traces []trace.TraceData
+}
+
+// NewRecorder returns an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Logger returns the writer to pass to trace.NewTraceContext.
+func (r *Recorder) Logger() io.Writer {
+	return trace.Formatted(r, trace.FormatTraceData)
+}
+
+// Write decodes the traces written by Log to the writer returned by Logger.
+func (r *Recorder) Write(p []byte) (int, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	for dec.More() {
+		var t trace.TraceData
+		if err := dec.Decode(&t); err != nil {
+			return 0, err
+		}
+		r.mux.Lock()
+		r.traces = append(r.traces, t)
+		r.mux.Unlock()
+	}
+	return len(p), nil
+}
+
+// Traces returns the recorded traces, oldest first.
+func (r *Recorder) Traces() []trace.TraceData {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return append([]trace.TraceData(nil), r.traces...)
+}
+
+// Last returns the trace recorded last, or the zero TraceData.
+func (r *Recorder) Last() trace.TraceData {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if len(r.traces) == 0 {
+		return trace.TraceData{}
+	}
+	return r.traces[len(r.traces)-1]
+}
+
+// Reset forgets the recorded traces.
+func (r *Recorder) Reset() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.traces = nil
+}
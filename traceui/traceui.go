@@ -0,0 +1,175 @@
+// Package traceui serves a small web UI over a tracestore, listing recent
+// traces and rendering any of them as a tree with collapsible spans. Mount
+// it like net/http/pprof:
+//
+//	http.Handle("/debug/traces/", traceui.Handler(store))
+//
+// InFlightHandler shows the traces still in progress the same way:
+//
+//	http.Handle("/debug/inflight/", traceui.InFlightHandler())
+package traceui
+
+import (
+	"html/template"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/tracestore"
+)
+
+const defaultLimit = 50
+
+// Handler returns the UI for store. The list of traces is served at the path
+// it is mounted on, a single trace at trace?id=<trace id> below it.
+func Handler(store *tracestore.Store) http.Handler {
+	return &handler{store: store}
+}
+
+type handler struct {
+	store *tracestore.Store
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if path.Base(r.URL.Path) == "trace" {
+		h.trace(w, r)
+		return
+	}
+	h.list(w, r)
+}
+
+type listPage struct {
+	Query  tracestore.Query
+	Traces []trace.TraceData
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	q := tracestore.Query{
+		ErrorsOnly: r.FormValue("errors") != "",
+		Func:       r.FormValue("func"),
+		Limit:      defaultLimit,
+	}
+	if n, err := strconv.Atoi(r.FormValue("limit")); err == nil && n > 0 {
+		q.Limit = n
+	}
+	traces, err := h.store.Query(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	render(w, "list", listPage{Query: q, Traces: traces})
+}
+
+func (h *handler) trace(w http.ResponseWriter, r *http.Request) {
+	t, err := h.store.Get(r.FormValue("id"))
+	if err == tracestore.ErrNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	renderTrace(w, t)
+}
+
+// InFlightHandler returns the UI over the traces created with
+// trace.WithInFlight that are still in progress, with their age. Any of them
+// is rendered at trace?id=<trace id> as it is at the time of the request.
+func InFlightHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) == "trace" {
+			t, ok := trace.InFlightTrace(r.FormValue("id"))
+			if !ok {
+				http.Error(w, "no trace in progress with this id, it may have finished", http.StatusNotFound)
+				return
+			}
+			renderTrace(w, t)
+			return
+		}
+		render(w, "inflight", inFlightPage{Now: time.Now(), Traces: trace.InFlight()})
+	})
+}
+
+type inFlightPage struct {
+	Now    time.Time
+	Traces []trace.TraceData
+}
+
+func render(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// tracePage is a trace rendered as the tree of trace.ExportHTML: the root
+// and every subtree with an error are expanded, healthy subtrees collapsed.
+type tracePage struct {
+	trace.TraceData
+	Tree template.HTML
+}
+
+func renderTrace(w http.ResponseWriter, t trace.TraceData) {
+	tree, err := t.HTMLTree("trace?id=")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	render(w, "trace", tracePage{TraceData: t, Tree: tree})
+}
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"failed": func(t trace.TraceData) bool {
+		return t.HasError()
+	},
+	"duration": func(t trace.TraceData) time.Duration {
+		return t.Duration().Round(time.Microsecond)
+	},
+	"age": func(now time.Time, t trace.TraceData) time.Duration {
+		return now.Sub(t.Start).Round(time.Millisecond)
+	},
+	"clock": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05.000")
+	},
+}).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>traces</title>
+<style>
+body{font-family:monospace;margin:1em}
+table{border-collapse:collapse}td,th{padding:2px 8px;text-align:left}
+tr.error{color:#c00}
+</style></head><body>{{end}}
+
+{{define "list"}}{{template "head"}}
+<form>
+<label><input type="checkbox" name="errors" value="1"{{if .Query.ErrorsOnly}} checked{{end}}> errors only</label>
+<input name="func" placeholder="function" value="{{.Query.Func}}">
+<input name="limit" size="4" value="{{.Query.Limit}}">
+<button>filter</button>
+</form>
+<table>
+<tr><th>started</th><th>trace</th><th>function</th><th>duration</th><th>status</th></tr>
+{{range .Traces}}<tr{{if failed .}} class="error"{{end}}>
+<td>{{clock .Start}}</td><td><a href="trace?id={{.TraceId}}">{{.TraceId}}</a></td>
+<td>{{.Func}}</td><td>{{duration .}}</td><td>{{if failed .}}error{{else}}ok{{end}}</td>
+</tr>{{else}}<tr><td colspan="5">no traces</td></tr>{{end}}
+</table></body></html>{{end}}
+
+{{define "inflight"}}{{template "head"}}
+<p>{{len .Traces}} traces in progress</p>
+<table>
+<tr><th>started</th><th>age</th><th>trace</th><th>function</th><th>status</th></tr>
+{{$now := .Now}}{{range .Traces}}<tr{{if failed .}} class="error"{{end}}>
+<td>{{clock .Start}}</td><td>{{age $now .}}</td><td><a href="trace?id={{.TraceId}}">{{.TraceId}}</a></td>
+<td>{{.Func}}</td><td>{{if failed .}}error{{else}}ok{{end}}</td>
+</tr>{{else}}<tr><td colspan="5">no traces in progress</td></tr>{{end}}
+</table></body></html>{{end}}
+
+{{define "trace"}}{{template "head"}}
+<p><a href="./">all traces</a> · trace {{.TraceId}} started {{clock .Start}}{{with .Resource}} · {{.}}{{end}}</p>
+{{.Tree}}
+</body></html>{{end}}
+`))
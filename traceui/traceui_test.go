@@ -0,0 +1,71 @@
+package traceui
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mucolud/trace"
+	"github.com/mucolud/trace/tracestore"
+)
+
+func get(t *testing.T, h http.Handler, url string) (int, string) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestHandler(t *testing.T) {
+	store := tracestore.New(tracestore.NewMemory(10))
+	ok := trace.NewTraceContext(context.Background(), store.Logger())
+	ok.Info("fine")
+	ok.Log()
+	failed := trace.NewTraceContext(context.Background(), store.Logger())
+	_ = failed.Trace().Error(errors.New("<timeout>"))
+	failed.Trace().Info("healthy")
+	failed.Log()
+
+	h := http.StripPrefix("/debug/traces", Handler(store))
+	code, body := get(t, h, "/debug/traces/")
+	if code != http.StatusOK || !strings.Contains(body, ok.TraceID()) || !strings.Contains(body, failed.TraceID()) {
+		t.Fatalf("list should show both traces: %d %s", code, body)
+	}
+	_, body = get(t, h, "/debug/traces/?errors=1")
+	if strings.Contains(body, ok.TraceID()) || !strings.Contains(body, `href="trace?id=`+failed.TraceID()+`"`) {
+		t.Fatalf("errors filter should keep only the failed trace: %s", body)
+	}
+
+	code, body = get(t, h, "/debug/traces/trace?id="+failed.TraceID())
+	if code != http.StatusOK || strings.Count(body, `<details open class="error">`) != 2 ||
+		strings.Count(body, "<details>") != 1 ||
+		!strings.Contains(body, `[&#34;&lt;timeout&gt;&#34;]`) {
+		t.Fatalf("trace should be rendered escaped with only its failed spans open: %d %s", code, body)
+	}
+	if code, _ = get(t, h, "/debug/traces/trace?id=missing"); code != http.StatusNotFound {
+		t.Fatalf("unknown trace should be 404, got %d", code)
+	}
+}
+
+func TestInFlightHandler(t *testing.T) {
+	busy := trace.NewTraceContext(context.Background(), nil, trace.WithInFlight())
+	busy.Trace().Info("waiting on <db>")
+	done := trace.NewTraceContext(context.Background(), nil, trace.WithInFlight())
+	done.Log()
+	defer busy.Log()
+
+	h := http.StripPrefix("/debug/inflight", InFlightHandler())
+	code, body := get(t, h, "/debug/inflight/")
+	if code != http.StatusOK || !strings.Contains(body, `href="trace?id=`+busy.TraceID()+`"`) || strings.Contains(body, done.TraceID()) {
+		t.Fatalf("list should show only the trace in progress: %d %s", code, body)
+	}
+	code, body = get(t, h, "/debug/inflight/trace?id="+busy.TraceID())
+	if code != http.StatusOK || !strings.Contains(body, `[&#34;waiting on &lt;db&gt;&#34;]`) {
+		t.Fatalf("trace should be rendered as it is now: %d %s", code, body)
+	}
+	if code, _ = get(t, h, "/debug/inflight/trace?id="+done.TraceID()); code != http.StatusNotFound {
+		t.Fatalf("finished trace should be 404, got %d", code)
+	}
+}
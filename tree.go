@@ -0,0 +1,60 @@
+package trace
+
+import "strings"
+
+// defaultTreeIndent is the indent of a level of the tree without
+// WithTreeIndent.
+const defaultTreeIndent = 3
+
+// branches hands out the branch glyphs of the items drawn below a span, in
+// order. With WithClosedTree the last one closes the branch.
+type branches struct {
+	left   int
+	closed bool
+}
+
+// next returns the branch of the next item and the guide continuing it on
+// the lines drawn below the item, such as stack frames and the children of
+// a span.
+func (b *branches) next() (branch, guide string) {
+	b.left--
+	if b.closed && b.left == 0 {
+		return "└", " "
+	}
+	return "├", "│"
+}
+
+// treeIndent returns the number of cells a level of the tree is indented by.
+func (tc *TraceContext) treeIndent() int {
+	switch n := tc.opts.treeIndent; {
+	case n == 0:
+		return defaultTreeIndent
+	case n < 2:
+		return 2
+	default:
+		return n
+	}
+}
+
+// childLead returns the lead drawn before the header of a child span, ending
+// with the collapsed chain leading to it, and the prefix of the lines drawn
+// below it.
+func (tc *TraceContext) childLead(prefix, branch, guide, chain string) (lead, childPrefix string) {
+	indent := tc.treeIndent()
+	if !tc.opts.closedTree {
+		return prefix + branch + chain, prefix + strings.Repeat(" ", indent)
+	}
+	lead = prefix + branch + strings.Repeat("─", indent-2) + " " + chain
+	return lead, prefix + guide + strings.Repeat(" ", indent-1)
+}
+
+// visibleChildren returns the children of node drawn in the tree.
+func (tc *TraceContext) visibleChildren(node *spanSnapshot) []*spanSnapshot {
+	res := make([]*spanSnapshot, 0, len(node.children))
+	for _, v := range node.children {
+		if !tc.pruned(v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
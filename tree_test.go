@@ -0,0 +1,72 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var ansi = regexp.MustCompile("\x1b\\[[0-9]*m")
+
+func load(tc *TraceContext) {
+	tc = tc.Trace()
+	tc.Info("key")
+	query(tc, "bob")
+}
+
+// treeLines returns the uncolored lines of the tree logged to buf, without
+// the trace id header and footer.
+func treeLines(buf *bytes.Buffer) []string {
+	out := ansi.ReplaceAllString(buf.String(), "")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return lines[1 : len(lines)-1]
+}
+
+func TestWithClosedTree(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithClosedTree())
+	tc.Info()
+	load(tc)
+	tc.Log()
+
+	lines := treeLines(buf)
+	if len(lines) != 7 {
+		t.Fatalf("want 7 lines, got %d\n%s", len(lines), buf.String())
+	}
+	wants := []string{"", "├> ", "├─ ", "│  ├> ", "│  └─ ", "│     ├> ", "│     └E "}
+	for i, want := range wants {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("line %d = %q, want prefix %q", i, lines[i], want)
+		}
+	}
+	if !strings.HasSuffix(lines[1], ":") {
+		t.Errorf("a node without data should end its line: %q", lines[1])
+	}
+}
+
+func TestWithTreeIndent(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithClosedTree(), WithTreeIndent(5))
+	load(tc)
+	tc.Log()
+
+	lines := treeLines(buf)
+	// the root level is closed by the footer of the trace
+	wants := []string{"", "├─── ", "│    ├> ", "│    └─── ", "│         ├> ", "│         └E "}
+	for i, want := range wants {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("line %d = %q, want prefix %q", i, lines[i], want)
+		}
+	}
+
+	buf.Reset()
+	tc = NewTraceContext(context.Background(), buf, WithTreeIndent(2))
+	load(tc)
+	tc.Log()
+	lines = treeLines(buf)
+	if !strings.HasPrefix(lines[2], "  ├> ") || !strings.HasPrefix(lines[4], "    ├> ") {
+		t.Errorf("want levels indented by 2 cells\n%s", buf.String())
+	}
+}
@@ -0,0 +1,39 @@
+package trace
+
+import "errors"
+
+// UserError is an error whose message is meant for the end user, unlike the
+// internal details of its cause.
+type UserError struct {
+	Code    int
+	Message string
+	Cause   error
+}
+
+// NewUserError returns a UserError with the given code, message and cause.
+func NewUserError(code int, message string, cause error) *UserError {
+	return &UserError{Code: code, Message: message, Cause: cause}
+}
+
+func (e *UserError) Error() string {
+	return e.Message
+}
+
+func (e *UserError) Unwrap() error {
+	return e.Cause
+}
+
+// IsUserError reports whether err or any error it wraps is a *UserError.
+func IsUserError(err error) bool {
+	var ue *UserError
+	return errors.As(err, &ue)
+}
+
+func firstError(params []interface{}) error {
+	for _, v := range params {
+		if err, ok := v.(error); ok && err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestUserError(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	cause := errors.New("duplicate key")
+	err := tc.ErrorCustom("name already taken", cause)
+	if err.Error() != "name already taken,duplicate key" || !IsUserError(err) || !errors.Is(err, cause) {
+		t.Fatalf("unexpected user error %v", err)
+	}
+	if len(tc.Entries()) != 0 {
+		t.Fatalf("ErrorCustom must not record a node")
+	}
+
+	wrapped := fmt.Errorf("create user: %w", err)
+	if got := tc.WrapError(wrapped, "internal error"); got != err {
+		t.Fatalf("WrapError should unwrap to the user error, got %v", got)
+	}
+	internal := tc.Error("db down")
+	if IsUserError(internal) || tc.WrapError(internal, "internal", "retry").Error() != "internal,retry" {
+		t.Fatalf("internal errors should be hidden behind the title")
+	}
+	if tc.WrapError(internal) != internal || tc.WrapError(nil) != nil {
+		t.Fatalf("without a title errors pass through")
+	}
+
+	ue := NewUserError(404, "not found", nil)
+	if !IsUserError(ue) || ue.Code != 404 || errors.Unwrap(ue) != nil {
+		t.Fatalf("unexpected %+v", ue)
+	}
+}
@@ -0,0 +1,43 @@
+package trace
+
+import "errors"
+
+// SkipChildren is returned by a WalkFunc to skip the children of the span
+// it was called for. Walk does not return it.
+var SkipChildren = errors.New("trace: skip children")
+
+// WalkFunc is called by Walk for every span, with its depth below the span
+// the walk started from, 0 for that one. Returning an error other than
+// SkipChildren stops the walk.
+type WalkFunc func(span *TraceData, depth int) error
+
+// Walk calls fn for the span and every span below it in pre-order, the
+// order Log prints them in, to build formatters and analyzers on top of
+// TraceData. It returns the error that stopped the walk.
+func (t *TraceData) Walk(fn WalkFunc) error {
+	err := t.walk(fn, 0)
+	if err == SkipChildren {
+		return nil
+	}
+	return err
+}
+
+func (t *TraceData) walk(fn WalkFunc, depth int) error {
+	if err := fn(t, depth); err != nil {
+		return err
+	}
+	for i := range t.Children {
+		err := t.Children[i].walk(fn, depth+1)
+		if err != nil && err != SkipChildren {
+			return err
+		}
+	}
+	return nil
+}
+
+// Walk walks a Snapshot of tc, see TraceData.Walk. The spans fn is called
+// with are copies: the trace goes on unaffected by fn.
+func (tc *TraceContext) Walk(fn WalkFunc) error {
+	data := tc.Snapshot()
+	return data.Walk(fn)
+}
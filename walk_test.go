@@ -0,0 +1,43 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext_Walk(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil)
+	load(tc)
+	tc.Trace(WithSpanName("cache")).Trace(WithSpanName("redis"))
+
+	var visited []string
+	err := tc.Walk(func(span *TraceData, depth int) error {
+		name := span.Func[strings.LastIndex(span.Func, ".")+1:]
+		visited = append(visited, strings.Repeat(" ", depth)+name)
+		if name == "cache" {
+			return SkipChildren
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(visited, "|"); got != "TestTraceContext_Walk| load|  query| cache" {
+		t.Fatalf("unexpected walk %q", got)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = tc.Walk(func(span *TraceData, depth int) error {
+		n++
+		if len(span.Errors) > 0 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 3 {
+		t.Fatalf("the walk should stop at the first error, got %v after %d spans", err, n)
+	}
+}
@@ -0,0 +1,55 @@
+package trace
+
+// Warn records params as a warning: an info of level LevelWarn, rendered
+// with its own glyph and colour. Unlike Info it is recorded under
+// WithEscalation too. With WithWarnThreshold, enough warnings on a span fail
+// it, for soft failures such as a batch where some items were skipped:
+//
+//	for _, item := range batch {
+//		if err := process(item); err != nil {
+//			tc.Warn("skipped", item.ID, err)
+//		}
+//	}
+func (tc *TraceContext) Warn(params ...interface{}) {
+	tc.warn(2, params)
+}
+
+// warn records a warning attributed to the caller skip frames up.
+func (tc *TraceContext) warn(skip int, params []interface{}) {
+	if !tc.enabled(LevelWarn) {
+		return
+	}
+	funcName, line := caller(skip + tc.opts.callerSkip)
+	if !tc.opts.funcsAllowed(funcName) {
+		return
+	}
+	n := node{
+		Level: LevelWarn,
+		Line:  line,
+		Func:  funcName,
+		Time:  tc.now(),
+		Data:  params,
+		seq:   tc.state.nextSeq(),
+	}
+	if !tc.onRecord(&n) {
+		return
+	}
+	// dropped by WithRateLimit or not, a warning counts towards the threshold
+	admitted := tc.admit(&n)
+	tc.mux.Lock()
+	if admitted {
+		tc.infos = append(tc.infos, newNode(n))
+	}
+	tc.warnings++
+	escalated := tc.warnings == tc.opts.warnThreshold
+	tc.mux.Unlock()
+	if escalated {
+		tc.state.markError()
+	}
+}
+
+// warned reports whether the span recorded enough warnings to fail with
+// WithWarnThreshold. tc.mux must be held.
+func (tc *TraceContext) warned() bool {
+	return tc.opts.warnThreshold > 0 && tc.warnings >= tc.opts.warnThreshold
+}
@@ -0,0 +1,67 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+)
+
+func TestTraceContext_Warn(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithEscalation())
+	tc.Info("dropped")
+	tc.Warn("skipped", 7)
+	tc.Log()
+
+	out := ansi.ReplaceAllString(buf.String(), "")
+	if !regexp.MustCompile(`├W \S+:\d+:\["skipped",7\]\n`).MatchString(out) || regexp.MustCompile(`dropped`).MatchString(out) {
+		t.Fatalf("warning not kept under escalation: %s", out)
+	}
+	if entries := tc.Entries(); len(entries) != 1 || entries[0].Level != LevelWarn {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if tc.Status() != StatusOK {
+		t.Fatalf("a warning alone should not fail the span, got %s", tc.Status())
+	}
+}
+
+func TestWithWarnThreshold(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithWarnThreshold(2), WithEscalationBuffer(5), WithErrorOnly())
+	batch := tc.Trace()
+	batch.Info("batch", 3)
+	batch.Warn("skipped", 1)
+	if batch.Status() != StatusOK {
+		t.Fatalf("failed below the threshold: %s", batch.Status())
+	}
+	batch.Warn("skipped", 2)
+	other := tc.Trace()
+	other.Warn("skipped", 3)
+	if batch.Status() != StatusError || other.Status() != StatusOK || tc.Status() != StatusOK {
+		t.Fatalf("unexpected statuses %s %s %s", batch.Status(), other.Status(), tc.Status())
+	}
+	tc.Log()
+
+	out := ansi.ReplaceAllString(buf.String(), "")
+	if !regexp.MustCompile(`(?s)TestWithWarnThreshold \[error\]\n.*\["batch",3\]`).MatchString(out) {
+		t.Fatalf("soft failure should be logged with its details: %s", out)
+	}
+}
+
+func TestWithWarnThreshold_traceData(t *testing.T) {
+	tc := NewTraceContext(context.Background(), nil, WithWarnThreshold(1))
+	tc.Trace().Warn("skipped")
+	data := tc.Snapshot()
+	if !data.Children[0].Warned || data.Warned || !data.HasError() {
+		t.Fatalf("a span failed by warnings should count as failed: %+v", data)
+	}
+	b, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back TraceData
+	if err := back.Unmarshal(b); err != nil || !back.Children[0].Warned {
+		t.Fatalf("warned should round trip: %v", err)
+	}
+}
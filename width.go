@@ -0,0 +1,191 @@
+package trace
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges are the East Asian Wide and Fullwidth ranges, CJK ideographs,
+// kana, hangul, fullwidth forms and emoji, drawn on two terminal cells.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x23E9, 0x23EC},
+	{0x25FD, 0x25FE},
+	{0x2614, 0x2615},
+	{0x26AA, 0x26AB},
+	{0x26BD, 0x26BE},
+	{0x26C4, 0x26C5},
+	{0x2705, 0x2705},
+	{0x274C, 0x274C},
+	{0x2753, 0x2755},
+	{0x2795, 0x2797},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xA960, 0xA97F},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF},
+	{0x20000, 0x3FFFD},
+}
+
+// ambiguousRanges are the East Asian Ambiguous ranges used by the tree:
+// punctuation, arrows, box drawing and shapes, drawn on two cells by
+// terminals set up for CJK locales.
+var ambiguousRanges = [][2]rune{
+	{0x00A7, 0x00A7},
+	{0x00B7, 0x00B7},
+	{0x2018, 0x2019},
+	{0x201C, 0x201D},
+	{0x2020, 0x2022},
+	{0x2026, 0x2026},
+	{0x2030, 0x2030},
+	{0x203B, 0x203B},
+	{0x2190, 0x2199},
+	{0x2460, 0x24E9},
+	{0x2500, 0x257F},
+	{0x25A0, 0x25FC},
+	{0x2605, 0x2606},
+	{0x2640, 0x2642},
+}
+
+func inRanges(r rune, ranges [][2]rune) bool {
+	for _, v := range ranges {
+		if r < v[0] {
+			return false
+		}
+		if r <= v[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// runeWidth returns the number of terminal cells r is drawn on.
+func runeWidth(r rune, ambiguousWide bool) int {
+	switch {
+	case r < 0x20 || r == 0x7F:
+		return 0
+	case r < 0x300:
+		if ambiguousWide && inRanges(r, ambiguousRanges) {
+			return 2
+		}
+		return 1
+	case r == 0x200B || r == 0x200D || r == 0xFEFF,
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case inRanges(r, wideRanges):
+		return 2
+	case ambiguousWide && inRanges(r, ambiguousRanges):
+		return 2
+	}
+	return 1
+}
+
+// displayWidth returns the number of terminal cells s is drawn on. ANSI
+// color sequences take none.
+func displayWidth(s string, ambiguousWide bool) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '[' {
+			j := i + 2
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			i = j + 1
+			continue
+		}
+		if s[i] < utf8.RuneSelf {
+			n += runeWidth(rune(s[i]), ambiguousWide)
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		n += runeWidth(r, ambiguousWide)
+		i += size
+	}
+	return n
+}
+
+// stripColor removes the ANSI color sequences from s, for sinks that are
+// not terminals.
+func stripColor(s string) string {
+	if strings.IndexByte(s, 0x1b) < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '[' {
+			j := i + 2
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			i = j + 1
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// width returns the display width of s in the terminal the trace is
+// configured for.
+func (tc *TraceContext) width(s string) int {
+	return displayWidth(s, tc.opts.ambiguousWide)
+}
+
+// padRight pads s with spaces until it is drawn on width cells.
+func (tc *TraceContext) padRight(s string, width int) string {
+	if n := width - tc.width(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// headerColumn returns the column the markers following the span names line
+// up at with WithAlignedColumns: one past the widest name, peer and tags of
+// the spans drawn from node, whose header starts at indent.
+func (tc *TraceContext) headerColumn(node *spanSnapshot, prefix string, indent int) int {
+	column := indent + tc.width(node.funcName+node.formatArgs()+node.formatLabel()+node.formatKind()+node.formatPeer()+node.formatDependency()+node.formatTags())
+	for _, v := range node.children {
+		if tc.pruned(v) || tc.collapsible(v) {
+			continue
+		}
+		chain, v := tc.chain(v)
+		lead, childPrefix := tc.childLead(prefix, "├", "│", chain)
+		if c := tc.headerColumn(v, childPrefix, tc.width(lead)); c > column {
+			column = c
+		}
+	}
+	return column
+}
+
+// locationWidth returns the width the locations of the nodes of node are
+// padded to with WithAlignedColumns, so their data lines up.
+func (tc *TraceContext) locationWidth(node *spanSnapshot) int {
+	n := 0
+	for _, v := range node.infos {
+		if w := tc.width(v.Func + ":" + strconv.Itoa(v.Line) + ":"); w > n {
+			n = w
+		}
+	}
+	for _, v := range node.errors {
+		if w := tc.width(v.formatCode() + v.Func + ":" + strconv.Itoa(v.Line) + ":"); w > n {
+			n = w
+		}
+	}
+	return n
+}
@@ -0,0 +1,61 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	cases := []struct {
+		s             string
+		ambiguousWide bool
+		want          int
+	}{
+		{"abc", false, 3},
+		{"张三", false, 4},
+		{"ｱ한", false, 3},
+		{"é", false, 1},
+		{"\x1b[31mred\x1b[0m", false, 3},
+		{"├> …", false, 4},
+		{"├> …", true, 6},
+		{"✅ok", false, 4},
+	}
+	for _, c := range cases {
+		if got := displayWidth(c.s, c.ambiguousWide); got != c.want {
+			t.Errorf("displayWidth(%q, %v) = %d, want %d", c.s, c.ambiguousWide, got, c.want)
+		}
+	}
+}
+
+func query(tc *TraceContext, user string) {
+	tc = tc.Trace().SetTag("user", user)
+	tc.Info("名前", user)
+	_ = tc.ErrorCode(404, "not found")
+}
+
+func TestWithAlignedColumns(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := NewTraceContext(context.Background(), buf, WithAlignedColumns())
+	query(tc, "张三")
+	query(tc, "bob")
+	tc.Log()
+
+	var columns []int
+	var markers []int
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if i := strings.Index(line, "[error]"); i >= 0 {
+			markers = append(markers, displayWidth(line[:i], false))
+		}
+		if i := strings.Index(line, `["`); i >= 0 {
+			columns = append(columns, displayWidth(line[:i], false))
+		}
+	}
+	if len(markers) != 2 || markers[0] != markers[1] {
+		t.Fatalf("span markers should line up: %v\n%s", markers, buf.String())
+	}
+	if len(columns) != 4 || columns[0] != columns[1] || columns[2] != columns[3] {
+		t.Fatalf("node data should line up: %v\n%s", columns, buf.String())
+	}
+}
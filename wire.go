@@ -0,0 +1,783 @@
+package trace
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errWireTruncated = errors.New("trace: truncated protobuf message")
+
+// Marshal encodes t in the protobuf wire format defined by trace.proto.
+//
+// Params and attributes are sent as strings, integers, floats, booleans or
+// nulls; values of any other type are sent as their JSON encoding.
+// Unmarshal(Marshal(t)) reproduces t as long as its integer values are ints
+// and its other values are floats, strings, booleans, nulls or decoded JSON.
+func (t *TraceData) Marshal() ([]byte, error) {
+	w := &wireWriter{}
+	if err := w.span(t); err != nil {
+		return nil, err
+	}
+	return w.b, nil
+}
+
+// Unmarshal decodes a trace encoded by Marshal into t, replacing its
+// content. Times are decoded in UTC, integer values as int.
+func (t *TraceData) Unmarshal(b []byte) error {
+	*t = TraceData{}
+	return readSpan(b, t)
+}
+
+type wireWriter struct {
+	b []byte
+}
+
+func (w *wireWriter) tag(field, wire int) {
+	w.b = binary.AppendUvarint(w.b, uint64(field)<<3|uint64(wire))
+}
+
+func (w *wireWriter) varint(field int, v uint64) {
+	if v != 0 {
+		w.tag(field, wireVarint)
+		w.b = binary.AppendUvarint(w.b, v)
+	}
+}
+
+func (w *wireWriter) int(field int, v int) {
+	w.varint(field, uint64(int64(v)))
+}
+
+func (w *wireWriter) bool(field int, v bool) {
+	if v {
+		w.varint(field, 1)
+	}
+}
+
+func (w *wireWriter) time(field int, t time.Time) {
+	if !t.IsZero() {
+		w.tag(field, wireFixed64)
+		w.b = binary.LittleEndian.AppendUint64(w.b, uint64(t.UnixNano()))
+	}
+}
+
+func (w *wireWriter) bytes(field int, b []byte) {
+	w.tag(field, wireBytes)
+	w.b = binary.AppendUvarint(w.b, uint64(len(b)))
+	w.b = append(w.b, b...)
+}
+
+func (w *wireWriter) string(field int, s string) {
+	if s != "" {
+		w.tag(field, wireBytes)
+		w.b = binary.AppendUvarint(w.b, uint64(len(s)))
+		w.b = append(w.b, s...)
+	}
+}
+
+// message encodes a sub message with fn.
+func (w *wireWriter) message(field int, fn func(w *wireWriter) error) error {
+	sub := &wireWriter{}
+	if err := fn(sub); err != nil {
+		return err
+	}
+	w.bytes(field, sub.b)
+	return nil
+}
+
+func (w *wireWriter) span(t *TraceData) error {
+	w.string(1, t.TraceId)
+	w.string(2, t.Func)
+	if p := t.Peer; p != nil {
+		_ = w.message(3, func(w *wireWriter) error {
+			w.string(1, p.Host)
+			w.int(2, p.Port)
+			w.string(3, p.Service)
+			return nil
+		})
+	}
+	if err := w.valueMap(4, t.Tags); err != nil {
+		return err
+	}
+	w.time(5, t.Start)
+	w.time(6, t.End)
+	w.bool(7, t.Running)
+	w.string(8, t.Cause)
+	w.int(9, t.Suppressed)
+	w.int(10, int(t.Status))
+	for _, group := range []struct {
+		field int
+		nodes []NodeData
+	}{{11, t.Infos}, {12, t.Errors}, {13, t.Events}} {
+		for i := range group.nodes {
+			n := &group.nodes[i]
+			if err := w.message(group.field, func(w *wireWriter) error { return w.node(n) }); err != nil {
+				return err
+			}
+		}
+	}
+	for _, v := range t.Violations {
+		w.bytes(14, []byte(v))
+	}
+	w.string(16, t.SpanId)
+	w.string(17, t.ParentSpanId)
+	w.bool(18, t.Slow)
+	w.bool(27, t.Warned)
+	w.int(21, int(t.Budget))
+	w.int(23, int(t.Kind))
+	w.string(25, t.Label)
+	for _, v := range t.Args {
+		v := v
+		err := w.message(24, func(w *wireWriter) error {
+			w.string(1, v.Name)
+			return w.message(2, func(w *wireWriter) error { return w.value(v.Value) })
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if d := t.Dependency; d != nil {
+		_ = w.message(22, func(w *wireWriter) error {
+			w.string(1, d.Name)
+			w.string(2, string(d.Kind))
+			w.string(3, d.Target)
+			return nil
+		})
+	}
+	if res := t.Resource; res != nil {
+		err := w.message(20, func(w *wireWriter) error {
+			w.string(1, res.Service)
+			w.string(2, res.Version)
+			w.string(3, res.Environment)
+			w.string(4, res.Host)
+			w.int(5, res.PID)
+			return w.valueMap(6, res.Attrs)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for i := range t.Links {
+		l := &t.Links[i]
+		err := w.message(19, func(w *wireWriter) error {
+			w.string(1, l.TraceId)
+			w.time(2, l.Time)
+			return w.valueMap(3, l.Attrs)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for i := range t.Attachments {
+		a := &t.Attachments[i]
+		_ = w.message(26, func(w *wireWriter) error {
+			w.string(1, a.Name)
+			w.string(2, a.ContentType)
+			w.time(3, a.Time)
+			w.int(4, a.Size)
+			w.bytes(5, a.Data)
+			return nil
+		})
+	}
+	for i := range t.Children {
+		child := &t.Children[i]
+		if err := w.message(15, func(w *wireWriter) error { return w.span(child) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *wireWriter) node(n *NodeData) error {
+	w.int(1, int(n.Level))
+	w.int(2, n.Code)
+	w.string(3, string(n.Category))
+	w.string(4, n.Func)
+	w.int(5, n.Line)
+	w.string(6, n.Name)
+	w.time(7, n.Time)
+	for _, v := range n.Data {
+		v := v
+		if err := w.message(8, func(w *wireWriter) error { return w.value(v) }); err != nil {
+			return err
+		}
+	}
+	if err := w.valueMap(9, n.Attrs); err != nil {
+		return err
+	}
+	for _, v := range n.Stack {
+		w.bytes(10, []byte(v))
+	}
+	w.int(11, n.Suppressed)
+	w.varint(13, n.Seq)
+	for _, k := range sortedTags(n.Tags) {
+		k := k
+		_ = w.message(12, func(w *wireWriter) error {
+			w.string(1, k)
+			w.string(2, n.Tags[k])
+			return nil
+		})
+	}
+	return nil
+}
+
+// valueMap encodes m as map entries sorted by key, so the encoding is
+// deterministic.
+func (w *wireWriter) valueMap(field int, m map[string]interface{}) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		err := w.message(field, func(w *wireWriter) error {
+			w.string(1, k)
+			return w.message(2, func(w *wireWriter) error { return w.value(m[k]) })
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value encodes a Value. The fields of a oneof are written even when zero.
+func (w *wireWriter) value(v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		w.bytes(1, []byte(val))
+		return nil
+	case bool:
+		w.tag(4, wireVarint)
+		if val {
+			w.b = append(w.b, 1)
+		} else {
+			w.b = append(w.b, 0)
+		}
+		return nil
+	case float64:
+		w.tag(3, wireFixed64)
+		w.b = binary.LittleEndian.AppendUint64(w.b, math.Float64bits(val))
+		return nil
+	case float32:
+		return w.value(float64(val))
+	}
+	if i, ok := wireInt(v); ok {
+		w.tag(2, wireVarint)
+		w.b = binary.AppendVarint(w.b, i)
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("trace: marshal %T: %w", v, err)
+	}
+	w.bytes(5, data)
+	return nil
+}
+
+func wireInt(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), true
+	case int8:
+		return int64(val), true
+	case int16:
+		return int64(val), true
+	case int32:
+		return int64(val), true
+	case int64:
+		return val, true
+	case uint:
+		return int64(val), uint64(val) <= math.MaxInt64
+	case uint8:
+		return int64(val), true
+	case uint16:
+		return int64(val), true
+	case uint32:
+		return int64(val), true
+	case uint64:
+		return int64(val), val <= math.MaxInt64
+	}
+	return 0, false
+}
+
+// wireReader walks the fields of a message.
+type wireReader struct {
+	b []byte
+}
+
+// next reads the next field. It returns field 0 at the end of the message.
+func (r *wireReader) next() (field, wire int, err error) {
+	if len(r.b) == 0 {
+		return 0, 0, nil
+	}
+	key, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(key >> 3), int(key & 7), nil
+}
+
+func (r *wireReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		return 0, errWireTruncated
+	}
+	r.b = r.b[n:]
+	return v, nil
+}
+
+func (r *wireReader) fixed64() (uint64, error) {
+	if len(r.b) < 8 {
+		return 0, errWireTruncated
+	}
+	v := binary.LittleEndian.Uint64(r.b)
+	r.b = r.b[8:]
+	return v, nil
+}
+
+func (r *wireReader) bytes() ([]byte, error) {
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.b)) < n {
+		return nil, errWireTruncated
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b, nil
+}
+
+func (r *wireReader) time() (time.Time, error) {
+	v, err := r.fixed64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(v)).UTC(), nil
+}
+
+func (r *wireReader) int() (int, error) {
+	v, err := r.varint()
+	return int(int64(v)), err
+}
+
+// skip skips a field of an unknown number.
+func (r *wireReader) skip(wire int) error {
+	var err error
+	switch wire {
+	case wireVarint:
+		_, err = r.varint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireFixed32:
+		if len(r.b) < 4 {
+			return errWireTruncated
+		}
+		r.b = r.b[4:]
+	default:
+		return fmt.Errorf("trace: unsupported protobuf wire type %d", wire)
+	}
+	return err
+}
+
+// readFields calls fn for every field of the message b. fn reads the value
+// of the fields it knows and reports whether it did.
+func readFields(b []byte, fn func(r *wireReader, field int) (bool, error)) error {
+	r := &wireReader{b: b}
+	for {
+		field, wire, err := r.next()
+		if err != nil {
+			return err
+		}
+		if field == 0 {
+			return nil
+		}
+		ok, err := fn(r, field)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if err := r.skip(wire); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func readSpan(b []byte, t *TraceData) error {
+	return readFields(b, func(r *wireReader, field int) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			t.TraceId, err = readString(r)
+		case 2:
+			t.Func, err = readString(r)
+		case 3:
+			t.Peer = &Peer{}
+			err = readMessage(r, func(b []byte) error { return readPeer(b, t.Peer) })
+		case 24:
+			var a Arg
+			if err = readMessage(r, func(b []byte) error { return readArg(b, &a) }); err == nil {
+				t.Args = append(t.Args, a)
+			}
+		case 23:
+			var v int
+			v, err = r.int()
+			t.Kind = SpanKind(v)
+		case 22:
+			t.Dependency = &Dependency{}
+			err = readMessage(r, func(b []byte) error { return readDependency(b, t.Dependency) })
+		case 4:
+			t.Tags, err = readMapEntry(r, t.Tags)
+		case 5:
+			t.Start, err = r.time()
+		case 6:
+			t.End, err = r.time()
+		case 7:
+			var v uint64
+			v, err = r.varint()
+			t.Running = v != 0
+		case 8:
+			t.Cause, err = readString(r)
+		case 9:
+			t.Suppressed, err = r.int()
+		case 10:
+			var v int
+			v, err = r.int()
+			t.Status = Status(v)
+		case 11, 12, 13:
+			var n NodeData
+			if err = readMessage(r, func(b []byte) error { return readNode(b, &n) }); err == nil {
+				switch field {
+				case 11:
+					t.Infos = append(t.Infos, n)
+				case 12:
+					t.Errors = append(t.Errors, n)
+				default:
+					t.Events = append(t.Events, n)
+				}
+			}
+		case 14:
+			var v string
+			v, err = readString(r)
+			t.Violations = append(t.Violations, v)
+		case 16:
+			t.SpanId, err = readString(r)
+		case 17:
+			t.ParentSpanId, err = readString(r)
+		case 25:
+			t.Label, err = readString(r)
+		case 18:
+			var v uint64
+			v, err = r.varint()
+			t.Slow = v != 0
+		case 27:
+			var v uint64
+			v, err = r.varint()
+			t.Warned = v != 0
+		case 21:
+			var v int
+			v, err = r.int()
+			t.Budget = time.Duration(v)
+		case 20:
+			t.Resource = &Resource{}
+			err = readMessage(r, func(b []byte) error { return readResource(b, t.Resource) })
+		case 19:
+			var l LinkData
+			if err = readMessage(r, func(b []byte) error { return readLink(b, &l) }); err == nil {
+				t.Links = append(t.Links, l)
+			}
+		case 26:
+			var a Attachment
+			if err = readMessage(r, func(b []byte) error { return readAttachment(b, &a) }); err == nil {
+				t.Attachments = append(t.Attachments, a)
+			}
+		case 15:
+			var child TraceData
+			if err = readMessage(r, func(b []byte) error { return readSpan(b, &child) }); err == nil {
+				t.Children = append(t.Children, child)
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func readResource(b []byte, res *Resource) error {
+	return readFields(b, func(r *wireReader, field int) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			res.Service, err = readString(r)
+		case 2:
+			res.Version, err = readString(r)
+		case 3:
+			res.Environment, err = readString(r)
+		case 4:
+			res.Host, err = readString(r)
+		case 5:
+			res.PID, err = r.int()
+		case 6:
+			res.Attrs, err = readMapEntry(r, res.Attrs)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func readLink(b []byte, l *LinkData) error {
+	return readFields(b, func(r *wireReader, field int) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			l.TraceId, err = readString(r)
+		case 2:
+			l.Time, err = r.time()
+		case 3:
+			l.Attrs, err = readMapEntry(r, l.Attrs)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func readAttachment(b []byte, a *Attachment) error {
+	return readFields(b, func(r *wireReader, field int) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			a.Name, err = readString(r)
+		case 2:
+			a.ContentType, err = readString(r)
+		case 3:
+			a.Time, err = r.time()
+		case 4:
+			a.Size, err = r.int()
+		case 5:
+			var b []byte
+			b, err = r.bytes()
+			a.Data = append([]byte(nil), b...)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func readPeer(b []byte, p *Peer) error {
+	return readFields(b, func(r *wireReader, field int) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			p.Host, err = readString(r)
+		case 2:
+			p.Port, err = r.int()
+		case 3:
+			p.Service, err = readString(r)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func readArg(b []byte, a *Arg) error {
+	return readFields(b, func(r *wireReader, field int) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			a.Name, err = readString(r)
+		case 2:
+			err = readMessage(r, func(b []byte) (err error) { a.Value, err = readValue(b); return err })
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func readDependency(b []byte, d *Dependency) error {
+	return readFields(b, func(r *wireReader, field int) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			d.Name, err = readString(r)
+		case 2:
+			var v string
+			v, err = readString(r)
+			d.Kind = DependencyKind(v)
+		case 3:
+			d.Target, err = readString(r)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func readNode(b []byte, n *NodeData) error {
+	return readFields(b, func(r *wireReader, field int) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			var v int
+			v, err = r.int()
+			n.Level = Level(v)
+		case 2:
+			n.Code, err = r.int()
+		case 3:
+			var v string
+			v, err = readString(r)
+			n.Category = Category(v)
+		case 4:
+			n.Func, err = readString(r)
+		case 5:
+			n.Line, err = r.int()
+		case 6:
+			n.Name, err = readString(r)
+		case 7:
+			n.Time, err = r.time()
+		case 8:
+			var v interface{}
+			if err = readMessage(r, func(b []byte) (err error) { v, err = readValue(b); return err }); err == nil {
+				n.Data = append(n.Data, v)
+			}
+		case 9:
+			n.Attrs, err = readMapEntry(r, n.Attrs)
+		case 10:
+			var v string
+			v, err = readString(r)
+			n.Stack = append(n.Stack, v)
+		case 11:
+			n.Suppressed, err = r.int()
+		case 12:
+			n.Tags, err = readTag(r, n.Tags)
+		case 13:
+			n.Seq, err = r.varint()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func readString(r *wireReader) (string, error) {
+	b, err := r.bytes()
+	return string(b), err
+}
+
+func readMessage(r *wireReader, fn func(b []byte) error) error {
+	b, err := r.bytes()
+	if err != nil {
+		return err
+	}
+	return fn(b)
+}
+
+// readTag reads one entry of a map<string, string> into m.
+func readTag(r *wireReader, m map[string]string) (map[string]string, error) {
+	var key, value string
+	err := readMessage(r, func(b []byte) error {
+		return readFields(b, func(r *wireReader, field int) (bool, error) {
+			var err error
+			switch field {
+			case 1:
+				key, err = readString(r)
+			case 2:
+				value, err = readString(r)
+			default:
+				return false, nil
+			}
+			return true, err
+		})
+	})
+	if err != nil {
+		return m, err
+	}
+	if m == nil {
+		m = make(map[string]string)
+	}
+	m[key] = value
+	return m, nil
+}
+
+// readMapEntry reads one entry of a map<string, Value> into m.
+func readMapEntry(r *wireReader, m map[string]interface{}) (map[string]interface{}, error) {
+	var key string
+	var value interface{}
+	err := readMessage(r, func(b []byte) error {
+		return readFields(b, func(r *wireReader, field int) (bool, error) {
+			var err error
+			switch field {
+			case 1:
+				key, err = readString(r)
+			case 2:
+				err = readMessage(r, func(b []byte) (err error) { value, err = readValue(b); return err })
+			default:
+				return false, nil
+			}
+			return true, err
+		})
+	})
+	if err != nil {
+		return m, err
+	}
+	if m == nil {
+		m = make(map[string]interface{})
+	}
+	m[key] = value
+	return m, nil
+}
+
+func readValue(b []byte) (interface{}, error) {
+	var res interface{}
+	err := readFields(b, func(r *wireReader, field int) (bool, error) {
+		switch field {
+		case 1:
+			s, err := readString(r)
+			res = s
+			return true, err
+		case 2:
+			u, err := r.varint()
+			// zigzag, as written by binary.AppendVarint
+			res = int(int64(u>>1) ^ -int64(u&1))
+			return true, err
+		case 3:
+			u, err := r.fixed64()
+			res = math.Float64frombits(u)
+			return true, err
+		case 4:
+			u, err := r.varint()
+			res = u != 0
+			return true, err
+		case 5:
+			data, err := r.bytes()
+			if err != nil {
+				return true, err
+			}
+			return true, json.Unmarshal(data, &res)
+		}
+		return false, nil
+	})
+	return res, err
+}
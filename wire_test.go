@@ -0,0 +1,75 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTraceDataMarshal(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	tc := NewTraceContext(context.Background(), nil, WithClock(clock), WithStackTrace(2))
+	tc.SetTag("user", "ann")
+	tc.SetTag("attempt", 2)
+	tc.Info("loaded", 42, -7, 1.5, true, nil, "", map[string]interface{}{"nested": []interface{}{1.0, "x"}})
+	child := tc.Trace(WithPeer("db", 5432), WithPeerService("orders"))
+	child.Event("query", "rows", 3, "ok", false)
+	clock.now = clock.now.Add(time.Millisecond)
+	_ = child.ErrorCode(503, errors.New("timeout"))
+	child.SetStatus(StatusCancelled)
+	child.End()
+	tc.Trace()
+
+	data := tc.snapshot().data()
+	b, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got TraceData
+	if err := got.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, data) {
+		want, _ := json.Marshal(data)
+		have, _ := json.Marshal(got)
+		t.Fatalf("round trip changed the trace\nwant %s\ngot  %s", want, have)
+	}
+	again, _ := got.Marshal()
+	if string(again) != string(b) {
+		t.Fatalf("encoding should be deterministic")
+	}
+
+	// fields from newer schema versions are skipped
+	w := &wireWriter{b: b}
+	w.string(99, "future")
+	w.int(98, 7)
+	if err := got.Unmarshal(w.b); err != nil || !reflect.DeepEqual(got, data) {
+		t.Fatalf("unknown fields should be ignored: %v", err)
+	}
+	if err := got.Unmarshal(b[:len(b)-3]); err == nil {
+		t.Fatalf("truncated input should fail")
+	}
+}
+
+func TestTraceDataMarshalTypes(t *testing.T) {
+	type point struct{ X, Y int }
+	data := TraceData{Infos: []NodeData{{Data: []interface{}{int8(-3), uint64(7), float32(0.5), point{1, 2}}}}}
+	b, err := data.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got TraceData
+	if err := got.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{-3, 7, 0.5, map[string]interface{}{"X": 1.0, "Y": 2.0}}
+	if !reflect.DeepEqual(got.Infos[0].Data, want) {
+		t.Fatalf("got %#v, want %#v", got.Infos[0].Data, want)
+	}
+	if _, err := (&TraceData{Tags: map[string]interface{}{"ch": make(chan int)}}).Marshal(); err == nil {
+		t.Fatalf("unencodable values should fail")
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/mucolud/trace/zaptrace
+
+go 1.20
+
+require (
+	github.com/mucolud/trace v0.0.0
+	go.uber.org/zap v1.21.0
+)
+
+require (
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+)
+
+replace github.com/mucolud/trace => ../
@@ -0,0 +1,74 @@
+// Package zaptrace emits finished traces through a zap.Logger.
+package zaptrace
+
+import (
+	"fmt"
+
+	"github.com/mucolud/trace"
+	"go.uber.org/zap"
+)
+
+// Log writes every node of tc as its own structured entry, at info, warn or
+// error level.
+func Log(logger *zap.Logger, tc *trace.TraceContext) {
+	for _, e := range tc.Entries() {
+		fields := []zap.Field{
+			zap.String("trace_id", e.TraceId),
+			zap.String("span_id", e.SpanId),
+			zap.String("span", e.Span),
+			zap.Int("depth", e.Depth),
+			zap.String("caller", e.Func+":"+e.Line),
+			zap.Any("data", e.Data),
+		}
+		if e.ParentSpanId != "" {
+			fields = append(fields, zap.String("parent_span_id", e.ParentSpanId))
+		}
+		if e.Code != 0 {
+			fields = append(fields, zap.Int("code", e.Code), zap.String("category", string(e.Category)))
+		}
+		log(logger, e.Level, message(e), fields)
+	}
+}
+
+// LogAggregated writes the whole trace as a single entry carrying all nodes,
+// at the level of its most severe node.
+func LogAggregated(logger *zap.Logger, tc *trace.TraceContext) {
+	entries := tc.Entries()
+	if len(entries) == 0 {
+		return
+	}
+	level := trace.LevelInfo
+	for _, e := range entries {
+		if e.Level > level {
+			level = e.Level
+		}
+	}
+	log(logger, level, "trace", []zap.Field{
+		zap.String("trace_id", entries[0].TraceId),
+		zap.String("span_id", tc.SpanID()),
+		zap.Any("nodes", entries),
+	})
+}
+
+// log writes msg at the zap level of level.
+func log(logger *zap.Logger, level trace.Level, msg string, fields []zap.Field) {
+	switch {
+	case level >= trace.LevelError:
+		logger.Error(msg, fields...)
+	case level == trace.LevelWarn:
+		logger.Warn(msg, fields...)
+	default:
+		logger.Info(msg, fields...)
+	}
+}
+
+// message uses the first param as the log message, like tc.Info("msg", ...).
+func message(e trace.Entry) string {
+	if len(e.Data) > 0 {
+		if s, ok := e.Data[0].(string); ok {
+			return s
+		}
+		return fmt.Sprint(e.Data[0])
+	}
+	return e.Func
+}
@@ -0,0 +1,65 @@
+package zaptrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mucolud/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLog(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	tc := trace.NewTraceContext(context.Background(), nil)
+	tc.Info("started", 1)
+	child := tc.Trace()
+	child.Warn("skipped", 2)
+	_ = child.ErrorCode(503, errors.New("unavailable"))
+	Log(zap.New(core), tc)
+
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(entries))
+	}
+	for i, want := range []struct {
+		level  zapcore.Level
+		msg    string
+		span   string
+		parent string
+	}{
+		{zapcore.InfoLevel, "started", tc.SpanID(), ""},
+		{zapcore.WarnLevel, "skipped", child.SpanID(), tc.SpanID()},
+		{zapcore.ErrorLevel, "unavailable", child.SpanID(), tc.SpanID()},
+	} {
+		e := entries[i]
+		fields := e.ContextMap()
+		if e.Level != want.level || e.Message != want.msg || fields["trace_id"] != tc.TraceID() || fields["span_id"] != want.span {
+			t.Fatalf("entry %d: unexpected %v %q %v", i, e.Level, e.Message, fields)
+		}
+		if parent, _ := fields["parent_span_id"].(string); parent != want.parent {
+			t.Fatalf("entry %d: want parent span %q, got %q", i, want.parent, parent)
+		}
+	}
+	if fields := entries[2].ContextMap(); fields["code"] != int64(503) {
+		t.Fatalf("the error code should be logged: %v", fields)
+	}
+}
+
+func TestLogAggregated(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	tc := trace.NewTraceContext(context.Background(), nil)
+	tc.Info("started")
+	tc.Warn("slow")
+	LogAggregated(zap.New(core), tc)
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 || entries[0].Level != zapcore.WarnLevel || entries[0].Message != "trace" {
+		t.Fatalf("want a single warn entry, got %+v", entries)
+	}
+	if fields := entries[0].ContextMap(); fields["trace_id"] != tc.TraceID() || fields["span_id"] != tc.SpanID() {
+		t.Fatalf("unexpected ids %v", fields)
+	}
+}
@@ -0,0 +1,83 @@
+package trace
+
+import "reflect"
+
+// ZeroMode controls how nil params, empty strings and zero structs are
+// recorded.
+type ZeroMode int
+
+const (
+	// ZeroKeep records them as they are: "nil" in error text, null or the
+	// zero value dump in log data.
+	ZeroKeep ZeroMode = iota
+	// ZeroSkip drops them.
+	ZeroSkip
+	// ZeroNull records them as an explicit null.
+	ZeroNull
+	// ZeroPlaceholder replaces them with the configured placeholder.
+	ZeroPlaceholder
+)
+
+// DefaultZeroPlaceholder is used by ZeroPlaceholder unless changed with
+// WithZeroPlaceholder.
+const DefaultZeroPlaceholder = "<empty>"
+
+func isZeroParam(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch val := v.(type) {
+	case string:
+		return val == ""
+	case error:
+		// errors are recorded by their text, nil ones as nil
+		return isNilParam(val) || errorText(val) == ""
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return rv.IsNil()
+	case reflect.Struct:
+		return rv.IsZero()
+	}
+	return false
+}
+
+// applyZeroMode rewrites nil and zero params according to the zero mode.
+// params must already be resolved.
+func (tc *TraceContext) applyZeroMode(params []interface{}) []interface{} {
+	mode := tc.opts.zeroMode
+	if mode == ZeroKeep {
+		return params
+	}
+	res := params[:0:0]
+	for _, v := range params {
+		if !isZeroParam(v) {
+			res = append(res, v)
+			continue
+		}
+		switch mode {
+		case ZeroSkip:
+		case ZeroNull:
+			res = append(res, nil)
+		case ZeroPlaceholder:
+			res = append(res, tc.zeroPlaceholder())
+		}
+	}
+	return res
+}
+
+func (tc *TraceContext) zeroPlaceholder() string {
+	if p := tc.opts.zeroPlaceholder; p != "" {
+		return p
+	}
+	return DefaultZeroPlaceholder
+}
+
+// nilString is the error text of a nil param.
+func (tc *TraceContext) nilString() string {
+	if tc.opts.zeroMode == ZeroNull {
+		return "null"
+	}
+	return "nil"
+}
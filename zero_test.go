@@ -0,0 +1,46 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type profile struct {
+	Name string
+}
+
+func TestWithZeroMode(t *testing.T) {
+	var nilPtr *profile
+	params := func() []interface{} {
+		return []interface{}{"a", nil, "", profile{}, nilPtr, 0}
+	}
+	cases := []struct {
+		opt  Option
+		err  string
+		data string
+	}{
+		{WithZeroMode(ZeroKeep), "a,nil,,{Name:},nil,0", `["a",null,"",{"Name":""},null,0]`},
+		{WithZeroMode(ZeroSkip), "a,0", `["a",0]`},
+		{WithZeroMode(ZeroNull), "a,null,null,null,null,0", `["a",null,null,null,null,0]`},
+		{WithZeroPlaceholder("-"), "a,-,-,-,-,0", `["a","-","-","-","-",0]`},
+	}
+	for _, c := range cases {
+		tc := NewTraceContext(context.Background(), nil, c.opt)
+		if err := tc.Error(params()...); err.Error() != c.err {
+			t.Errorf("error text: want %q, got %q", c.err, err.Error())
+		}
+		data, _ := json.Marshal(tc.renderParams(tc.errors[0].Data))
+		if string(data) != c.data {
+			t.Errorf("log data: want %s, got %s", c.data, data)
+		}
+	}
+}
+
+func TestWithZeroMode_nilError(t *testing.T) {
+	var e *derefError
+	tc := NewTraceContext(context.Background(), nil, WithZeroMode(ZeroSkip))
+	if err := tc.Error("x", e); err.Error() != "x" {
+		t.Fatalf("want the nil error skipped, got %q", err.Error())
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/mucolud/trace/zerologtrace
+
+go 1.20
+
+require (
+	github.com/mucolud/trace v0.0.0
+	github.com/rs/zerolog v1.26.1
+)
+
+replace github.com/mucolud/trace => ../
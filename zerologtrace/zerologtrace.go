@@ -0,0 +1,73 @@
+// Package zerologtrace emits finished traces through a zerolog.Logger.
+package zerologtrace
+
+import (
+	"fmt"
+
+	"github.com/mucolud/trace"
+	"github.com/rs/zerolog"
+)
+
+// Log writes every node of tc as its own structured entry, at info, warn or
+// error level.
+func Log(logger zerolog.Logger, tc *trace.TraceContext) {
+	for _, e := range tc.Entries() {
+		event := newEvent(logger, e.Level)
+		if e.ParentSpanId != "" {
+			event = event.Str("parent_span_id", e.ParentSpanId)
+		}
+		if e.Code != 0 {
+			event = event.Int("code", e.Code).Str("category", string(e.Category))
+		}
+		event.
+			Str("trace_id", e.TraceId).
+			Str("span_id", e.SpanId).
+			Str("span", e.Span).
+			Int("depth", e.Depth).
+			Str("caller", e.Func+":"+e.Line).
+			Interface("data", e.Data).
+			Msg(message(e))
+	}
+}
+
+// LogAggregated writes the whole trace as a single entry carrying all nodes,
+// at the level of its most severe node.
+func LogAggregated(logger zerolog.Logger, tc *trace.TraceContext) {
+	entries := tc.Entries()
+	if len(entries) == 0 {
+		return
+	}
+	level := trace.LevelInfo
+	for _, e := range entries {
+		if e.Level > level {
+			level = e.Level
+		}
+	}
+	newEvent(logger, level).
+		Str("trace_id", entries[0].TraceId).
+		Str("span_id", tc.SpanID()).
+		Interface("nodes", entries).
+		Msg("trace")
+}
+
+// newEvent starts an event at the zerolog level of level.
+func newEvent(logger zerolog.Logger, level trace.Level) *zerolog.Event {
+	switch {
+	case level >= trace.LevelError:
+		return logger.Error()
+	case level == trace.LevelWarn:
+		return logger.Warn()
+	}
+	return logger.Info()
+}
+
+// message uses the first param as the log message, like tc.Info("msg", ...).
+func message(e trace.Entry) string {
+	if len(e.Data) > 0 {
+		if s, ok := e.Data[0].(string); ok {
+			return s
+		}
+		return fmt.Sprint(e.Data[0])
+	}
+	return e.Func
+}
@@ -0,0 +1,74 @@
+package zerologtrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mucolud/trace"
+	"github.com/rs/zerolog"
+)
+
+// lines decodes the JSON lines zerolog wrote to buf.
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var res []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var m map[string]interface{}
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+func TestLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := trace.NewTraceContext(context.Background(), nil)
+	tc.Info("started", 1)
+	child := tc.Trace()
+	child.Warn("skipped", 2)
+	_ = child.ErrorCode(503, errors.New("unavailable"))
+	Log(zerolog.New(buf), tc)
+
+	got := lines(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(got))
+	}
+	for i, want := range []struct {
+		level  string
+		msg    string
+		span   string
+		parent interface{}
+	}{
+		{"info", "started", tc.SpanID(), nil},
+		{"warn", "skipped", child.SpanID(), tc.SpanID()},
+		{"error", "unavailable", child.SpanID(), tc.SpanID()},
+	} {
+		e := got[i]
+		if e["level"] != want.level || e["message"] != want.msg || e["trace_id"] != tc.TraceID() ||
+			e["span_id"] != want.span || e["parent_span_id"] != want.parent {
+			t.Fatalf("entry %d: unexpected %v", i, e)
+		}
+	}
+	if got[2]["code"] != float64(503) {
+		t.Fatalf("the error code should be logged: %v", got[2])
+	}
+}
+
+func TestLogAggregated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tc := trace.NewTraceContext(context.Background(), nil)
+	tc.Info("started")
+	tc.Warn("slow")
+	LogAggregated(zerolog.New(buf), tc)
+
+	got := lines(t, buf)
+	if len(got) != 1 || got[0]["level"] != "warn" || got[0]["message"] != "trace" ||
+		got[0]["trace_id"] != tc.TraceID() || got[0]["span_id"] != tc.SpanID() {
+		t.Fatalf("want a single warn entry, got %v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zipkinKinds maps the OTLP span kinds to Zipkin's, which leaves internal
+// spans without kind.
+var zipkinKinds = map[int]string{
+	2: "SERVER",
+	3: "CLIENT",
+	4: "PRODUCER",
+	5: "CONSUMER",
+}
+
+type zipkinSpan struct {
+	TraceId        string             `json:"traceId"`
+	Id             string             `json:"id"`
+	ParentId       string             `json:"parentId,omitempty"`
+	Name           string             `json:"name"`
+	Kind           string             `json:"kind,omitempty"`
+	Timestamp      int64              `json:"timestamp,omitempty"` // unix microseconds
+	Duration       int64              `json:"duration,omitempty"`  // microseconds
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint,omitempty"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint,omitempty"`
+	Annotations    []zipkinAnnotation `json:"annotations,omitempty"`
+	Tags           map[string]string  `json:"tags,omitempty"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	IPv4        string `json:"ipv4,omitempty"`
+	IPv6        string `json:"ipv6,omitempty"`
+	Port        int    `json:"port,omitempty"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// zipkinSpans appends the spans of the subtree in pre-order. They carry the
+// attributes and events of their OTLP conversion as tags and annotations.
+func (s *spanSnapshot) zipkinSpans(res []zipkinSpan, local *zipkinEndpoint) []zipkinSpan {
+	o := s.otlpSpan()
+	span := zipkinSpan{
+		TraceId:       hexID(s.traceId),
+		Id:            hexID(s.spanId),
+		ParentId:      o.ParentSpanId,
+		Name:          o.Name,
+		Kind:          zipkinKinds[o.Kind],
+		LocalEndpoint: local,
+	}
+	if !s.start.IsZero() {
+		span.Timestamp = zipkinTime(s.start)
+		// Zipkin reads a zero duration as unknown
+		span.Duration = s.duration().Microseconds()
+		if span.Duration < 1 {
+			span.Duration = 1
+		}
+	}
+	if !s.peer.IsZero() {
+		span.RemoteEndpoint = &zipkinEndpoint{ServiceName: s.peer.Service, Port: s.peer.Port}
+		if ip := net.ParseIP(s.peer.Host); ip.To4() != nil {
+			span.RemoteEndpoint.IPv4 = ip.String()
+		} else if ip != nil {
+			span.RemoteEndpoint.IPv6 = ip.String()
+		}
+	}
+	if len(o.Attributes) > 0 {
+		span.Tags = make(map[string]string, len(o.Attributes)+1)
+		for _, kv := range o.Attributes {
+			span.Tags[kv.Key] = kv.Value.String()
+		}
+	}
+	if o.Status.Code == otlpStatusError {
+		if span.Tags == nil {
+			span.Tags = map[string]string{}
+		}
+		span.Tags["error"] = o.Status.Message
+		if o.Status.Message == "" {
+			span.Tags["error"] = "true"
+		}
+	}
+	for _, v := range o.Events {
+		value := v.Name
+		for _, kv := range v.Attributes {
+			value += " " + kv.Key + "=" + kv.Value.String()
+		}
+		t, _ := strconv.ParseInt(v.TimeUnixNano, 10, 64)
+		span.Annotations = append(span.Annotations, zipkinAnnotation{Timestamp: t / 1000, Value: value})
+	}
+	res = append(res, span)
+	for _, v := range s.children {
+		res = v.zipkinSpans(res, local)
+	}
+	return res
+}
+
+func zipkinTime(t time.Time) int64 {
+	return t.UnixNano() / 1000
+}
+
+// String renders the value as Zipkin tags hold them.
+func (v otlpAnyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'g', -1, 64)
+	}
+	return v.IntValue
+}
+
+// writeZipkin writes the trace as a single Zipkin v2 JSON array of spans.
+func writeZipkin(w io.Writer, s *spanSnapshot) (int, error) {
+	var local *zipkinEndpoint
+	if r := s.resource; r != nil && r.Service != "" {
+		local = &zipkinEndpoint{ServiceName: strings.ToLower(r.Service)}
+	}
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(s.zipkinSpans(nil, local)); err != nil {
+		return 0, err
+	}
+	return w.Write(buf.Bytes())
+}
+
+// NewZipkinExporter returns a sink posting every trace to the Zipkin v2
+// spans endpoint url of a collector, such as
+// http://localhost:9411/api/v2/spans. Set the service name with
+// WithResource.
+func NewZipkinExporter(url string) io.Writer {
+	return Formatted(newHTTPExporter("Zipkin", url), FormatZipkin)
+}
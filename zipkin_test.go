@@ -0,0 +1,72 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestZipkinExporter(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	tc := NewTraceContext(context.Background(), NewZipkinExporter(srv.URL), WithResource(Resource{Service: "Checkout"}))
+	tc.SetTag("user", 42)
+	child := tc.Trace(WithPeer("10.0.0.7", 5432), WithPeerService("billing"), WithSpanKind(SpanKindClient))
+	child.Event("charged", "amount", 1.5)
+	_ = child.Error(errors.New("declined"))
+	tc.Log()
+
+	var spans []zipkinSpan
+	if err := json.Unmarshal(<-bodies, &spans); err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	root, sub := spans[0], spans[1]
+	if root.TraceId != tc.TraceID() || sub.TraceId != root.TraceId || root.ParentId != "" || sub.ParentId != root.Id {
+		t.Fatalf("spans not linked: %+v %+v", root, sub)
+	}
+	if root.LocalEndpoint == nil || root.LocalEndpoint.ServiceName != "checkout" || root.Kind != "" || root.Tags["user"] != "42" {
+		t.Fatalf("unexpected root %+v", root)
+	}
+	if root.Timestamp == 0 || root.Duration < 1 {
+		t.Fatalf("spans should be timed: %+v", root)
+	}
+	if sub.Kind != "CLIENT" || sub.RemoteEndpoint == nil || *sub.RemoteEndpoint != (zipkinEndpoint{ServiceName: "billing", IPv4: "10.0.0.7", Port: 5432}) {
+		t.Fatalf("unexpected client span %+v", sub)
+	}
+	if sub.Tags["error"] != `["declined"]` {
+		t.Fatalf("failed span should have an error tag: %+v", sub.Tags)
+	}
+	if len(sub.Annotations) != 2 || sub.Annotations[0].Value != "charged amount=1.5" {
+		t.Fatalf("unexpected annotations %+v", sub.Annotations)
+	}
+}
+
+func TestZipkinExporter_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	var failure error
+	tc := NewTraceContext(context.Background(), NewZipkinExporter(srv.URL), WithWriteErrorHandler(func(err error) { failure = err }))
+	tc.Log()
+	if failure == nil || failure.Error() != "trace: Zipkin export to "+srv.URL+": 400 Bad Request" {
+		t.Fatalf("unexpected error %v", failure)
+	}
+}